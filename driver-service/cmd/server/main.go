@@ -2,20 +2,38 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/config"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/dispatch"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/handler"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/idempotency"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/telematics"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tracking"
 	"github.com/eneszeyt/bitaksi-driver-service/pkg/database"
 
 	_ "github.com/eneszeyt/bitaksi-driver-service/docs" // This line is crucial for swagger to find generated docs
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// docs/ is generated with the shared geo module as a second search dir, its types appear in responses:
+//
+//	swag init -g cmd/server/main.go -d ./,../geo
+
 // @title           Bitaksi Driver Service API
 // @version         1.0
 // @description     This is a sample driver service for Bitaksi Hackathon.
@@ -33,8 +51,15 @@ import (
 
 func main() {
 	cfg := config.LoadConfig()
+	if cfg.GatewaySecret == "" {
+		log.Fatal("GATEWAY_SECRET must be set, the service only serves requests forwarded by the gateway")
+	}
 	fmt.Printf("starting driver service on port %s...\n", cfg.Port)
 
+	// SIGINT and SIGTERM stop the server gracefully, queued positions are written before exit
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// connect to database
 	mongoClient, err := database.ConnectMongoDB(cfg.MongoURI)
 	if err != nil {
@@ -42,29 +67,317 @@ func main() {
 	}
 	defer mongoClient.Disconnect(context.Background())
 
+	// service areas; a new city is a configuration change
+	cityList, err := city.ParseCities(cfg.Cities)
+	if err != nil {
+		log.Fatalf("invalid CITIES: %v", err)
+	}
+	cities, err := city.NewRegistry(cityList, cfg.DefaultCity)
+	if err != nil {
+		log.Fatalf("invalid city configuration: %v", err)
+	}
+
 	// dependency injection
 	db := mongoClient.Database(cfg.DBName)
 	repo := repository.NewDriverRepository(db)
-	svc := service.NewDriverService(repo)
-	h := handler.NewDriverHandler(svc)
+	// records from before cities existed belong to the default city
+	if n, err := repo.AssignCity(context.Background(), cities.Default().Code); err != nil {
+		log.Printf("WARN: could not assign drivers to a city: %v", err)
+	} else if n > 0 {
+		log.Printf("assigned %d drivers to %s", n, cities.Default().Code)
+	}
+	locationRepo := repository.NewLocationRepository(db)
+	tripRepo := repository.NewTripRepository(db)
+	// road routing is optional; without it distances fall back to Haversine
+	var router routing.Router
+	if cfg.RoutingPBFPath != "" {
+		start := time.Now()
+		graph, err := routing.LoadPBF(context.Background(), cfg.RoutingPBFPath)
+		if err != nil {
+			log.Printf("WARN: could not load road network, using straight-line distances: %v", err)
+		} else {
+			log.Printf("road network loaded: %d nodes, %d edges in %s", graph.NodeCount(), graph.EdgeCount(), time.Since(start))
+			router = graph
+		}
+	}
+
+	// speed zones and hour buckets follow the city of each point
+	estimator := eta.NewEstimator(cfg.EtaDetourFactor, cfg.EtaDefaultSpeedKmh, cities.Zones(), cities.Default().Location())
+	estimator.SetLocator(cities.LocationAt)
+	estimator.SetRouter(router)
+	// location pings are smoothed per driver; a bad override keeps the defaults for every taxi type
+	gpsDefaults := tracking.Profile{
+		MaxSpeedKmh:      cfg.GpsMaxSpeedKmh,
+		ProcessNoise:     cfg.GpsProcessNoise,
+		DefaultAccuracyM: cfg.GpsDefaultAccuracyM,
+		Action:           cfg.GpsTeleportAction,
+		MaxRejects:       cfg.GpsMaxRejects,
+	}
+	gpsProfiles, err := tracking.ParseProfiles(cfg.GpsProfiles, gpsDefaults)
+	if err != nil {
+		log.Printf("WARN: invalid GPS_PROFILES, using defaults: %v", err)
+	}
+	gpsFilter := tracking.NewFilter(gpsProfiles, gpsDefaults)
+
+	// driver events are written to an outbox with the change and relayed to the sinks below
+	tx := repository.NewTransactor(mongoClient)
+	outboxRepo := repository.NewOutboxRepository(db, cfg.OutboxRetention)
+	events := outbox.NewWriter(tx, outboxRepo)
+
+	// positions from batch uploads are coalesced and written in bulk
+	locationWriter := repository.NewLocationWriter(repo, outboxRepo, tx, cfg.LocationFlushInterval)
+	// it outlives the server so positions accepted by the last requests are flushed too
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	flushed := make(chan struct{})
+	go func() {
+		locationWriter.Run(writerCtx)
+		close(flushed)
+	}()
+
+	// vehicles are split from drivers; older records are migrated on startup
+	vehicleRepo := repository.NewVehicleRepository(db)
+	assignmentRepo := repository.NewAssignmentRepository(db)
+	shiftRepo := repository.NewShiftRepository(db)
+
+	// taxi types come from a managed catalog that driver, vehicle and tariff writes are checked against
+	taxiTypeSvc := service.NewTaxiTypeService(repository.NewTaxiTypeRepository(db), vehicleRepo, cities)
+	if err := taxiTypeSvc.SeedDefaults(context.Background()); err != nil {
+		log.Printf("WARN: could not seed default taxi types: %v", err)
+	}
+	taxiTypeHandler := handler.NewTaxiTypeHandler(taxiTypeSvc)
+
+	// every write to drivers and vehicles is appended to the audit log
+	auditSvc := service.NewAuditService(repository.NewAuditRepository(db), repo)
+
+	vehicleSvc := service.NewVehicleService(vehicleRepo, assignmentRepo, repo, shiftRepo, taxiTypeSvc, auditSvc, events)
+	// the car fields of drivers are split into vehicles once; the marker keeps later boots from paging every driver
+	migrations := repository.NewMigrationRepository(db)
+	if done, err := migrations.Done(context.Background(), "vehicles_from_drivers"); err != nil {
+		log.Printf("WARN: could not check the vehicle migration: %v", err)
+	} else if !done {
+		if n, err := vehicleSvc.MigrateFromDrivers(context.Background()); err != nil {
+			log.Printf("WARN: vehicle migration stopped after %d drivers: %v", n, err)
+		} else {
+			log.Printf("migrated %d drivers to vehicle assignments", n)
+			if err := migrations.MarkDone(context.Background(), "vehicles_from_drivers"); err != nil {
+				log.Printf("WARN: could not record the vehicle migration: %v", err)
+			}
+		}
+	}
+	vehicleHandler := handler.NewVehicleHandler(vehicleSvc)
+
+	svc := service.NewDriverService(repo, locationRepo, estimator, router, gpsFilter, locationWriter, vehicleSvc, taxiTypeSvc, cities, auditSvc, events)
+	// onboarding vets new drivers; a background job suspends drivers with expired documents
+	statusLog := repository.NewStatusLogRepository(db)
+	onboardingSvc := service.NewOnboardingService(repo, statusLog, repository.NewDocumentRepository(db), cfg.MandatoryDocuments, auditSvc, events)
+	go onboardingSvc.Run(context.Background(), cfg.DocumentCheckInterval)
+
+	// riders review drivers after completed trips; ratings are kept on the driver
+	reviewSvc := service.NewReviewService(repository.NewReviewRepository(db), tripRepo, repo, repository.NewMarkerRepository(db), auditSvc, events)
+	go reviewSvc.Run(context.Background(), cfg.RatingRefreshInterval)
+	reviewHandler := handler.NewReviewHandler(reviewSvc)
+
+	// fleet snapshots replay the location history and status log from the last checkpoint
+	fleetSvc := service.NewFleetService(repo, locationRepo, statusLog, repository.NewCheckpointRepository(db))
+	go fleetSvc.Run(context.Background(), cfg.FleetCheckpointInterval)
+
+	h := handler.NewDriverHandler(svc, vehicleSvc, onboardingSvc, reviewSvc, auditSvc)
+	locationHandler := handler.NewLocationHandler(svc)
+
+	// shifts record who drives which plate, one driver per vehicle at a time
+	shiftHandler := handler.NewShiftHandler(service.NewShiftService(shiftRepo, repo, vehicleRepo, assignmentRepo, tripRepo, gpsFilter, auditSvc, events))
+
+	tripSvc := service.NewTripService(tripRepo, repo, locationRepo, cities)
+	tripHandler := handler.NewTripHandler(tripSvc)
+
+	tariffRepo := repository.NewTariffRepository(db)
+	if n, err := tariffRepo.AssignCity(context.Background(), cities.Default().Code); err != nil {
+		log.Printf("WARN: could not assign tariffs to a city: %v", err)
+	} else if n > 0 {
+		log.Printf("assigned %d tariffs to %s", n, cities.Default().Code)
+	}
+	fareSvc := service.NewFareService(tariffRepo, tripRepo, router, taxiTypeSvc, cities)
+	if err := fareSvc.SeedDefaults(context.Background()); err != nil {
+		log.Printf("WARN: could not seed default tariffs: %v", err)
+	}
+	fareHandler := handler.NewFareHandler(fareSvc)
+
+	etaSvc := service.NewEtaService(estimator, repository.NewSpeedProfileRepository(db), tripRepo)
+	if err := etaSvc.Load(context.Background()); err != nil {
+		log.Printf("WARN: could not load speed profiles, using defaults: %v", err)
+	}
+	etaHandler := handler.NewEtaHandler(etaSvc)
+
+	geoHandler := handler.NewGeoHandler(service.NewGeoService(estimator, router, cfg.GeoMatrixMaxElements))
+
+	// batch dispatcher runs in the background for the lifetime of the server
+	matcher := dispatch.NewMatcher(cfg.DispatchMaxPickupKm)
+	switch {
+	case cfg.DispatchCost == "eta":
+		matcher.Cost = dispatch.ETACost(estimator)
+	case router != nil:
+		matcher.Cost = dispatch.RoadDistanceCost(router)
+	}
+	dispatchSvc := service.NewDispatchService(repo, tripRepo, matcher, cities, cfg.DispatchWindow, cfg.DispatchOfferTTL, cfg.DispatchPendingTTL)
+	dispatchHandler := handler.NewDispatchHandler(dispatchSvc)
+	go dispatchSvc.Run(context.Background())
+
+	// taximeter units publish over mqtt into the same location pipeline
+	if cfg.MqttEmbeddedAddr != "" {
+		broker, err := telematics.StartBroker(cfg.MqttEmbeddedAddr)
+		if err != nil {
+			log.Fatalf("embedded mqtt broker failed: %v", err)
+		}
+		defer broker.Close()
+		log.Printf("embedded mqtt broker listening on %s", cfg.MqttEmbeddedAddr)
+	}
+	if cfg.MqttBrokerURL != "" {
+		adapter, err := telematics.NewAdapter(telematics.Config{
+			BrokerURL:     cfg.MqttBrokerURL,
+			ClientID:      cfg.MqttClientID,
+			Username:      cfg.MqttUsername,
+			Password:      cfg.MqttPassword,
+			Topic:         cfg.MqttTopic,
+			BatchInterval: cfg.LocationFlushInterval / 4,
+		}, svc)
+		if err != nil {
+			log.Fatalf("mqtt adapter: %v", err)
+		}
+		go func() {
+			if err := adapter.Run(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("WARN: mqtt ingestion stopped: %v", err)
+			}
+		}()
+	}
+
+	// the relay delivers outbox events at least once; each sink resumes from its own position
+	sinks := []outbox.Sink{outbox.NewBus()}
+	for _, url := range cfg.OutboxWebhookURLs {
+		sinks = append(sinks, outbox.NewWebhookSink(url, cfg.OutboxWebhookTimeout))
+	}
+	if cfg.OutboxFilePath != "" {
+		fileSink, err := outbox.NewFileSink(cfg.OutboxFilePath)
+		if err != nil {
+			log.Fatalf("outbox file sink: %v", err)
+		}
+		defer fileSink.Close()
+		sinks = append(sinks, fileSink)
+	}
+
+	// subscriptions registered through /webhooks get signed deliveries with retries
+	webhookSvc := service.NewWebhookService(repository.NewWebhookRepository(db), repository.NewDeliveryRepository(db), service.WebhookPolicy{
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		RetryBase:    cfg.WebhookRetryBase,
+		RetryMax:     cfg.WebhookRetryMax,
+		Retention:    cfg.OutboxRetention,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+	})
+	sinks = append(sinks, webhookSvc)
+	go webhookSvc.Run(context.Background(), cfg.WebhookDeliveryInterval)
+	go outbox.NewRelay(outboxRepo, cfg.OutboxRelayInterval, cfg.OutboxBatchSize, sinks...).Run(context.Background())
+
+	// retried creates with the same Idempotency-Key get the first response again
+	idem := idempotency.New(repository.NewIdempotencyRepository(db), cfg.IdempotencyTTL)
 
 	// --- ROUTES ---
 
-	// 1. Swagger Documentation Route
-	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+	// 1. Swagger Documentation Route, the only one served without the gateway's scope
+	public := http.NewServeMux()
+	public.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
 	// 2. /drivers -> GET (List) & POST (Create)
-	http.HandleFunc("/drivers", h.DriversRoot)
+	http.HandleFunc("/drivers", idem.Wrap(h.DriversRoot))
 
-	// 3. /drivers/nearby -> GET (Nearby Search)
+	// 3. /drivers/nearby -> GET (Nearby Search) & /drivers/changes -> GET (Delta Sync)
 	http.HandleFunc("/drivers/nearby", h.SearchNearby)
+	http.HandleFunc("/drivers/changes", h.Changes)
 
-	// 4. /drivers/ -> PUT (Update)
+	// 4. /drivers/{id} -> GET, PUT (Update), PATCH (Partial Update), DELETE (Delete) & /drivers/{id}/location -> PUT (Location Ping)
+	//    & /drivers/{id}/vehicle -> GET, PUT, DELETE & /drivers/{id}/assignments -> GET
+	//    & /drivers/{id}/status -> POST & /drivers/{id}/status-history, /drivers/{id}/documents -> GET
+	//    & /drivers/{id}/documents/{type} -> PUT & /drivers/{id}/history -> GET
 	http.HandleFunc("/drivers/", h.DriverByID)
 
-	// start server
-	addr := ":" + cfg.Port
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	// 5. /dispatch/rides -> POST (Request Ride) & /dispatch/rides/{id} -> GET (Ride Status)
+	http.HandleFunc("/dispatch/rides", idem.Wrap(dispatchHandler.Rides))
+	http.HandleFunc("/dispatch/rides/", dispatchHandler.RideByID)
+
+	// 6. /trips -> GET (List) & POST (Request) & /trips/{id}[/{action}] -> lifecycle
+	http.HandleFunc("/trips", idem.Wrap(tripHandler.TripsRoot))
+	http.HandleFunc("/trips/", tripHandler.TripByID)
+
+	// 7. /fares/estimate -> POST, /fares/tariffs -> GET & POST, /fares/trips/{id} -> GET (Re-price)
+	http.HandleFunc("/fares/estimate", fareHandler.Estimate)
+	http.HandleFunc("/fares/tariffs", fareHandler.Tariffs)
+	http.HandleFunc("/fares/trips/", fareHandler.TripFare)
+
+	// 8. /eta/profiles -> GET & PUT, /eta/profiles/learn -> POST (Learn From Trips)
+	http.HandleFunc("/eta/profiles", etaHandler.Profiles)
+	http.HandleFunc("/eta/profiles/learn", etaHandler.Learn)
+
+	// 9. /geo/matrix -> POST (Distance Matrix)
+	http.HandleFunc("/geo/matrix", geoHandler.Matrix)
+
+	// 10. /locations:batch -> POST (JSON Array or NDJSON Pings)
+	http.HandleFunc("/locations:batch", locationHandler.Batch)
+
+	// 11. /vehicles -> GET & POST, /vehicles/{id} -> GET, PUT, DELETE, /vehicles/{id}/assignments -> GET
+	http.HandleFunc("/vehicles", vehicleHandler.VehiclesRoot)
+	http.HandleFunc("/vehicles/", vehicleHandler.VehicleByID)
+
+	// 12. /shifts -> GET, /shifts/start, /shifts/end, /shifts/handover -> POST, /shifts/hours -> GET
+	http.HandleFunc("/shifts", shiftHandler.Shifts)
+	http.HandleFunc("/shifts/start", shiftHandler.Start)
+	http.HandleFunc("/shifts/end", shiftHandler.End)
+	http.HandleFunc("/shifts/handover", shiftHandler.Handover)
+	http.HandleFunc("/shifts/hours", shiftHandler.Hours)
+
+	// 13. /documents/expiring -> GET (Documents Expiring Soon)
+	http.HandleFunc("/documents/expiring", h.ExpiringDocuments)
+
+	// 14. /reviews -> GET (Moderation List) & POST (Review Trip), /reviews/{id} -> GET, /reviews/{id}/moderation -> PUT
+	http.HandleFunc("/reviews", reviewHandler.ReviewsRoot)
+	http.HandleFunc("/reviews/", reviewHandler.ReviewByID)
+
+	// 15. /taxi-types -> GET (Catalog) & POST, /taxi-types/{code} -> GET, PUT, DELETE
+	http.HandleFunc("/taxi-types", taxiTypeHandler.TaxiTypesRoot)
+	http.HandleFunc("/taxi-types/", taxiTypeHandler.TaxiTypeByCode)
+
+	// 16. /cities -> GET (Service Areas)
+	http.HandleFunc("/cities", handler.NewCityHandler(cities).Cities)
+
+	// 17. /audit -> GET (Search Audit Log)
+	http.HandleFunc("/audit", handler.NewAuditHandler(auditSvc).Search)
+
+	// 18. /webhooks -> GET & POST, /webhooks/{id} -> GET, PUT, DELETE, /webhooks/{id}/deliveries -> GET
+	//     & /webhooks/{id}/replay, /webhooks/deliveries/{id}/replay -> POST (Replay Dead Letters)
+	webhookHandler := handler.NewWebhookHandler(webhookSvc)
+	http.HandleFunc("/webhooks", webhookHandler.WebhooksRoot)
+	http.HandleFunc("/webhooks/", webhookHandler.WebhookByID)
+
+	// 19. /fleet/snapshot -> GET (Fleet At A Point In Time)
+	http.HandleFunc("/fleet/snapshot", handler.NewFleetHandler(fleetSvc).Snapshot)
+
+	// start server; every other request carries the tenant, actor and request id the gateway forwarded
+	public.Handle("/", tenant.Middleware(cfg.GatewaySecret, http.DefaultServeMux))
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: public}
+	shutdown := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		log.Println("shutting down, finishing in-flight requests...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("WARN: server shutdown: %v", err)
+		}
+		close(shutdown)
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("server failed: %v", err)
 	}
+	<-shutdown
+
+	stopWriter()
+	<-flushed
 }
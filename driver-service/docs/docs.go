@@ -24,9 +24,220 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/audit": {
+            "get": {
+                "description": "Lists recorded writes to drivers and vehicles across all fleets, newest first. Platform admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Search the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "driver or vehicle",
+                        "name": "entity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver or vehicle ID",
+                        "name": "entityId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "create, update, delete, status_change, vehicle_assign or vehicle_unassign",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User who made the change, system for jobs",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID as returned in X-Request-ID",
+                        "name": "requestId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339), exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/cities": {
+            "get": {
+                "description": "Returns the configured service areas with their search radius, heartbeat window and time zone",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "List cities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/city.City"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dispatch/rides": {
+            "post": {
+                "description": "Queues a ride request; pending requests are matched to drivers in batches",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dispatch"
+                ],
+                "summary": "Request a ride",
+                "parameters": [
+                    {
+                        "description": "Pickup point, optional taxi type and required attributes",
+                        "name": "ride",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RideRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dispatch/rides/{id}": {
+            "get": {
+                "description": "Returns the ride request status and the driver offer once it is matched; requests no driver was found for in time end up expired",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dispatch"
+                ],
+                "summary": "Get ride request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ride Request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RideRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/expiring": {
+            "get": {
+                "description": "Lists documents of all drivers that expire within the given number of days, expired ones included",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onboarding"
+                ],
+                "summary": "Expiring documents",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Days ahead (default 30)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DriverDocument"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/drivers": {
             "get": {
-                "description": "Get all drivers with pagination",
+                "description": "Get all drivers with pagination, optionally in one city",
                 "consumes": [
                     "application/json"
                 ],
@@ -49,6 +260,12 @@ const docTemplate = `{
                         "description": "Page size",
                         "name": "pageSize",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City code",
+                        "name": "city",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -64,7 +281,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Registers a new taxi driver in the database",
+                "description": "Registers a new taxi driver in the database in the pending state. A plate assigns the vehicle with that plate, registering it when unknown.",
                 "consumes": [
                     "application/json"
                 ],
@@ -84,6 +301,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.Driver"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -95,13 +318,53 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/changes": {
+            "get": {
+                "description": "Returns drivers created, updated or deleted since the token, oldest change first, with the token to send next time.\nStart without since to receive every driver; keep calling while more is true. Deleted drivers come with deletedAt set,\nrejected and suspended ones as updates with their status. Changes of the last few seconds are held back.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Drivers changed since a token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the previous response",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Drivers per response, 100 by default and at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DriverChanges"
+                        }
                     }
                 }
             }
         },
         "/drivers/nearby": {
             "get": {
-                "description": "Calculates distance using Haversine formula and returns drivers within 6km radius",
+                "description": "Calculates distance using Haversine formula and returns approved drivers within the city's search radius with their ETA.\nThe city is the one given or the one containing the point.",
                 "consumes": [
                     "application/json"
                 ],
@@ -132,6 +395,30 @@ const docTemplate = `{
                         "description": "Taxi Type (e.g. yellow, black)",
                         "name": "taxiType",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by distance (default), eta or rating (ETA weighted by rating)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only drivers with at least this average rating",
+                        "name": "minRating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated attributes the driver must all offer, e.g. wheelchair_accessible,pet_friendly",
+                        "name": "requires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City code (e.g. istanbul), inferred from the point when empty",
+                        "name": "city",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -148,8 +435,44 @@ const docTemplate = `{
             }
         },
         "/drivers/{id}": {
+            "get": {
+                "description": "Returns the driver with the current vehicle. The ETag carries the version and the time of the position; with a matching If-None-Match the answer is 304.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Get a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from an earlier GET",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Driver"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            },
             "put": {
-                "description": "Updates existing driver information by ID",
+                "description": "Replaces existing driver information by ID. The plate moves the driver to that vehicle; an empty plate unassigns.\nIf-Match must carry the ETag of the driver as last read; 412 means someone else changed it in between. Position updates since the read do not count as changes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -168,6 +491,13 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from GET, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "description": "Driver Data",
                         "name": "driver",
@@ -187,41 +517,3129 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.Driver": {
-            "type": "object",
+            },
+            "delete": {
+                "description": "Removes a driver who is off shift and ends their vehicle assignment. The changes feed reports the driver with deletedAt set;\nshifts, reviews, the location history and the audit trail are kept",
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Delete a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Applies the body as a JSON merge patch (RFC 7396) to the stored driver: present members replace, null removes, objects merge. If-Match works as for PUT.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Patch a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from GET, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "driver",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Driver"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/assignments": {
+            "get": {
+                "description": "Lists the vehicles the driver worked with and when, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Driver assignment history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.VehicleAssignment"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/documents": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onboarding"
+                ],
+                "summary": "List driver documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DriverDocument"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/documents/{type}": {
+            "put": {
+                "description": "Replaces the driver's document of the type: license, psychotechnic, vehicle_inspection or insurance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onboarding"
+                ],
+                "summary": "Store a driver document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Number, issue and expiry dates",
+                        "name": "document",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DriverDocument"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DriverDocument"
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/history": {
+            "get": {
+                "description": "Lists who changed the driver, when and how: creation, updates, status changes and vehicle assignments with the fields before and after, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Change history of a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/location": {
+            "put": {
+                "description": "Runs the ping through the driver's GPS filter, moves the driver to the smoothed position and stores raw and smoothed positions in the location history. Pings implying an impossible speed are rejected with 422 (or only flagged, depending on the taxi type).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Update driver location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current Position",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LocationUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocationPing"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocationPing"
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/reviews": {
+            "get": {
+                "description": "Lists the driver's visible reviews, newest first. The aggregates are on the driver as rating.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Reviews of a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Review"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/status": {
+            "post": {
+                "description": "Moves a driver between pending, approved, suspended and rejected. Suspending and rejecting need a reason; approving needs valid mandatory documents.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onboarding"
+                ],
+                "summary": "Change onboarding status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StatusChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DriverStatusChange"
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/status-history": {
+            "get": {
+                "description": "Lists the driver's status transitions with reasons, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onboarding"
+                ],
+                "summary": "Onboarding history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DriverStatusChange"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/drivers/{id}/vehicle": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Current vehicle of a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Vehicle"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Ends the driver's current assignment and starts one with the given vehicle",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Assign a vehicle to a driver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VehicleAssignment"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drivers"
+                ],
+                "summary": "Unassign a driver's vehicle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VehicleAssignment"
+                        }
+                    }
+                }
+            }
+        },
+        "/eta/profiles": {
+            "get": {
+                "description": "Returns the stored ETA speed profiles; the built-in hourly curve applies where none match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "eta"
+                ],
+                "summary": "List speed profiles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SpeedProfile"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the ETA speed table; weekday and hour -1 mean any, empty zone means city-wide",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "eta"
+                ],
+                "summary": "Replace speed profiles",
+                "parameters": [
+                    {
+                        "description": "Speed Profiles",
+                        "name": "profiles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SpeedProfile"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/eta/profiles/learn": {
+            "post": {
+                "description": "Rebuilds the learned ETA speed profiles from recently completed trips; profiles set by hand are kept and take precedence",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "eta"
+                ],
+                "summary": "Learn speed profiles",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Days of trip history (default 30)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Trips needed per profile (default 5)",
+                        "name": "minSamples",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SpeedProfile"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/fares/estimate": {
+            "post": {
+                "description": "Prices a trip from pickup to dropoff with the tariff in force at the given time (default now)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fares"
+                ],
+                "summary": "Estimate a fare",
+                "parameters": [
+                    {
+                        "description": "Pickup, dropoff and taxi type",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FareEstimateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FareEstimate"
+                        }
+                    }
+                }
+            }
+        },
+        "/fares/tariffs": {
+            "get": {
+                "description": "Returns every tariff version, optionally for one city and taxi type",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fares"
+                ],
+                "summary": "List tariffs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City code",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Taxi Type",
+                        "name": "taxiType",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tariff"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores a new tariff version for a taxi type, effective from the given date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fares"
+                ],
+                "summary": "Add a tariff version",
+                "parameters": [
+                    {
+                        "description": "Tariff",
+                        "name": "tariff",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Tariff"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tariff"
+                        }
+                    }
+                }
+            }
+        },
+        "/fares/trips/{id}": {
+            "get": {
+                "description": "Prices a completed trip with the tariff that was in force when it was requested",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fares"
+                ],
+                "summary": "Re-price a trip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FareEstimate"
+                        }
+                    }
+                }
+            }
+        },
+        "/fleet/snapshot": {
+            "get": {
+                "description": "Rebuilds the last reported position and the onboarding status of every driver that existed at the given instant\nfrom the location history and the status log. Fleet operators see their own drivers.\nSnapshots are rebuilt from the last fleet checkpoint, an instant before the first checkpoint is rejected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fleet"
+                ],
+                "summary": "Fleet at a point in time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instant (RFC3339), now when empty",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FleetSnapshot"
+                        }
+                    }
+                }
+            }
+        },
+        "/geo/matrix": {
+            "post": {
+                "description": "Returns distance and ETA from every origin to every destination, using road routing when configured",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "geo"
+                ],
+                "summary": "Distance matrix",
+                "parameters": [
+                    {
+                        "description": "Origins and destinations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MatrixRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MatrixResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/locations:batch": {
+            "post": {
+                "description": "Accepts a JSON array or an NDJSON stream of pings (driverId, lat, lon, ts) and reports the outcome of every item in input order. Pings run through the same GPS filter as single updates; accepted positions are reported as queued and written in bulk shortly after.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Batch location upload",
+                "parameters": [
+                    {
+                        "description": "Pings",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LocationBatchItem"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LocationBatchResult"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews": {
+            "get": {
+                "description": "Get reviews with pagination for moderation, optionally filtered by driver and status, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "List reviews",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "driverId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "visible or hidden",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Review"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Rates the driver of a completed trip with 1 to 5 stars, optional tags and a comment. A trip can be reviewed once, by its rider. Updates the driver's rating.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Review a trip",
+                "parameters": [
+                    {
+                        "description": "tripId, riderId, stars, tags and comment",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Review"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Review"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Get a review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Review"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}/moderation": {
+            "put": {
+                "description": "Hides a review (reason required) or makes it visible again. Hidden reviews do not count towards the driver's rating.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Moderate a review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Review"
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts": {
+            "get": {
+                "description": "Get shifts with pagination, newest first, optionally filtered by driver, vehicle and running state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shifts"
+                ],
+                "summary": "List shifts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "driverId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vehicle ID",
+                        "name": "vehicleId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only running shifts",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Shift"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/end": {
+            "post": {
+                "description": "Takes a driver off duty; not allowed during an active trip",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shifts"
+                ],
+                "summary": "End a shift",
+                "parameters": [
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Shift"
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/handover": {
+            "post": {
+                "description": "Ends the running shift of the vehicle and starts the incoming driver's, who takes over the vehicle's live location and on duty status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shifts"
+                ],
+                "summary": "Hand a vehicle over",
+                "parameters": [
+                    {
+                        "description": "Vehicle and incoming driver",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HandoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Shift"
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/hours": {
+            "get": {
+                "description": "Sums time on shift per driver between from and to (RFC3339, default the last 7 days); running shifts count until now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shifts"
+                ],
+                "summary": "Hours on shift",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the period",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the period",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "driverId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ShiftHours"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/start": {
+            "post": {
+                "description": "Puts a driver on duty with the vehicle they are assigned to; a vehicle has one driver on shift at a time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shifts"
+                ],
+                "summary": "Start a shift",
+                "parameters": [
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Shift"
+                        }
+                    }
+                }
+            }
+        },
+        "/taxi-types": {
+            "get": {
+                "description": "Returns the catalog in display order, e.g. for the map filter buttons; city limits it to the types offered there",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "taxi-types"
+                ],
+                "summary": "List taxi types",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City code",
+                        "name": "city",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TaxiType"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a type to the catalog. The code is lower case and cannot change; tariffCode lets the type use another type's tariff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "taxi-types"
+                ],
+                "summary": "Add a taxi type",
+                "parameters": [
+                    {
+                        "description": "Catalog entry",
+                        "name": "taxiType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxiType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxiType"
+                        }
+                    }
+                }
+            }
+        },
+        "/taxi-types/{code}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "taxi-types"
+                ],
+                "summary": "Get a taxi type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Taxi type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxiType"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Changes names, color, seats, tariff reference and order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "taxi-types"
+                ],
+                "summary": "Update a taxi type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Taxi type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Catalog entry",
+                        "name": "taxiType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxiType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaxiType"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a type no vehicle is registered with and no other type takes its tariff from",
+                "tags": [
+                    "taxi-types"
+                ],
+                "summary": "Delete a taxi type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Taxi type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/trips": {
+            "get": {
+                "description": "Get trips with pagination, optionally filtered by driver, status and city",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "List trips",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Driver ID",
+                        "name": "driverId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trip status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City code",
+                        "name": "city",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Trip"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a trip in the requested state with pickup and dropoff points",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "Request a trip",
+                "parameters": [
+                    {
+                        "description": "Rider, pickup and dropoff",
+                        "name": "trip",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "Get a trip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{id}/accept": {
+            "post": {
+                "description": "Assigns a free driver to a requested trip",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "Accept a trip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{id}/cancel": {
+            "post": {
+                "description": "Cancels a trip that has not started yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "Cancel a trip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "{\\",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{id}/{action}": {
+            "post": {
+                "description": "Moves a trip to arrived, started or completed; completion computes the traveled distance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trips"
+                ],
+                "summary": "Advance a trip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trip ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "arrive, start or complete",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Trip"
+                        }
+                    }
+                }
+            }
+        },
+        "/vehicles": {
+            "get": {
+                "description": "Get vehicles with pagination, ordered by plate",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "List vehicles",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Vehicle"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a taxi; the plate is stored upper case without spaces and must be unique",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "Register a vehicle",
+                "parameters": [
+                    {
+                        "description": "Vehicle",
+                        "name": "vehicle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Vehicle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/vehicles/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "Get a vehicle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vehicle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Vehicle"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Changes a vehicle; drivers currently assigned to it see the new data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "Update a vehicle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vehicle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Vehicle",
+                        "name": "vehicle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Vehicle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a vehicle that no driver is assigned to; the assignment history is kept",
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "Delete a vehicle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vehicle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/vehicles/{id}/assignments": {
+            "get": {
+                "description": "Lists which drivers worked with the vehicle and when, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vehicles"
+                ],
+                "summary": "Vehicle assignment history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vehicle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.VehicleAssignment"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookSubscription"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers a URL that receives matching events as signed POST requests. The response carries the secret, it is not shown again.\nX-Webhook-Signature is \"sha256=\" followed by the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body.\nThe URL must resolve to a public address; redirects are not followed. Fleet operators receive events of their own drivers only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Subscribe to driver events",
+                "parameters": [
+                    {
+                        "description": "URL, event types (all when empty) and optional secret",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/replay": {
+            "post": {
+                "description": "Queues one delivery again with fresh attempts, whether it is dead or was delivered",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay a delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookSubscription"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces url and event types. active and the secret change only when they are given; inactive subscriptions keep queueing without sending.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "URL, event types, and optionally active and a new secret",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the subscription with its pending and dead deliveries",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Lists deliveries newest first with attempts and the last error; status=dead lists the dead letters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay": {
+            "post": {
+                "description": "Queues every dead delivery of the subscription again with fresh attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay dead deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "city.City": {
+            "type": "object",
+            "properties": {
+                "bounds": {
+                    "description": "coordinates inside belong to the city",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/geo.Box"
+                        }
+                    ]
+                },
+                "code": {
+                    "description": "e.g. \"istanbul\", stored on drivers, tariffs and trips",
+                    "type": "string"
+                },
+                "heartbeat": {
+                    "description": "Heartbeat hides drivers whose last position is older; 0 keeps them regardless",
+                    "type": "string",
+                    "example": "10m"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "radiusKm": {
+                    "description": "nearby search radius",
+                    "type": "number"
+                },
+                "timeZone": {
+                    "description": "IANA name, e.g. \"Europe/Istanbul\"",
+                    "type": "string"
+                },
+                "zones": {
+                    "description": "Zones split the city for ETA speed profiles; the first match wins",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/eta.Zone"
+                    }
+                }
+            }
+        },
+        "eta.Zone": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "set from the city the zone is configured in",
+                    "type": "string"
+                },
+                "maxLat": {
+                    "type": "number"
+                },
+                "maxLon": {
+                    "type": "number"
+                },
+                "minLat": {
+                    "type": "number"
+                },
+                "minLon": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "geo.Box": {
+            "type": "object",
+            "properties": {
+                "maxLat": {
+                    "type": "number"
+                },
+                "maxLon": {
+                    "type": "number"
+                },
+                "minLat": {
+                    "type": "number"
+                },
+                "minLon": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Attribute": {
+            "type": "string",
+            "enum": [
+                "wheelchair_accessible",
+                "large_trunk",
+                "child_seat",
+                "pet_friendly",
+                "english_speaking"
+            ],
+            "x-enum-varnames": [
+                "AttrWheelchairAccessible",
+                "AttrLargeTrunk",
+                "AttrChildSeat",
+                "AttrPetFriendly",
+                "AttrEnglishSpeaking"
+            ]
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "description": "user from the gateway token, \"system\" for jobs",
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "entity": {
+                    "description": "\"driver\" or \"vehicle\"",
+                    "type": "string"
+                },
+                "entityId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator owning the record",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Driver": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "description": "Attributes are the driver's own, VehicleAttributes mirror the current vehicle",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attribute"
+                    }
+                },
+                "carBrand": {
+                    "type": "string"
+                },
+                "carModel": {
+                    "type": "string"
+                },
+                "city": {
+                    "description": "licensing city, inferred from the location when not given",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "DeletedAt marks a deleted driver; the tombstone is only served by the changes feed",
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "locationAt": {
+                    "description": "LocationAt is the time of the last position update, checked against the city's heartbeat",
+                    "type": "string"
+                },
+                "onShift": {
+                    "description": "maintained by shift start, end and handover",
+                    "type": "boolean"
+                },
+                "plate": {
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "aggregated from visible reviews",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DriverRating"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "onboarding state, e.g. \"pending\", \"approved\"",
+                    "type": "string"
+                },
+                "taxiType": {
+                    "description": "e.g., \"yellow\", \"black\"",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator the driver belongs to, empty for drivers of the platform itself",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "vehicle": {
+                    "description": "resolved on read",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Vehicle"
+                        }
+                    ]
+                },
+                "vehicleAttributes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attribute"
+                    }
+                },
+                "version": {
+                    "description": "Version increases on every write except position updates and is served as the ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DriverChanges": {
+            "type": "object",
+            "properties": {
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Driver"
+                    }
+                },
+                "more": {
+                    "type": "boolean"
+                },
+                "next": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DriverDocument": {
+            "type": "object",
+            "properties": {
+                "driverId": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issuedAt": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the driver",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DriverRating": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
+                },
+                "average30d": {
+                    "description": "reviews of the last 30 days",
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "count30d": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DriverStatusChange": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "\"system\" for automatic suspensions",
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the driver",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Event": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "data": {
+                    "description": "Data is the driver for created, updated and deleted, the DriverStatusChange for\nstatus_changed and a DriverMoved for location_moved",
+                    "type": "object"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FareEstimate": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "distanceKm": {
+                    "type": "number"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FareLine"
+                    }
+                },
+                "minimumApplied": {
+                    "type": "boolean"
+                },
+                "night": {
+                    "type": "boolean"
+                },
+                "tariffVersion": {
+                    "type": "integer"
+                },
+                "taxiType": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "waitingMinutes": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.FareEstimateRequest": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "defaults to now",
+                    "type": "string"
+                },
+                "city": {
+                    "description": "inferred from the pickup point when empty",
+                    "type": "string"
+                },
+                "dropoff": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "pickup": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "surcharges": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "taxiType": {
+                    "type": "string"
+                },
+                "waitingMinutes": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.FareLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {},
+                "field": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FleetPosition": {
+            "type": "object",
+            "properties": {
+                "driverId": {
+                    "type": "string"
+                },
+                "location": {
+                    "description": "nil until the driver reported a position",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Location"
+                        }
+                    ]
+                },
+                "locationAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FleetSnapshot": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "checkpointAt": {
+                    "type": "string"
+                },
+                "drivers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FleetPosition"
+                    }
+                }
+            }
+        },
+        "models.HandoverRequest": {
+            "type": "object",
+            "properties": {
+                "toDriverId": {
+                    "type": "string"
+                },
+                "vehicleId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Location": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.LocationBatchItem": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "description": "meters, optional",
+                    "type": "number"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "ts": {
+                    "description": "defaults to the time of arrival, at most 30s ahead of the server clock",
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocationBatchResult": {
+            "type": "object",
+            "properties": {
+                "driverId": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "flag": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "status": {
+                    "description": "\"queued\", \"flagged\", \"rejected\" or \"error\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocationPing": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "type": "number"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "flag": {
+                    "description": "\"teleport\" or \"out_of_order\"",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "raw": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "rejected": {
+                    "type": "boolean"
+                },
+                "speedKmh": {
+                    "type": "number"
+                },
+                "ts": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocationUpdate": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "description": "meters, optional",
+                    "type": "number"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "ts": {
+                    "description": "defaults to the time of arrival, at most 30s ahead of the server clock",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MatrixElement": {
+            "type": "object",
+            "properties": {
+                "distanceKm": {
+                    "type": "number"
+                },
+                "etaMinutes": {
+                    "type": "number"
+                },
+                "routed": {
+                    "description": "true when the distance comes from the road network",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.MatrixRequest": {
+            "type": "object",
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Location"
+                    }
+                },
+                "origins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Location"
+                    }
+                }
+            }
+        },
+        "models.MatrixResponse": {
+            "type": "object",
+            "properties": {
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.MatrixElement"
+                        }
+                    }
+                }
+            }
+        },
+        "models.ModerationRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"visible\" or \"hidden\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Offer": {
+            "type": "object",
+            "properties": {
+                "distanceKm": {
+                    "type": "number"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "offeredAt": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Review": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "driverId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "moderatedAt": {
+                    "type": "string"
+                },
+                "moderationReason": {
+                    "type": "string"
+                },
+                "riderId": {
+                    "type": "string"
+                },
+                "stars": {
+                    "description": "1 to 5",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "hidden reviews stay stored but do not count towards the rating",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the driver",
+                    "type": "string"
+                },
+                "tripId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RideRequest": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "inferred from the pickup point when empty",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "offer": {
+                    "$ref": "#/definitions/models.Offer"
+                },
+                "pickup": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "requestedAt": {
+                    "type": "string"
+                },
+                "requires": {
+                    "description": "the driver must offer all of them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attribute"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "taxiType": {
+                    "description": "empty means any type",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "the fleet operator that requested the ride",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Shift": {
+            "type": "object",
             "properties": {
-                "carBrand": {
+                "active": {
+                    "type": "boolean"
+                },
+                "driverId": {
                     "type": "string"
                 },
-                "carModel": {
+                "endReason": {
+                    "description": "\"ended\" or \"handover\"",
+                    "type": "string"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "handoverFrom": {
+                    "description": "HandoverFrom is the driver whose shift this one took over",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "plate": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the driver",
+                    "type": "string"
+                },
+                "vehicleId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShiftHours": {
+            "type": "object",
+            "properties": {
+                "driverId": {
+                    "type": "string"
+                },
+                "hours": {
+                    "type": "number"
+                },
+                "shifts": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SpeedProfile": {
+            "type": "object",
+            "properties": {
+                "hour": {
+                    "description": "0-23, -1 = any",
+                    "type": "integer"
+                },
+                "samples": {
+                    "description": "completed trips behind a learned profile, 0 when set by hand",
+                    "type": "integer"
+                },
+                "speedKmh": {
+                    "type": "number"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "weekday": {
+                    "description": "0 = Sunday, -1 = any",
+                    "type": "integer"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StatusChangeRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tariff": {
+            "type": "object",
+            "properties": {
+                "bridgeSurcharge": {
+                    "description": "BridgeSurcharge is added when the trip crosses the Bosphorus",
+                    "type": "number"
+                },
+                "city": {
                     "type": "string"
                 },
                 "createdAt": {
                     "type": "string"
                 },
-                "firstName": {
+                "currency": {
+                    "type": "string"
+                },
+                "effectiveFrom": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "lastName": {
+                "minimumFare": {
+                    "type": "number"
+                },
+                "nightEndHour": {
+                    "type": "integer"
+                },
+                "nightMultiplier": {
+                    "description": "night tariff applies between NightStartHour and NightEndHour local time",
+                    "type": "number"
+                },
+                "nightStartHour": {
+                    "type": "integer"
+                },
+                "openingFee": {
+                    "type": "number"
+                },
+                "perKm": {
+                    "type": "number"
+                },
+                "perMinuteWaiting": {
+                    "type": "number"
+                },
+                "surcharges": {
+                    "description": "Surcharges are named tolls (e.g. \"airport\", \"highway\") the caller can ask for",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "taxiType": {
                     "type": "string"
                 },
-                "location": {
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaxiType": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "description": "Cities lists where the type operates, every city when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "description": "lower case, e.g. \"turquoise\"; cannot change",
+                    "type": "string"
+                },
+                "color": {
+                    "description": "hex, e.g. \"#FFD100\"",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "nameEn": {
+                    "type": "string"
+                },
+                "nameTr": {
+                    "type": "string"
+                },
+                "order": {
+                    "description": "position of the filter button",
+                    "type": "integer"
+                },
+                "seats": {
+                    "description": "passenger capacity",
+                    "type": "integer"
+                },
+                "tariffCode": {
+                    "description": "TariffCode is the taxi type whose tariff prices trips, the code itself when empty",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Trip": {
+            "type": "object",
+            "properties": {
+                "acceptedAt": {
+                    "type": "string"
+                },
+                "arrivedAt": {
+                    "type": "string"
+                },
+                "cancelReason": {
+                    "type": "string"
+                },
+                "cancelledAt": {
+                    "type": "string"
+                },
+                "city": {
+                    "description": "inferred from the pickup point when empty",
+                    "type": "string"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "distanceKm": {
+                    "description": "traveled distance, computed on completion",
+                    "type": "number"
+                },
+                "driverId": {
+                    "description": "set when a driver accepts",
+                    "type": "string"
+                },
+                "dropoff": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pickup": {
                     "$ref": "#/definitions/models.Location"
                 },
+                "requestedAt": {
+                    "description": "one timestamp per transition",
+                    "type": "string"
+                },
+                "riderId": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "taxiType": {
+                    "description": "requested type, or the accepting driver's",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the accepting driver; requested trips belong to no one yet",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Vehicle": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "description": "only vehicle scoped attributes, e.g. \"wheelchair_accessible\"",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attribute"
+                    }
+                },
+                "carBrand": {
+                    "type": "string"
+                },
+                "carModel": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
                 "plate": {
+                    "description": "stored upper case without spaces, e.g. \"34ABC123\"",
                     "type": "string"
                 },
                 "taxiType": {
-                    "description": "e.g., \"yellow\", \"black\"",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator owning the vehicle, empty for vehicles of the platform itself",
                     "type": "string"
                 },
                 "updatedAt": {
@@ -229,14 +3647,123 @@ const docTemplate = `{
                 }
             }
         },
-        "models.Location": {
+        "models.VehicleAssignment": {
             "type": "object",
             "properties": {
-                "lat": {
-                    "type": "number"
+                "active": {
+                    "type": "boolean"
                 },
-                "lon": {
-                    "type": "number"
+                "driverId": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator of the driver",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "vehicleId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/models.Event"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastStatusCode": {
+                    "type": "integer"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscriptionId": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookSubscription": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "event types to send, every type when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Secret signs deliveries; generated when not given and only returned when set",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "description": "TenantID is the fleet operator whose events are sent, every tenant for platform subscriptions",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookUpdate": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,204 @@
+package city
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	// time zones are looked up by name; embed the database so the binary does not depend on the host
+	_ "time/tzdata"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// ErrUnknownCity is returned for a city code that is not configured
+var ErrUnknownCity = errors.New("unknown city")
+
+// City is one service area with its own search settings.
+// Adding a city is a matter of configuration, see ParseCities.
+type City struct {
+	Code     string  `json:"code"` // e.g. "istanbul", stored on drivers, tariffs and trips
+	Name     string  `json:"name"`
+	TimeZone string  `json:"timeZone"` // IANA name, e.g. "Europe/Istanbul"
+	Bounds   geo.Box `json:"bounds"`   // coordinates inside belong to the city
+	RadiusKm float64 `json:"radiusKm"` // nearby search radius
+	// Heartbeat hides drivers whose last position is older; 0 keeps them regardless
+	Heartbeat Duration `json:"heartbeat" swaggertype:"string" example:"10m"`
+	// Zones split the city for ETA speed profiles; the first match wins
+	Zones []eta.Zone `json:"zones,omitempty"`
+
+	loc *time.Location
+}
+
+// Location returns the city's time zone
+func (c *City) Location() *time.Location {
+	return c.loc
+}
+
+// Duration reads "10m" style values from JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultCities is the original single service area
+var DefaultCities = []City{
+	{
+		Code:     "istanbul",
+		Name:     "İstanbul",
+		TimeZone: "Europe/Istanbul",
+		Bounds:   geo.Box{MinLat: 40.80, MinLon: 27.95, MaxLat: 41.60, MaxLon: 29.95},
+		RadiusKm: 6,
+		Zones:    eta.DefaultZones,
+	},
+}
+
+// ParseCities reads a JSON array of cities, e.g.
+// [{"code":"ankara","name":"Ankara","timeZone":"Europe/Istanbul","bounds":{...},"radiusKm":5,"heartbeat":"10m"}]
+// and merges it over DefaultCities: a city with a default code replaces that city, others are added.
+func ParseCities(raw string) ([]City, error) {
+	cities := append([]City(nil), DefaultCities...)
+	if raw == "" {
+		return cities, nil
+	}
+	var configured []City
+	if err := json.Unmarshal([]byte(raw), &configured); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(configured))
+	for _, c := range configured {
+		if seen[c.Code] {
+			return nil, fmt.Errorf("city %q: configured twice", c.Code)
+		}
+		seen[c.Code] = true
+
+		replaced := false
+		for i := range cities {
+			if cities[i].Code == c.Code {
+				cities[i], replaced = c, true
+				break
+			}
+		}
+		if !replaced {
+			cities = append(cities, c)
+		}
+	}
+	return cities, nil
+}
+
+// Registry answers which city a request or a point belongs to
+type Registry struct {
+	cities   []*City
+	byCode   map[string]*City
+	fallback *City
+}
+
+// NewRegistry checks the cities and loads their time zones; defaultCode is used for points outside every city
+func NewRegistry(cities []City, defaultCode string) (*Registry, error) {
+	r := &Registry{byCode: make(map[string]*City, len(cities))}
+	for i := range cities {
+		c := cities[i]
+		if c.Code == "" {
+			return nil, fmt.Errorf("city %d: code is required", i)
+		}
+		if _, dup := r.byCode[c.Code]; dup {
+			return nil, fmt.Errorf("city %q: configured twice", c.Code)
+		}
+		if c.RadiusKm <= 0 {
+			return nil, fmt.Errorf("city %q: radiusKm must be positive", c.Code)
+		}
+		loc, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("city %q: %w", c.Code, err)
+		}
+		c.loc = loc
+		// copy so tagging the zones does not touch shared defaults
+		c.Zones = append([]eta.Zone(nil), c.Zones...)
+		for j := range c.Zones {
+			c.Zones[j].City = c.Code
+		}
+		r.cities = append(r.cities, &c)
+		r.byCode[c.Code] = &c
+	}
+
+	fallback, ok := r.byCode[defaultCode]
+	if !ok {
+		return nil, fmt.Errorf("default city %q is not configured", defaultCode)
+	}
+	r.fallback = fallback
+	return r, nil
+}
+
+// Get returns a city by code
+func (r *Registry) Get(code string) (*City, error) {
+	c, ok := r.byCode[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCity, code)
+	}
+	return c, nil
+}
+
+// Locate returns the city containing the point, or nil
+func (r *Registry) Locate(lat, lon float64) *City {
+	for _, c := range r.cities {
+		if c.Bounds.Contains(lat, lon) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Resolve picks the city of a request: the explicit code when given, otherwise the one
+// containing the point, otherwise the default city
+func (r *Registry) Resolve(code string, lat, lon float64) (*City, error) {
+	if code != "" {
+		return r.Get(code)
+	}
+	if c := r.Locate(lat, lon); c != nil {
+		return c, nil
+	}
+	return r.fallback, nil
+}
+
+// Default returns the city used when nothing else decides
+func (r *Registry) Default() *City {
+	return r.fallback
+}
+
+// All returns the configured cities in configuration order
+func (r *Registry) All() []*City {
+	return r.cities
+}
+
+// Zones returns the ETA zones of every city
+func (r *Registry) Zones() []eta.Zone {
+	var zones []eta.Zone
+	for _, c := range r.cities {
+		zones = append(zones, c.Zones...)
+	}
+	return zones
+}
+
+// LocationAt returns the time zone of the city containing the point, the default city's otherwise
+func (r *Registry) LocationAt(lat, lon float64) *time.Location {
+	if c := r.Locate(lat, lon); c != nil {
+		return c.loc
+	}
+	return r.fallback.loc
+}
@@ -0,0 +1,29 @@
+package city
+
+import "testing"
+
+func TestParseCitiesMergesOverDefaults(t *testing.T) {
+	cities, err := ParseCities(`[{"code":"istanbul","name":"İstanbul","timeZone":"Europe/Istanbul","radiusKm":3},
+		{"code":"ankara","name":"Ankara","timeZone":"Europe/Istanbul","radiusKm":5}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 2 || cities[0].Code != "istanbul" || cities[1].Code != "ankara" {
+		t.Fatalf("cities = %+v, want istanbul then ankara", cities)
+	}
+	if cities[0].RadiusKm != 3 {
+		t.Fatalf("istanbul radius = %v, want the configured 3", cities[0].RadiusKm)
+	}
+	if DefaultCities[0].RadiusKm != 6 {
+		t.Fatal("merging changed DefaultCities")
+	}
+
+	cities, err = ParseCities(`[{"code":"izmir","name":"İzmir","timeZone":"Europe/Istanbul","radiusKm":4}]`)
+	if err != nil || len(cities) != 2 || cities[0].Code != "istanbul" {
+		t.Fatalf("adding a city dropped the defaults: %+v, %v", cities, err)
+	}
+
+	if _, err := ParseCities(`[{"code":"izmir"},{"code":"izmir"}]`); err == nil {
+		t.Fatal("a city configured twice was accepted")
+	}
+}
@@ -0,0 +1,156 @@
+package dispatch
+
+import "math"
+
+// Infeasible marks a request/driver pair that must never be assigned
+var Infeasible = math.Inf(1)
+
+// AssignOptimal solves the min-cost assignment problem with the Hungarian algorithm.
+// cost[i][j] is the cost of giving row i (request) column j (driver).
+// It returns, for every row, the assigned column or -1 when the row stays unassigned.
+func AssignOptimal(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	if cols == 0 {
+		return result
+	}
+
+	// the algorithm below needs rows <= cols, so solve the transposed problem otherwise
+	if rows > cols {
+		transposed := make([][]float64, cols)
+		for j := range transposed {
+			transposed[j] = make([]float64, rows)
+			for i := 0; i < rows; i++ {
+				transposed[j][i] = cost[i][j]
+			}
+		}
+		for j, i := range AssignOptimal(transposed) {
+			if i >= 0 {
+				result[i] = j
+			}
+		}
+		return result
+	}
+
+	// infeasible pairs get a penalty larger than any real solution so they are picked last
+	penalty := 1.0
+	for i := range cost {
+		for _, c := range cost[i] {
+			if !math.IsInf(c, 1) {
+				penalty += math.Abs(c)
+			}
+		}
+	}
+	at := func(i, j int) float64 {
+		if math.IsInf(cost[i][j], 1) {
+			return penalty
+		}
+		return cost[i][j]
+	}
+
+	// potentials based O(n^2 * m) implementation, 1-indexed with a virtual column 0
+	u := make([]float64, rows+1)
+	v := make([]float64, cols+1)
+	match := make([]int, cols+1) // match[j] = row assigned to column j
+	way := make([]int, cols+1)
+
+	for i := 1; i <= rows; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]float64, cols+1)
+		used := make([]bool, cols+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for match[j0] != 0 {
+			used[j0] = true
+			i0 := match[j0]
+			delta := math.Inf(1)
+			j1 := 0
+
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				cur := at(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+
+		// walk back the augmenting path
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	for j := 1; j <= cols; j++ {
+		if i := match[j]; i != 0 && !math.IsInf(cost[i-1][j-1], 1) {
+			result[i-1] = j - 1
+		}
+	}
+	return result
+}
+
+// AssignGreedy serves rows in order, each taking its cheapest free column.
+// It mirrors the nearest-driver dispatch and is kept as a baseline for comparison.
+func AssignGreedy(cost [][]float64) []int {
+	result := make([]int, len(cost))
+	if len(cost) == 0 {
+		return result
+	}
+
+	taken := make([]bool, len(cost[0]))
+	for i, row := range cost {
+		result[i] = -1
+		best := math.Inf(1)
+		for j, c := range row {
+			if !taken[j] && c < best {
+				best = c
+				result[i] = j
+			}
+		}
+		if result[i] >= 0 {
+			taken[result[i]] = true
+		}
+	}
+	return result
+}
+
+// TotalCost sums the cost of an assignment, ignoring unassigned rows
+func TotalCost(cost [][]float64, assignment []int) (total float64, matched int) {
+	for i, j := range assignment {
+		if j >= 0 {
+			total += cost[i][j]
+			matched++
+		}
+	}
+	return total, matched
+}
@@ -0,0 +1,111 @@
+package dispatch
+
+import (
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// CostFunc returns the cost of sending a driver to a ride request
+type CostFunc func(req models.RideRequest, d models.Driver) float64
+
+// Strategy picks an assignment for a cost matrix (AssignOptimal or AssignGreedy)
+type Strategy func(cost [][]float64) []int
+
+// HaversineCost uses the straight-line pickup distance in kilometers as the cost
+func HaversineCost(req models.RideRequest, d models.Driver) float64 {
+	return geo.Distance(req.Pickup.Lat, req.Pickup.Lon, d.Location.Lat, d.Location.Lon)
+}
+
+// RoadDistanceCost uses the road distance from the routing graph, falling back to Haversine
+func RoadDistanceCost(router routing.Router) CostFunc {
+	return func(req models.RideRequest, d models.Driver) float64 {
+		return routing.DistanceKm(router, d.Location.Lat, d.Location.Lon, req.Pickup.Lat, req.Pickup.Lon)
+	}
+}
+
+// ETACost uses the estimated minutes for the driver to reach the pickup as the cost
+func ETACost(estimator *eta.Estimator) CostFunc {
+	return func(req models.RideRequest, d models.Driver) float64 {
+		return estimator.Minutes(d.Location.Lat, d.Location.Lon, req.Pickup.Lat, req.Pickup.Lon, time.Now())
+	}
+}
+
+// Matcher turns a batch of requests and drivers into offers
+type Matcher struct {
+	Cost        CostFunc
+	Strategy    Strategy
+	MaxPickupKm float64 // pairs farther than this are never matched
+}
+
+// NewMatcher creates a matcher using Haversine cost and optimal assignment
+func NewMatcher(maxPickupKm float64) *Matcher {
+	return &Matcher{
+		Cost:        HaversineCost,
+		Strategy:    AssignOptimal,
+		MaxPickupKm: maxPickupKm,
+	}
+}
+
+// CostMatrix builds the request x driver cost matrix, marking impossible pairs as Infeasible
+func (m *Matcher) CostMatrix(requests []models.RideRequest, drivers []models.Driver) [][]float64 {
+	cost := make([][]float64, len(requests))
+	for i, req := range requests {
+		cost[i] = make([]float64, len(drivers))
+		for j, d := range drivers {
+			// drivers only serve rides in their own city
+			if req.City != "" && req.City != d.City {
+				cost[i][j] = Infeasible
+				continue
+			}
+			// taxi type must match when the rider asked for one
+			if req.TaxiType != "" && req.TaxiType != d.TaxiType {
+				cost[i][j] = Infeasible
+				continue
+			}
+			// and so must every attribute the rider requires
+			if !d.HasAttributes(req.Requires) {
+				cost[i][j] = Infeasible
+				continue
+			}
+
+			// the straight-line bound is cheap and keeps road routing in Cost to nearby pairs
+			dist := geo.Distance(req.Pickup.Lat, req.Pickup.Lon, d.Location.Lat, d.Location.Lon)
+			if m.MaxPickupKm > 0 && dist > m.MaxPickupKm {
+				cost[i][j] = Infeasible
+				continue
+			}
+			cost[i][j] = m.Cost(req, d)
+		}
+	}
+	return cost
+}
+
+// Match assigns drivers to requests and returns one offer per matched request
+func (m *Matcher) Match(requests []models.RideRequest, drivers []models.Driver) []models.Offer {
+	if len(requests) == 0 || len(drivers) == 0 {
+		return nil
+	}
+
+	cost := m.CostMatrix(requests, drivers)
+	assignment := m.Strategy(cost)
+
+	now := time.Now()
+	var offers []models.Offer
+	for i, j := range assignment {
+		if j < 0 {
+			continue
+		}
+		d := drivers[j]
+		offers = append(offers, models.Offer{
+			RequestID:  requests[i].ID,
+			DriverID:   d.ID.Hex(),
+			DistanceKm: geo.Distance(requests[i].Pickup.Lat, requests[i].Pickup.Lon, d.Location.Lat, d.Location.Lon),
+			OfferedAt:  now,
+		})
+	}
+	return offers
+}
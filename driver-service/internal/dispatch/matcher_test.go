@@ -0,0 +1,174 @@
+package dispatch
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rush hour demand is concentrated around a few hubs on both sides of the Bosphorus
+var hubs = []models.Location{
+	{Lat: 41.0369, Lon: 28.9850}, // Taksim
+	{Lat: 41.0430, Lon: 29.0060}, // Besiktas
+	{Lat: 40.9900, Lon: 29.0270}, // Kadikoy
+	{Lat: 41.0760, Lon: 29.0130}, // Levent
+}
+
+func randomRequests(rng *rand.Rand, n int) []models.RideRequest {
+	reqs := make([]models.RideRequest, n)
+	for i := range reqs {
+		reqs[i] = models.RideRequest{
+			ID:     fmt.Sprintf("req-%d", i),
+			Pickup: jitter(rng, hubs[rng.Intn(len(hubs))], 0.02),
+		}
+	}
+	return reqs
+}
+
+func randomDrivers(rng *rand.Rand, n int) []models.Driver {
+	drvs := make([]models.Driver, n)
+	for i := range drvs {
+		drvs[i] = models.Driver{
+			ID:       primitive.NewObjectID(),
+			TaxiType: "yellow",
+			Location: jitter(rng, hubs[rng.Intn(len(hubs))], 0.04),
+		}
+	}
+	return drvs
+}
+
+func jitter(rng *rand.Rand, p models.Location, spread float64) models.Location {
+	return models.Location{
+		Lat: p.Lat + (rng.Float64()*2-1)*spread,
+		Lon: p.Lon + (rng.Float64()*2-1)*spread,
+	}
+}
+
+// TestOptimalBeatsGreedy replays the seeded rush hour of cmd/dispatch-sim: in every batch the
+// optimal assignment matches at least as many requests as greedy and, matching as many, never
+// drives further to the pickups
+func TestOptimalBeatsGreedy(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	m := NewMatcher(6)
+
+	var greedyKm, optimalKm float64
+	for b := 0; b < 50; b++ {
+		cost := m.CostMatrix(randomRequests(rng, 40), randomDrivers(rng, 60))
+
+		greedyTotal, greedyMatched := TotalCost(cost, AssignGreedy(cost))
+		optimalTotal, optimalMatched := TotalCost(cost, AssignOptimal(cost))
+
+		if optimalMatched < greedyMatched {
+			t.Fatalf("batch %d: optimal matched %d, greedy %d", b, optimalMatched, greedyMatched)
+		}
+		if optimalMatched == greedyMatched && optimalTotal > greedyTotal+1e-9 {
+			t.Fatalf("batch %d: optimal drives %.3f km, greedy %.3f km", b, optimalTotal, greedyTotal)
+		}
+		greedyKm += greedyTotal
+		optimalKm += optimalTotal
+	}
+	if optimalKm >= greedyKm {
+		t.Fatalf("optimal saved nothing over the run: %.2f km vs greedy %.2f km", optimalKm, greedyKm)
+	}
+}
+
+// TestAssignOptimalBruteForce checks small matrices, infeasible pairs and both shapes included,
+// against every possible assignment
+func TestAssignOptimalBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for n := 0; n < 500; n++ {
+		rows, cols := 1+rng.Intn(5), 1+rng.Intn(5)
+		cost := make([][]float64, rows)
+		for i := range cost {
+			cost[i] = make([]float64, cols)
+			for j := range cost[i] {
+				cost[i][j] = math.Round(rng.Float64()*100) / 10
+				if rng.Intn(4) == 0 {
+					cost[i][j] = Infeasible
+				}
+			}
+		}
+
+		assignment := AssignOptimal(cost)
+		if err := valid(cost, assignment); err != nil {
+			t.Fatalf("%v: %v", cost, err)
+		}
+		total, matched := TotalCost(cost, assignment)
+		bestTotal, bestMatched := bruteForce(cost, 0, make([]bool, cols))
+		if matched != bestMatched || math.Abs(total-bestTotal) > 1e-9 {
+			t.Fatalf("%v: got %d matched for %.1f, best is %d for %.1f", cost, matched, total, bestMatched, bestTotal)
+		}
+	}
+}
+
+// valid reports an assignment that reuses a column or uses an infeasible pair
+func valid(cost [][]float64, assignment []int) error {
+	if len(assignment) != len(cost) {
+		return fmt.Errorf("%d rows assigned, want %d", len(assignment), len(cost))
+	}
+	taken := make(map[int]bool)
+	for i, j := range assignment {
+		if j < 0 {
+			continue
+		}
+		if taken[j] {
+			return fmt.Errorf("column %d assigned twice", j)
+		}
+		if math.IsInf(cost[i][j], 1) {
+			return fmt.Errorf("infeasible pair %d/%d assigned", i, j)
+		}
+		taken[j] = true
+	}
+	return nil
+}
+
+// bruteForce returns the most matches possible and the lowest total cost among those
+func bruteForce(cost [][]float64, row int, taken []bool) (float64, int) {
+	if row == len(cost) {
+		return 0, 0
+	}
+	bestTotal, bestMatched := bruteForce(cost, row+1, taken)
+	for j, c := range cost[row] {
+		if taken[j] || math.IsInf(c, 1) {
+			continue
+		}
+		taken[j] = true
+		total, matched := bruteForce(cost, row+1, taken)
+		taken[j] = false
+		total, matched = total+c, matched+1
+		if matched > bestMatched || matched == bestMatched && total < bestTotal {
+			bestTotal, bestMatched = total, matched
+		}
+	}
+	return bestTotal, bestMatched
+}
+
+func benchmarkStrategy(b *testing.B, strategy Strategy, requests, drivers int) {
+	rng := rand.New(rand.NewSource(42))
+	cost := NewMatcher(6).CostMatrix(randomRequests(rng, requests), randomDrivers(rng, drivers))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		strategy(cost)
+	}
+}
+
+func BenchmarkAssignOptimal40x60(b *testing.B)   { benchmarkStrategy(b, AssignOptimal, 40, 60) }
+func BenchmarkAssignOptimal200x300(b *testing.B) { benchmarkStrategy(b, AssignOptimal, 200, 300) }
+func BenchmarkAssignGreedy40x60(b *testing.B)    { benchmarkStrategy(b, AssignGreedy, 40, 60) }
+func BenchmarkAssignGreedy200x300(b *testing.B)  { benchmarkStrategy(b, AssignGreedy, 200, 300) }
+
+func BenchmarkMatch40x60(b *testing.B) {
+	rng := rand.New(rand.NewSource(42))
+	m := NewMatcher(6)
+	reqs, drvs := randomRequests(rng, 40), randomDrivers(rng, 60)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(reqs, drvs)
+	}
+}
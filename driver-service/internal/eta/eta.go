@@ -0,0 +1,196 @@
+package eta
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// Zone is a named rectangle of a city used to pick a speed profile
+type Zone struct {
+	Name string `json:"name"`
+	City string `json:"city,omitempty"` // set from the city the zone is configured in
+	geo.Box
+}
+
+// DefaultZones splits Istanbul into a few areas with distinct traffic; the first match wins
+var DefaultZones = []Zone{
+	{Name: "historic-peninsula", Box: geo.Box{MinLat: 40.995, MinLon: 28.920, MaxLat: 41.025, MaxLon: 28.985}},
+	{Name: "beyoglu-sisli", Box: geo.Box{MinLat: 41.025, MinLon: 28.950, MaxLat: 41.090, MaxLon: 29.035}},
+	{Name: "kadikoy-uskudar", Box: geo.Box{MinLat: 40.960, MinLon: 29.010, MaxLat: 41.050, MaxLon: 29.110}},
+	{Name: "european-side", Box: geo.Box{MinLat: 40.950, MinLon: 28.500, MaxLat: 41.350, MaxLon: 29.060}},
+	{Name: "asian-side", Box: geo.Box{MinLat: 40.800, MinLon: 29.000, MaxLat: 41.250, MaxLon: 29.500}},
+}
+
+// DefaultProfiles is a city-wide hourly speed curve used until trip data is learned
+var DefaultProfiles = hourlyProfiles(map[int]float64{
+	0: 40, 1: 42, 2: 42, 3: 42, 4: 42, 5: 38,
+	6: 30, 7: 20, 8: 16, 9: 20, 10: 25, 11: 25,
+	12: 24, 13: 24, 14: 24, 15: 22, 16: 18, 17: 14,
+	18: 14, 19: 18, 20: 25, 21: 30, 22: 34, 23: 38,
+})
+
+func hourlyProfiles(speeds map[int]float64) []models.SpeedProfile {
+	profiles := make([]models.SpeedProfile, 0, len(speeds))
+	for hour := 0; hour < 24; hour++ {
+		profiles = append(profiles, models.SpeedProfile{Weekday: models.AnyWeekday, Hour: hour, SpeedKmh: speeds[hour]})
+	}
+	return profiles
+}
+
+type profileKey struct {
+	zone    string
+	weekday int
+	hour    int
+}
+
+// Estimator converts straight-line distance into driving minutes.
+// It is safe for concurrent use; profiles can be swapped while it is serving.
+type Estimator struct {
+	detour       float64
+	defaultSpeed float64
+	zones        []Zone
+	loc          *time.Location
+	locator      func(lat, lon float64) *time.Location // optional, per city time zones
+	router       routing.Router                        // optional, road distances replace Haversine x detour when set
+
+	mu       sync.RWMutex
+	profiles map[profileKey]float64
+	builtin  map[profileKey]float64 // DefaultProfiles, below every stored profile
+}
+
+// NewEstimator creates an estimator; detour scales Haversine distance to road distance
+func NewEstimator(detour, defaultSpeedKmh float64, zones []Zone, loc *time.Location) *Estimator {
+	e := &Estimator{
+		detour:       detour,
+		defaultSpeed: defaultSpeedKmh,
+		zones:        zones,
+		loc:          loc,
+	}
+	e.builtin = profileTable(DefaultProfiles)
+	e.SetProfiles(nil)
+	return e
+}
+
+// SetProfiles replaces the speed table; DefaultProfiles stay underneath as the fallback
+func (e *Estimator) SetProfiles(profiles []models.SpeedProfile) {
+	table := profileTable(profiles)
+	e.mu.Lock()
+	e.profiles = table
+	e.mu.Unlock()
+}
+
+func profileTable(profiles []models.SpeedProfile) map[profileKey]float64 {
+	table := make(map[profileKey]float64, len(profiles))
+	for _, p := range profiles {
+		if p.SpeedKmh > 0 {
+			table[profileKey{p.Zone, p.Weekday, p.Hour}] = p.SpeedKmh
+		}
+	}
+	return table
+}
+
+// SetLocator picks the time zone from the departure point, e.g. the city it lies in
+func (e *Estimator) SetLocator(locator func(lat, lon float64) *time.Location) {
+	e.locator = locator
+}
+
+// locationAt returns the time zone hours and weekdays are bucketed in for a point
+func (e *Estimator) locationAt(lat, lon float64) *time.Location {
+	if e.locator != nil {
+		return e.locator(lat, lon)
+	}
+	return e.loc
+}
+
+// SetRouter makes the estimator use road distances from a routing graph; nil turns it off
+func (e *Estimator) SetRouter(r routing.Router) {
+	e.router = r
+}
+
+// RoadKm returns the road distance from the router, or Haversine x detour when it cannot answer
+func (e *Estimator) RoadKm(fromLat, fromLon, toLat, toLon float64) float64 {
+	if e.router != nil {
+		if route, ok := e.router.Route(fromLat, fromLon, toLat, toLon); ok {
+			return route.DistanceKm
+		}
+	}
+	return geo.Distance(fromLat, fromLon, toLat, toLon) * e.detour
+}
+
+// Zone returns the name of the zone containing the point, or "" outside every zone
+func (e *Estimator) Zone(lat, lon float64) string {
+	for _, z := range e.zones {
+		if z.Contains(lat, lon) {
+			return z.Name
+		}
+	}
+	return ""
+}
+
+// Zones returns the configured zones
+func (e *Estimator) Zones() []Zone {
+	return e.zones
+}
+
+// Detour returns the straight-line to road distance factor
+func (e *Estimator) Detour() float64 {
+	return e.detour
+}
+
+// Location returns the default time zone used to bucket hours and weekdays
+func (e *Estimator) Location() *time.Location {
+	return e.loc
+}
+
+// SpeedAt returns the expected speed for departures from a point at a time.
+// The most specific stored profile wins: zone before city-wide, then weekday+hour, hour,
+// weekday, any time. Without one the built-in hourly curve applies, then the default speed.
+func (e *Estimator) SpeedAt(lat, lon float64, at time.Time) float64 {
+	zone := e.Zone(lat, lon)
+	local := at.In(e.locationAt(lat, lon))
+	weekday, hour := int(local.Weekday()), local.Hour()
+
+	zones := []string{""}
+	if zone != "" {
+		zones = []string{zone, ""}
+	}
+	var candidates []profileKey
+	for _, z := range zones {
+		candidates = append(candidates,
+			profileKey{z, weekday, hour},
+			profileKey{z, models.AnyWeekday, hour},
+			profileKey{z, weekday, models.AnyHour},
+			profileKey{z, models.AnyWeekday, models.AnyHour},
+		)
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, k := range candidates {
+		if speed, ok := e.profiles[k]; ok {
+			return speed
+		}
+	}
+	if speed, ok := e.builtin[profileKey{"", models.AnyWeekday, hour}]; ok {
+		return speed
+	}
+	return e.defaultSpeed
+}
+
+// Minutes estimates the driving time between two points departing at the given time
+func (e *Estimator) Minutes(fromLat, fromLon, toLat, toLon float64, at time.Time) float64 {
+	return e.MinutesForKm(e.RoadKm(fromLat, fromLon, toLat, toLon), fromLat, fromLon, at)
+}
+
+// MinutesForKm converts an already known road distance into minutes for a departure point and time
+func (e *Estimator) MinutesForKm(km, fromLat, fromLon float64, at time.Time) float64 {
+	speed := e.SpeedAt(fromLat, fromLon, at)
+	if speed <= 0 {
+		return 0
+	}
+	return km / speed * 60
+}
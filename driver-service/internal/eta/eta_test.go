@@ -0,0 +1,67 @@
+package eta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+func TestSpeedAtFallsBackToAnyHour(t *testing.T) {
+	zones := []Zone{{Name: "centre", Box: geo.Box{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 1}}}
+	e := NewEstimator(1.3, 25, zones, time.UTC)
+	monday8 := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	const inLat, inLon, outLat, outLon = 0.5, 0.5, 5.0, 5.0
+
+	// the built-in curve applies without stored profiles
+	if got := e.SpeedAt(inLat, inLon, monday8); got != 16 {
+		t.Fatalf("built-in speed = %v, want 16", got)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		profiles []models.SpeedProfile
+		lat, lon float64
+		want     float64
+	}{
+		{
+			name:     "zone for any hour",
+			profiles: []models.SpeedProfile{{Zone: "centre", Weekday: models.AnyWeekday, Hour: models.AnyHour, SpeedKmh: 11}},
+			lat:      inLat, lon: inLon, want: 11,
+		},
+		{
+			name:     "city-wide for any hour",
+			profiles: []models.SpeedProfile{{Weekday: models.AnyWeekday, Hour: models.AnyHour, SpeedKmh: 33}},
+			lat:      outLat, lon: outLon, want: 33,
+		},
+		{
+			name: "hour beats any hour",
+			profiles: []models.SpeedProfile{
+				{Zone: "centre", Weekday: models.AnyWeekday, Hour: models.AnyHour, SpeedKmh: 11},
+				{Zone: "centre", Weekday: models.AnyWeekday, Hour: 8, SpeedKmh: 9},
+			},
+			lat: inLat, lon: inLon, want: 9,
+		},
+		{
+			name: "zone beats city-wide",
+			profiles: []models.SpeedProfile{
+				{Zone: "centre", Weekday: 1, Hour: models.AnyHour, SpeedKmh: 12},
+				{Weekday: 1, Hour: 8, SpeedKmh: 20},
+			},
+			lat: inLat, lon: inLon, want: 12,
+		},
+		{
+			name:     "other zone falls back to the built-in curve",
+			profiles: []models.SpeedProfile{{Zone: "centre", Weekday: models.AnyWeekday, Hour: models.AnyHour, SpeedKmh: 11}},
+			lat:      outLat, lon: outLon, want: 16,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e.SetProfiles(tc.profiles)
+			if got := e.SpeedAt(tc.lat, tc.lon, monday8); got != tc.want {
+				t.Fatalf("SpeedAt = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package eta
+
+import (
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+// trips outside these bounds are GPS noise or abandoned meters, not traffic
+const (
+	minLearnMinutes  = 1.0
+	minLearnSpeedKmh = 3.0
+	maxLearnSpeedKmh = 120.0
+)
+
+type bucket struct {
+	sum   float64
+	count int
+}
+
+// Learn derives speed profiles from completed trips.
+// Each trip contributes its pickup-to-dropoff road distance (see RoadKm) over its
+// riding time to its zone/weekday/hour bucket and to the coarser buckets above it.
+// Buckets with fewer than minSamples trips are left out so the estimator falls back.
+func (e *Estimator) Learn(trips []models.Trip, minSamples int) []models.SpeedProfile {
+	buckets := make(map[profileKey]*bucket)
+	add := func(k profileKey, speed float64) {
+		b, ok := buckets[k]
+		if !ok {
+			b = &bucket{}
+			buckets[k] = b
+		}
+		b.sum += speed
+		b.count++
+	}
+
+	for _, t := range trips {
+		if t.Status != models.TripCompleted || t.StartedAt == nil || t.CompletedAt == nil {
+			continue
+		}
+		minutes := t.CompletedAt.Sub(*t.StartedAt).Minutes()
+		if minutes < minLearnMinutes {
+			continue
+		}
+
+		km := e.RoadKm(t.Pickup.Lat, t.Pickup.Lon, t.Dropoff.Lat, t.Dropoff.Lon)
+		speed := km / (minutes / 60)
+		if speed < minLearnSpeedKmh || speed > maxLearnSpeedKmh {
+			continue
+		}
+
+		local := t.StartedAt.In(e.locationAt(t.Pickup.Lat, t.Pickup.Lon))
+		weekday, hour := int(local.Weekday()), local.Hour()
+		zone := e.Zone(t.Pickup.Lat, t.Pickup.Lon)
+
+		add(profileKey{"", models.AnyWeekday, hour}, speed)
+		add(profileKey{"", weekday, hour}, speed)
+		if zone != "" {
+			add(profileKey{zone, models.AnyWeekday, hour}, speed)
+			add(profileKey{zone, weekday, hour}, speed)
+		}
+	}
+
+	now := time.Now()
+	var profiles []models.SpeedProfile
+	for k, b := range buckets {
+		if b.count < minSamples {
+			continue
+		}
+		profiles = append(profiles, models.SpeedProfile{
+			Zone:      k.zone,
+			Weekday:   k.weekday,
+			Hour:      k.hour,
+			SpeedKmh:  b.sum / float64(b.count),
+			Samples:   b.count,
+			UpdatedAt: now,
+		})
+	}
+	return profiles
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type AuditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(service service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// Search godoc
+// @Summary      Search the audit log
+// @Description  Lists recorded writes to drivers and vehicles across all fleets, newest first. Platform admins only.
+// @Tags         audit
+// @Produce      json
+// @Param        entity     query     string  false  "driver or vehicle"
+// @Param        entityId   query     string  false  "Driver or vehicle ID"
+// @Param        action     query     string  false  "create, update, delete, status_change, vehicle_assign or vehicle_unassign"
+// @Param        actor      query     string  false  "User who made the change, system for jobs"
+// @Param        requestId  query     string  false  "Request ID as returned in X-Request-ID"
+// @Param        from       query     string  false  "Start time (RFC3339)"
+// @Param        to         query     string  false  "End time (RFC3339), exclusive"
+// @Param        page       query     int     false  "Page number"
+// @Param        pageSize   query     int     false  "Page size"
+// @Success      200        {array}   models.AuditEntry
+// @Router       /audit [get]
+func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	query := models.AuditQuery{
+		Entity:    q.Get("entity"),
+		EntityID:  q.Get("entityId"),
+		Action:    q.Get("action"),
+		Actor:     q.Get("actor"),
+		RequestID: q.Get("requestId"),
+	}
+	query.Page, _ = strconv.Atoi(q.Get("page"))
+	query.PageSize, _ = strconv.Atoi(q.Get("pageSize"))
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if query.From, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "from must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if query.To, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "to must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.service.Search(r.Context(), query)
+	writeAuditEntries(w, entries, err)
+}
+
+// driverHistory godoc
+// @Summary      Change history of a driver
+// @Description  Lists who changed the driver, when and how: creation, updates, status changes and vehicle assignments with the fields before and after, newest first
+// @Tags         audit
+// @Produce      json
+// @Param        id        path      string  true   "Driver ID"
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Success      200       {array}   models.AuditEntry
+// @Router       /drivers/{id}/history [get]
+func (h *DriverHandler) driverHistory(w http.ResponseWriter, r *http.Request, id string) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	entries, err := h.audit.History(r.Context(), id, page, pageSize)
+	writeAuditEntries(w, entries, err)
+}
+
+func writeAuditEntries(w http.ResponseWriter, entries []models.AuditEntry, err error) {
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
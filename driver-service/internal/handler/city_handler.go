@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+)
+
+type CityHandler struct {
+	cities *city.Registry
+}
+
+func NewCityHandler(cities *city.Registry) *CityHandler {
+	return &CityHandler{cities: cities}
+}
+
+// Cities godoc
+// @Summary      List cities
+// @Description  Returns the configured service areas with their search radius, heartbeat window and time zone
+// @Tags         cities
+// @Produce      json
+// @Success      200  {array}  city.City
+// @Router       /cities [get]
+func (h *CityHandler) Cities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.cities.All())
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type DispatchHandler struct {
+	service service.DispatchService
+}
+
+func NewDispatchHandler(service service.DispatchService) *DispatchHandler {
+	return &DispatchHandler{service: service}
+}
+
+// Rides handles /dispatch/rides endpoint
+func (h *DispatchHandler) Rides(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.requestRide(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// RideByID handles /dispatch/rides/{id} endpoint
+func (h *DispatchHandler) RideByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/dispatch/rides/")
+	if id == "" {
+		http.Error(w, "missing ride id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getRide(w, r, id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// requestRide godoc
+// @Summary      Request a ride
+// @Description  Queues a ride request; pending requests are matched to drivers in batches
+// @Tags         dispatch
+// @Accept       json
+// @Produce      json
+// @Param        ride             body      models.RideRequest  true   "Pickup point, optional taxi type and required attributes"
+// @Param        Idempotency-Key  header    string              false  "Retries with the same key get the first response"
+// @Success      202              {object}  map[string]string
+// @Failure      422              {string}  string
+// @Router       /dispatch/rides [post]
+func (h *DispatchHandler) requestRide(w http.ResponseWriter, r *http.Request) {
+	var ride models.RideRequest
+	if err := json.NewDecoder(r.Body).Decode(&ride); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.service.RequestRide(r.Context(), &ride)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+// getRide godoc
+// @Summary      Get ride request
+// @Description  Returns the ride request status and the driver offer once it is matched; requests no driver was found for in time end up expired
+// @Tags         dispatch
+// @Produce      json
+// @Param        id   path      string  true  "Ride Request ID"
+// @Success      200  {object}  models.RideRequest
+// @Router       /dispatch/rides/{id} [get]
+func (h *DispatchHandler) getRide(w http.ResponseWriter, r *http.Request, id string) {
+	ride, err := h.service.GetRide(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ride)
+}
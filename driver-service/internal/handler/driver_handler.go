@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,11 +12,15 @@ import (
 )
 
 type DriverHandler struct {
-	service service.DriverService
+	service    service.DriverService
+	vehicles   service.VehicleService
+	onboarding service.OnboardingService
+	reviews    service.ReviewService
+	audit      service.AuditService
 }
 
-func NewDriverHandler(service service.DriverService) *DriverHandler {
-	return &DriverHandler{service: service}
+func NewDriverHandler(service service.DriverService, vehicles service.VehicleService, onboarding service.OnboardingService, reviews service.ReviewService, audit service.AuditService) *DriverHandler {
+	return &DriverHandler{service: service, vehicles: vehicles, onboarding: onboarding, reviews: reviews, audit: audit}
 }
 
 // DriversRoot handles /drivers endpoint
@@ -32,15 +37,67 @@ func (h *DriverHandler) DriversRoot(w http.ResponseWriter, r *http.Request) {
 
 // DriverByID handles /drivers/{id} endpoint
 func (h *DriverHandler) DriverByID(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/drivers/")
+	// path is /drivers/{id} or /drivers/{id}/{location,vehicle,assignments,status,status-history,documents[/{type}],reviews,history}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/drivers/"), "/")
+	id := parts[0]
 	if id == "" {
 		http.Error(w, "missing driver id", http.StatusBadRequest)
 		return
 	}
 
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "location" && r.Method == http.MethodPut:
+			h.updateLocation(w, r, id)
+		case parts[1] == "vehicle" && r.Method == http.MethodGet:
+			h.getDriverVehicle(w, r, id)
+		case parts[1] == "vehicle" && r.Method == http.MethodPut:
+			h.assignVehicle(w, r, id)
+		case parts[1] == "vehicle" && r.Method == http.MethodDelete:
+			h.unassignVehicle(w, r, id)
+		case parts[1] == "assignments" && r.Method == http.MethodGet:
+			h.driverAssignments(w, r, id)
+		case parts[1] == "status" && r.Method == http.MethodPost:
+			h.changeStatus(w, r, id)
+		case parts[1] == "status-history" && r.Method == http.MethodGet:
+			h.statusHistory(w, r, id)
+		case parts[1] == "documents" && r.Method == http.MethodGet:
+			h.listDocuments(w, r, id)
+		case parts[1] == "reviews" && r.Method == http.MethodGet:
+			h.driverReviews(w, r, id)
+		case parts[1] == "history" && r.Method == http.MethodGet:
+			h.driverHistory(w, r, id)
+		case parts[1] == "location", parts[1] == "vehicle", parts[1] == "assignments",
+			parts[1] == "status", parts[1] == "status-history", parts[1] == "documents", parts[1] == "reviews",
+			parts[1] == "history":
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	if len(parts) == 3 && parts[1] == "documents" && parts[2] != "" {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.putDocument(w, r, id, parts[2])
+		return
+	}
+	if len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
 	switch r.Method {
+	case http.MethodGet:
+		h.getDriver(w, r, id)
 	case http.MethodPut:
 		h.updateDriver(w, r, id)
+	case http.MethodPatch:
+		h.patchDriver(w, r, id)
+	case http.MethodDelete:
+		h.deleteDriver(w, r, id)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -48,19 +105,28 @@ func (h *DriverHandler) DriverByID(w http.ResponseWriter, r *http.Request) {
 
 // SearchNearby godoc
 // @Summary      Find nearby drivers
-// @Description  Calculates distance using Haversine formula and returns drivers within 6km radius
+// @Description  Calculates distance using Haversine formula and returns approved drivers within the city's search radius with their ETA.
+// @Description  The city is the one given or the one containing the point.
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
 // @Param        lat       query     number  true  "Latitude"
 // @Param        lon       query     number  true  "Longitude"
 // @Param        taxiType  query     string  false "Taxi Type (e.g. yellow, black)"
+// @Param        sort      query     string  false "Sort by distance (default), eta or rating (ETA weighted by rating)"
+// @Param        minRating query     number  false "Only drivers with at least this average rating"
+// @Param        requires  query     string  false "Comma separated attributes the driver must all offer, e.g. wheelchair_accessible,pet_friendly"
+// @Param        city      query     string  false "City code (e.g. istanbul), inferred from the point when empty"
 // @Success      200       {array}   models.Driver
 // @Router       /drivers/nearby [get]
 func (h *DriverHandler) SearchNearby(w http.ResponseWriter, r *http.Request) {
 	latStr := r.URL.Query().Get("lat")
 	lonStr := r.URL.Query().Get("lon")
 	taxiType := r.URL.Query().Get("taxiType")
+	sortBy := r.URL.Query().Get("sort")
+	minRatingStr := r.URL.Query().Get("minRating")
+	// requires may be repeated or comma separated
+	requiresStr := strings.Join(r.URL.Query()["requires"], ",")
 
 	if latStr == "" || lonStr == "" {
 		http.Error(w, "missing lat or lon parameters", http.StatusBadRequest)
@@ -74,9 +140,38 @@ func (h *DriverHandler) SearchNearby(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := h.service.FindNearby(r.Context(), lat, lon, taxiType)
+	if sortBy != "" && sortBy != "distance" && sortBy != "eta" && sortBy != "rating" {
+		http.Error(w, "sort must be distance, eta or rating", http.StatusBadRequest)
+		return
+	}
+
+	var minRating float64
+	if minRatingStr != "" {
+		var err error
+		minRating, err = strconv.ParseFloat(minRatingStr, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			http.Error(w, "minRating must be a number between 0 and 5", http.StatusBadRequest)
+			return
+		}
+	}
+
+	requires, err := models.ParseAttributes(requiresStr)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.FindNearby(r.Context(), models.NearbyQuery{
+		Lat:       lat,
+		Lon:       lon,
+		TaxiType:  taxiType,
+		Sort:      sortBy,
+		MinRating: minRating,
+		Requires:  requires,
+		City:      r.URL.Query().Get("city"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
@@ -88,16 +183,51 @@ func (h *DriverHandler) SearchNearby(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(results)
 }
 
+// Changes godoc
+// @Summary      Drivers changed since a token
+// @Description  Returns drivers created, updated or deleted since the token, oldest change first, with the token to send next time.
+// @Description  Start without since to receive every driver; keep calling while more is true. Deleted drivers come with deletedAt set,
+// @Description  rejected and suspended ones as updates with their status. Changes of the last few seconds are held back.
+// @Tags         drivers
+// @Produce      json
+// @Param        since  query     string  false  "Token from the previous response"
+// @Param        limit  query     int     false  "Drivers per response, 100 by default and at most 500"
+// @Success      200    {object}  models.DriverChanges
+// @Router       /drivers/changes [get]
+func (h *DriverHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	changes, err := h.service.Changes(r.Context(), r.URL.Query().Get("since"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if changes.Drivers == nil {
+		changes.Drivers = []models.Driver{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
 // --- Private Helper Methods (Annotated for Swagger) ---
 
 // createDriver godoc
 // @Summary      Create a new driver
-// @Description  Registers a new taxi driver in the database
+// @Description  Registers a new taxi driver in the database in the pending state. A plate assigns the vehicle with that plate, registering it when unknown.
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
-// @Param        driver  body      models.Driver  true  "Driver Information"
-// @Success      201     {object}  map[string]string
+// @Param        driver           body      models.Driver  true   "Driver Information"
+// @Param        Idempotency-Key  header    string         false  "Retries with the same key get the first response"
+// @Success      201              {object}  map[string]string
+// @Failure      422              {string}  string
 // @Router       /drivers [post]
 func (h *DriverHandler) createDriver(w http.ResponseWriter, r *http.Request) {
 	var driver models.Driver
@@ -108,7 +238,7 @@ func (h *DriverHandler) createDriver(w http.ResponseWriter, r *http.Request) {
 
 	id, err := h.service.CreateDriver(r.Context(), &driver)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -117,41 +247,168 @@ func (h *DriverHandler) createDriver(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"id": id})
 }
 
+// getDriver godoc
+// @Summary      Get a driver
+// @Description  Returns the driver with the current vehicle. The ETag carries the version and the time of the position; with a matching If-None-Match the answer is 304.
+// @Tags         drivers
+// @Produce      json
+// @Param        id             path      string  true   "Driver ID"
+// @Param        If-None-Match  header    string  false  "ETag from an earlier GET"
+// @Success      200            {object}  models.Driver
+// @Success      304
+// @Router       /drivers/{id} [get]
+func (h *DriverHandler) getDriver(w http.ResponseWriter, r *http.Request, id string) {
+	driver, err := h.service.GetDriver(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(driver))
+	if notModified(r, driver) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(driver)
+}
+
 // updateDriver godoc
 // @Summary      Update a driver
-// @Description  Updates existing driver information by ID
+// @Description  Replaces existing driver information by ID. The plate moves the driver to that vehicle; an empty plate unassigns.
+// @Description  If-Match must carry the ETag of the driver as last read; 412 means someone else changed it in between. Position updates since the read do not count as changes.
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
-// @Param        id      path      string         true  "Driver ID"
-// @Param        driver  body      models.Driver  true  "Driver Data"
-// @Success      200     {object}  map[string]string
+// @Param        id        path      string         true  "Driver ID"
+// @Param        If-Match  header    string         true  "ETag from GET, or *"
+// @Param        driver    body      models.Driver  true  "Driver Data"
+// @Success      200       {object}  map[string]string
+// @Failure      412       {string}  string
+// @Failure      428       {string}  string
 // @Router       /drivers/{id} [put]
 func (h *DriverHandler) updateDriver(w http.ResponseWriter, r *http.Request, id string) {
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	var driver models.Driver
 	if err := json.NewDecoder(r.Body).Decode(&driver); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.UpdateDriver(r.Context(), id, &driver); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.UpdateDriver(r.Context(), id, &driver, version); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
+	h.writeUpdated(w, r, id)
+}
+
+// patchDriver godoc
+// @Summary      Patch a driver
+// @Description  Applies the body as a JSON merge patch (RFC 7396) to the stored driver: present members replace, null removes, objects merge. If-Match works as for PUT.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string         true  "Driver ID"
+// @Param        If-Match  header    string         true  "ETag from GET, or *"
+// @Param        driver    body      models.Driver  true  "Fields to change"
+// @Success      200       {object}  map[string]string
+// @Failure      412       {string}  string
+// @Failure      428       {string}  string
+// @Router       /drivers/{id} [patch]
+func (h *DriverHandler) patchDriver(w http.ResponseWriter, r *http.Request, id string) {
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(patch) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.PatchDriver(r.Context(), id, patch, version); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeUpdated(w, r, id)
+}
+
+// deleteDriver godoc
+// @Summary      Delete a driver
+// @Description  Removes a driver who is off shift and ends their vehicle assignment. The changes feed reports the driver with deletedAt set;
+// @Description  shifts, reviews, the location history and the audit trail are kept
+// @Tags         drivers
+// @Param        id   path  string  true  "Driver ID"
+// @Success      204
+// @Failure      409  {string}  string
+// @Router       /drivers/{id} [delete]
+func (h *DriverHandler) deleteDriver(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.service.DeleteDriver(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUpdated answers a successful write with the new ETag, so the next edit needs no extra GET
+func (h *DriverHandler) writeUpdated(w http.ResponseWriter, r *http.Request, id string) {
+	if driver, err := h.service.GetDriver(r.Context(), id); err == nil {
+		w.Header().Set("ETag", etag(driver))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
 }
 
+// updateLocation godoc
+// @Summary      Update driver location
+// @Description  Runs the ping through the driver's GPS filter, moves the driver to the smoothed position and stores raw and smoothed positions in the location history. Pings implying an impossible speed are rejected with 422 (or only flagged, depending on the taxi type).
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                 true  "Driver ID"
+// @Param        location  body      models.LocationUpdate  true  "Current Position"
+// @Success      200       {object}  models.LocationPing
+// @Failure      422       {object}  models.LocationPing
+// @Router       /drivers/{id}/location [put]
+func (h *DriverHandler) updateLocation(w http.ResponseWriter, r *http.Request, id string) {
+	var update models.LocationUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ping, err := h.service.UpdateLocation(r.Context(), id, update)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if ping.Rejected {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(ping)
+}
+
 // listDrivers godoc
 // @Summary      List drivers
-// @Description  Get all drivers with pagination
+// @Description  Get all drivers with pagination, optionally in one city
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
-// @Param        page      query     int  false  "Page number"
-// @Param        pageSize  query     int  false  "Page size"
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Param        city      query     string  false  "City code"
 // @Success      200       {array}   models.Driver
 // @Router       /drivers [get]
 func (h *DriverHandler) listDrivers(w http.ResponseWriter, r *http.Request) {
@@ -161,9 +418,9 @@ func (h *DriverHandler) listDrivers(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(pageStr)
 	pageSize, _ := strconv.Atoi(pageSizeStr)
 
-	drivers, err := h.service.ListDrivers(r.Context(), page, pageSize)
+	drivers, err := h.service.ListDrivers(r.Context(), page, pageSize, r.URL.Query().Get("city"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -174,3 +431,80 @@ func (h *DriverHandler) listDrivers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(drivers)
 }
+
+// getDriverVehicle godoc
+// @Summary      Current vehicle of a driver
+// @Tags         drivers
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  models.Vehicle
+// @Router       /drivers/{id}/vehicle [get]
+func (h *DriverHandler) getDriverVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	vehicle, err := h.vehicles.CurrentVehicle(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// assignVehicle godoc
+// @Summary      Assign a vehicle to a driver
+// @Description  Ends the driver's current assignment and starts one with the given vehicle
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string             true  "Driver ID"
+// @Param        body  body      map[string]string  true  "{\"vehicleId\": \"...\"}"
+// @Success      200   {object}  models.VehicleAssignment
+// @Router       /drivers/{id}/vehicle [put]
+func (h *DriverHandler) assignVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		VehicleID string `json:"vehicleId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.VehicleID == "" {
+		http.Error(w, "vehicleId is required", http.StatusBadRequest)
+		return
+	}
+
+	assignment, err := h.vehicles.Assign(r.Context(), id, body.VehicleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// unassignVehicle godoc
+// @Summary      Unassign a driver's vehicle
+// @Tags         drivers
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  models.VehicleAssignment
+// @Router       /drivers/{id}/vehicle [delete]
+func (h *DriverHandler) unassignVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	assignment, err := h.vehicles.Unassign(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// driverAssignments godoc
+// @Summary      Driver assignment history
+// @Description  Lists the vehicles the driver worked with and when, newest first
+// @Tags         drivers
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {array}   models.VehicleAssignment
+// @Router       /drivers/{id}/assignments [get]
+func (h *DriverHandler) driverAssignments(w http.ResponseWriter, r *http.Request, id string) {
+	writeAssignments(w, r, h.vehicles, id, "")
+}
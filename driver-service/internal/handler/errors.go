@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+// writeServiceError maps service and repository errors to http status codes
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repository.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repository.ErrTripNotFound),
+		errors.Is(err, repository.ErrDriverNotFound),
+		errors.Is(err, repository.ErrTariffNotFound),
+		errors.Is(err, repository.ErrVehicleNotFound),
+		errors.Is(err, repository.ErrAssignmentNotFound),
+		errors.Is(err, repository.ErrShiftNotFound),
+		errors.Is(err, repository.ErrReviewNotFound),
+		errors.Is(err, repository.ErrTaxiTypeNotFound),
+		errors.Is(err, repository.ErrWebhookNotFound),
+		errors.Is(err, repository.ErrDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, repository.ErrTripStateChanged),
+		errors.Is(err, repository.ErrDriverBusy),
+		errors.Is(err, repository.ErrPlateTaken),
+		errors.Is(err, repository.ErrDriverAssigned),
+		errors.Is(err, service.ErrVehicleInUse),
+		errors.Is(err, repository.ErrVehicleOnShift),
+		errors.Is(err, repository.ErrDriverOnShift),
+		errors.Is(err, repository.ErrDriverStatusChanged),
+		errors.Is(err, service.ErrInvalidStatusChange),
+		errors.Is(err, service.ErrDriverNotApproved),
+		errors.Is(err, repository.ErrAlreadyReviewed),
+		errors.Is(err, repository.ErrTaxiTypeExists),
+		errors.Is(err, service.ErrTaxiTypeInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
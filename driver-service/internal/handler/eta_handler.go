@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type EtaHandler struct {
+	service service.EtaService
+}
+
+func NewEtaHandler(service service.EtaService) *EtaHandler {
+	return &EtaHandler{service: service}
+}
+
+// Profiles handles /eta/profiles endpoint
+func (h *EtaHandler) Profiles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listProfiles(w, r)
+	case http.MethodPut:
+		h.setProfiles(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Learn handles /eta/profiles/learn endpoint
+func (h *EtaHandler) Learn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.learn(w, r)
+}
+
+// listProfiles godoc
+// @Summary      List speed profiles
+// @Description  Returns the stored ETA speed profiles; the built-in hourly curve applies where none match
+// @Tags         eta
+// @Produce      json
+// @Success      200  {array}  models.SpeedProfile
+// @Router       /eta/profiles [get]
+func (h *EtaHandler) listProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.service.ListProfiles(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if profiles == nil {
+		profiles = []models.SpeedProfile{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
+
+// setProfiles godoc
+// @Summary      Replace speed profiles
+// @Description  Replaces the ETA speed table; weekday and hour -1 mean any, empty zone means city-wide
+// @Tags         eta
+// @Accept       json
+// @Produce      json
+// @Param        profiles  body      []models.SpeedProfile  true  "Speed Profiles"
+// @Success      200       {object}  map[string]string
+// @Router       /eta/profiles [put]
+func (h *EtaHandler) setProfiles(w http.ResponseWriter, r *http.Request) {
+	var profiles []models.SpeedProfile
+	if err := json.NewDecoder(r.Body).Decode(&profiles); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetProfiles(r.Context(), profiles); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// learn godoc
+// @Summary      Learn speed profiles
+// @Description  Rebuilds the learned ETA speed profiles from recently completed trips; profiles set by hand are kept and take precedence
+// @Tags         eta
+// @Produce      json
+// @Param        days        query     int  false  "Days of trip history (default 30)"
+// @Param        minSamples  query     int  false  "Trips needed per profile (default 5)"
+// @Success      200         {array}   models.SpeedProfile
+// @Router       /eta/profiles/learn [post]
+func (h *EtaHandler) learn(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	minSamples, _ := strconv.Atoi(r.URL.Query().Get("minSamples"))
+
+	profiles, err := h.service.Learn(r.Context(), days, minSamples)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if profiles == nil {
+		profiles = []models.SpeedProfile{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// etag formats a driver as a strong entity tag of its version and the time of its position,
+// e.g. "7.1729330000123". Positions do not bump the version, so the second part tells
+// representations with the same version but another position apart.
+func etag(driver *models.Driver) string {
+	var moved int64
+	if driver.LocationAt != nil {
+		moved = driver.LocationAt.UnixMilli()
+	}
+	return strconv.Quote(strconv.FormatInt(driver.Version, 10) + "." + strconv.FormatInt(moved, 10))
+}
+
+// ifMatchVersion reads the version a write is conditional on. A missing header is answered
+// with 428 so clients cannot overwrite changes they have not seen; "*" matches any version.
+// Only the version part of the tag is compared: a position reported since the read does not
+// conflict with an edit, or no edit of a driver on duty would ever succeed.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		http.Error(w, "If-Match header is required, send the ETag of the driver you edited", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return repository.AnyVersion, true
+	}
+	// If-Match compares strongly, a weak or malformed tag never matches
+	tag, err := strconv.Unquote(header)
+	tag, _, _ = strings.Cut(tag, ".")
+	version, convErr := strconv.ParseInt(tag, 10, 64)
+	if err != nil || convErr != nil || version < 0 {
+		http.Error(w, "If-Match must be a single ETag as returned by GET", http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified reports whether If-None-Match names the current representation; weak tags match too
+func notModified(r *http.Request, driver *models.Driver) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(driver)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
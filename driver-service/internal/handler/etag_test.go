@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+func TestETagChangesWithThePosition(t *testing.T) {
+	at := time.Now()
+	driver := &models.Driver{Version: 7, LocationAt: &at}
+	tag := etag(driver)
+
+	r := httptest.NewRequest("GET", "/drivers/x", nil)
+	r.Header.Set("If-None-Match", tag)
+	if !notModified(r, driver) {
+		t.Fatal("unchanged driver is not 304")
+	}
+
+	// a new position does not bump the version, but a cached copy is stale
+	moved := at.Add(time.Second)
+	driver.LocationAt = &moved
+	if notModified(r, driver) {
+		t.Fatal("driver that moved is still 304")
+	}
+
+	// edits only conflict on the version
+	w := httptest.NewRecorder()
+	r = httptest.NewRequest("PUT", "/drivers/x", nil)
+	r.Header.Set("If-Match", tag)
+	if version, ok := ifMatchVersion(w, r); !ok || version != 7 {
+		t.Fatalf("If-Match %s read as %d, %v", tag, version, ok)
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type FareHandler struct {
+	service service.FareService
+}
+
+func NewFareHandler(service service.FareService) *FareHandler {
+	return &FareHandler{service: service}
+}
+
+// Estimate handles /fares/estimate endpoint
+func (h *FareHandler) Estimate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.estimate(w, r)
+}
+
+// Tariffs handles /fares/tariffs endpoint
+func (h *FareHandler) Tariffs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTariffs(w, r)
+	case http.MethodPost:
+		h.addTariff(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TripFare handles /fares/trips/{id} endpoint
+func (h *FareHandler) TripFare(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/fares/trips/")
+	if id == "" {
+		http.Error(w, "missing trip id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.priceTrip(w, r, id)
+}
+
+// estimate godoc
+// @Summary      Estimate a fare
+// @Description  Prices a trip from pickup to dropoff with the tariff in force at the given time (default now)
+// @Tags         fares
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.FareEstimateRequest  true  "Pickup, dropoff and taxi type"
+// @Success      200      {object}  models.FareEstimate
+// @Router       /fares/estimate [post]
+func (h *FareHandler) estimate(w http.ResponseWriter, r *http.Request) {
+	var req models.FareEstimateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	est, err := h.service.Estimate(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(est)
+}
+
+// priceTrip godoc
+// @Summary      Re-price a trip
+// @Description  Prices a completed trip with the tariff that was in force when it was requested
+// @Tags         fares
+// @Produce      json
+// @Param        id   path      string  true  "Trip ID"
+// @Success      200  {object}  models.FareEstimate
+// @Router       /fares/trips/{id} [get]
+func (h *FareHandler) priceTrip(w http.ResponseWriter, r *http.Request, id string) {
+	est, err := h.service.PriceTrip(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(est)
+}
+
+// listTariffs godoc
+// @Summary      List tariffs
+// @Description  Returns every tariff version, optionally for one city and taxi type
+// @Tags         fares
+// @Produce      json
+// @Param        city      query     string  false  "City code"
+// @Param        taxiType  query     string  false  "Taxi Type"
+// @Success      200       {array}   models.Tariff
+// @Router       /fares/tariffs [get]
+func (h *FareHandler) listTariffs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	tariffs, err := h.service.ListTariffs(r.Context(), q.Get("city"), q.Get("taxiType"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if tariffs == nil {
+		tariffs = []models.Tariff{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tariffs)
+}
+
+// addTariff godoc
+// @Summary      Add a tariff version
+// @Description  Stores a new tariff version for a taxi type, effective from the given date
+// @Tags         fares
+// @Accept       json
+// @Produce      json
+// @Param        tariff  body      models.Tariff  true  "Tariff"
+// @Success      201     {object}  models.Tariff
+// @Router       /fares/tariffs [post]
+func (h *FareHandler) addTariff(w http.ResponseWriter, r *http.Request) {
+	var tariff models.Tariff
+	if err := json.NewDecoder(r.Body).Decode(&tariff); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.AddTariff(r.Context(), &tariff); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tariff)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type FleetHandler struct {
+	service service.FleetService
+}
+
+func NewFleetHandler(service service.FleetService) *FleetHandler {
+	return &FleetHandler{service: service}
+}
+
+// Snapshot godoc
+// @Summary      Fleet at a point in time
+// @Description  Rebuilds the last reported position and the onboarding status of every driver that existed at the given instant
+// @Description  from the location history and the status log. Fleet operators see their own drivers.
+// @Description  Snapshots are rebuilt from the last fleet checkpoint, an instant before the first checkpoint is rejected.
+// @Tags         fleet
+// @Produce      json
+// @Param        at   query     string  false  "Instant (RFC3339), now when empty"
+// @Success      200  {object}  models.FleetSnapshot
+// @Router       /fleet/snapshot [get]
+func (h *FleetHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "at must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	snapshot, err := h.service.Snapshot(r.Context(), at)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+// limits the request body so a huge matrix is rejected before decoding
+const maxMatrixBodyBytes = 1 << 20
+
+type GeoHandler struct {
+	service service.GeoService
+}
+
+func NewGeoHandler(service service.GeoService) *GeoHandler {
+	return &GeoHandler{service: service}
+}
+
+// Matrix handles /geo/matrix endpoint
+func (h *GeoHandler) Matrix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.matrix(w, r)
+}
+
+// matrix godoc
+// @Summary      Distance matrix
+// @Description  Returns distance and ETA from every origin to every destination, using road routing when configured
+// @Tags         geo
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MatrixRequest  true  "Origins and destinations"
+// @Success      200      {object}  models.MatrixResponse
+// @Router       /geo/matrix [post]
+func (h *GeoHandler) matrix(w http.ResponseWriter, r *http.Request) {
+	var req models.MatrixRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxMatrixBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Matrix(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+// limits of one batch upload from a telematics gateway
+const (
+	maxLocationBatchBytes = 8 << 20
+	maxLocationBatchItems = 5000
+)
+
+type LocationHandler struct {
+	service service.DriverService
+}
+
+func NewLocationHandler(service service.DriverService) *LocationHandler {
+	return &LocationHandler{service: service}
+}
+
+// Batch handles /locations:batch endpoint
+func (h *LocationHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.batch(w, r)
+}
+
+// batch godoc
+// @Summary      Batch location upload
+// @Description  Accepts a JSON array or an NDJSON stream of pings (driverId, lat, lon, ts) and reports the outcome of every item in input order. Pings run through the same GPS filter as single updates; accepted positions are reported as queued and written in bulk shortly after.
+// @Tags         locations
+// @Accept       json
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        items  body      []models.LocationBatchItem  true  "Pings"
+// @Success      200    {array}   models.LocationBatchResult
+// @Router       /locations:batch [post]
+func (h *LocationHandler) batch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLocationBatchBytes)
+	items, err := decodeLocationBatch(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxLocationBatchItems {
+		http.Error(w, fmt.Sprintf("at most %d items per batch", maxLocationBatchItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	results, err := h.service.IngestLocations(r.Context(), items)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// decodeLocationBatch reads a JSON array, or one JSON object per line when the body does not start with '['
+func decodeLocationBatch(body io.Reader) ([]models.LocationBatchItem, error) {
+	br := bufio.NewReader(body)
+	var first byte
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.ContainsRune([]byte(" \t\r\n"), rune(b)) {
+			first = b
+			br.UnreadByte()
+			break
+		}
+	}
+
+	dec := json.NewDecoder(br)
+	var items []models.LocationBatchItem
+	if first == '[' {
+		if err := dec.Decode(&items); err != nil {
+			return nil, errors.New("invalid request body")
+		}
+		return items, nil
+	}
+
+	for line := 1; ; line++ {
+		var item models.LocationBatchItem
+		err := dec.Decode(&item)
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid item %d: %v", line, err)
+		}
+		items = append(items, item)
+	}
+}
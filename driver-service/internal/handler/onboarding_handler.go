@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+)
+
+// changeStatus godoc
+// @Summary      Change onboarding status
+// @Description  Moves a driver between pending, approved, suspended and rejected. Suspending and rejecting need a reason; approving needs valid mandatory documents.
+// @Tags         onboarding
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "Driver ID"
+// @Param        request  body      models.StatusChangeRequest  true  "New status and reason"
+// @Success      200      {object}  models.DriverStatusChange
+// @Router       /drivers/{id}/status [post]
+func (h *DriverHandler) changeStatus(w http.ResponseWriter, r *http.Request, id string) {
+	var req models.StatusChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.onboarding.ChangeStatus(r.Context(), id, &req, tenant.Actor(r.Context()))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+// statusHistory godoc
+// @Summary      Onboarding history
+// @Description  Lists the driver's status transitions with reasons, oldest first
+// @Tags         onboarding
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {array}   models.DriverStatusChange
+// @Router       /drivers/{id}/status-history [get]
+func (h *DriverHandler) statusHistory(w http.ResponseWriter, r *http.Request, id string) {
+	changes, err := h.onboarding.StatusHistory(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if changes == nil {
+		changes = []models.DriverStatusChange{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// listDocuments godoc
+// @Summary      List driver documents
+// @Tags         onboarding
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {array}   models.DriverDocument
+// @Router       /drivers/{id}/documents [get]
+func (h *DriverHandler) listDocuments(w http.ResponseWriter, r *http.Request, id string) {
+	docs, err := h.onboarding.ListDocuments(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if docs == nil {
+		docs = []models.DriverDocument{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
+
+// putDocument godoc
+// @Summary      Store a driver document
+// @Description  Replaces the driver's document of the type: license, psychotechnic, vehicle_inspection or insurance
+// @Tags         onboarding
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                 true  "Driver ID"
+// @Param        type      path      string                 true  "Document type"
+// @Param        document  body      models.DriverDocument  true  "Number, issue and expiry dates"
+// @Success      200       {object}  models.DriverDocument
+// @Router       /drivers/{id}/documents/{type} [put]
+func (h *DriverHandler) putDocument(w http.ResponseWriter, r *http.Request, id, docType string) {
+	var doc models.DriverDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	doc.DriverID = id
+	doc.Type = docType
+
+	if err := h.onboarding.PutDocument(r.Context(), &doc); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// ExpiringDocuments godoc
+// @Summary      Expiring documents
+// @Description  Lists documents of all drivers that expire within the given number of days, expired ones included
+// @Tags         onboarding
+// @Produce      json
+// @Param        days  query     int  false  "Days ahead (default 30)"
+// @Success      200   {array}   models.DriverDocument
+// @Router       /documents/expiring [get]
+func (h *DriverHandler) ExpiringDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 0 {
+			http.Error(w, "days must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	docs, err := h.onboarding.ExpiringDocuments(r.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if docs == nil {
+		docs = []models.DriverDocument{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type ReviewHandler struct {
+	service service.ReviewService
+}
+
+func NewReviewHandler(service service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+// ReviewsRoot handles /reviews endpoint
+func (h *ReviewHandler) ReviewsRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.submitReview(w, r)
+	case http.MethodGet:
+		h.listReviews(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ReviewByID handles /reviews/{id} and /reviews/{id}/moderation endpoints
+func (h *ReviewHandler) ReviewByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reviews/"), "/")
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "missing review id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getReview(w, r, id)
+	case len(parts) == 2 && parts[1] == "moderation" && r.Method == http.MethodPut:
+		h.moderateReview(w, r, id)
+	case len(parts) == 1, len(parts) == 2 && parts[1] == "moderation":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// submitReview godoc
+// @Summary      Review a trip
+// @Description  Rates the driver of a completed trip with 1 to 5 stars, optional tags and a comment. A trip can be reviewed once, by its rider. Updates the driver's rating.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        review  body      models.Review  true  "tripId, riderId, stars, tags and comment"
+// @Success      201     {object}  models.Review
+// @Router       /reviews [post]
+func (h *ReviewHandler) submitReview(w http.ResponseWriter, r *http.Request) {
+	var review models.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.SubmitReview(r.Context(), &review)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// listReviews godoc
+// @Summary      List reviews
+// @Description  Get reviews with pagination for moderation, optionally filtered by driver and status, newest first
+// @Tags         reviews
+// @Produce      json
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Param        driverId  query     string  false  "Driver ID"
+// @Param        status    query     string  false  "visible or hidden"
+// @Success      200       {array}   models.Review
+// @Router       /reviews [get]
+func (h *ReviewHandler) listReviews(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+	writeReviews(w, r, h.service, page, pageSize, q.Get("driverId"), q.Get("status"))
+}
+
+// getReview godoc
+// @Summary      Get a review
+// @Tags         reviews
+// @Produce      json
+// @Param        id   path      string  true  "Review ID"
+// @Success      200  {object}  models.Review
+// @Router       /reviews/{id} [get]
+func (h *ReviewHandler) getReview(w http.ResponseWriter, r *http.Request, id string) {
+	review, err := h.service.GetReview(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// moderateReview godoc
+// @Summary      Moderate a review
+// @Description  Hides a review (reason required) or makes it visible again. Hidden reviews do not count towards the driver's rating.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "Review ID"
+// @Param        request  body      models.ModerationRequest  true  "New status and reason"
+// @Success      200      {object}  models.Review
+// @Router       /reviews/{id}/moderation [put]
+func (h *ReviewHandler) moderateReview(w http.ResponseWriter, r *http.Request, id string) {
+	var req models.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.Moderate(r.Context(), id, &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// driverReviews godoc
+// @Summary      Reviews of a driver
+// @Description  Lists the driver's visible reviews, newest first. The aggregates are on the driver as rating.
+// @Tags         reviews
+// @Produce      json
+// @Param        id        path      string  true   "Driver ID"
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Success      200       {array}   models.Review
+// @Router       /drivers/{id}/reviews [get]
+func (h *DriverHandler) driverReviews(w http.ResponseWriter, r *http.Request, id string) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+
+	writeReviews(w, r, h.reviews, page, pageSize, id, models.ReviewVisible)
+}
+
+// writeReviews lists reviews for both the moderation and the per driver endpoint
+func writeReviews(w http.ResponseWriter, r *http.Request, svc service.ReviewService, page, pageSize int, driverID, status string) {
+	reviews, err := svc.ListReviews(r.Context(), page, pageSize, driverID, status)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if reviews == nil {
+		reviews = []models.Review{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+}
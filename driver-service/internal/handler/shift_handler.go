@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type ShiftHandler struct {
+	service service.ShiftService
+}
+
+func NewShiftHandler(service service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+// Shifts handles /shifts endpoint
+func (h *ShiftHandler) Shifts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.listShifts(w, r)
+}
+
+// Start handles /shifts/start endpoint
+func (h *ShiftHandler) Start(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.startShift(w, r)
+}
+
+// End handles /shifts/end endpoint
+func (h *ShiftHandler) End(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.endShift(w, r)
+}
+
+// Handover handles /shifts/handover endpoint
+func (h *ShiftHandler) Handover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.handover(w, r)
+}
+
+// Hours handles /shifts/hours endpoint
+func (h *ShiftHandler) Hours(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.hours(w, r)
+}
+
+// listShifts godoc
+// @Summary      List shifts
+// @Description  Get shifts with pagination, newest first, optionally filtered by driver, vehicle and running state
+// @Tags         shifts
+// @Produce      json
+// @Param        page       query     int     false  "Page number"
+// @Param        pageSize   query     int     false  "Page size"
+// @Param        driverId   query     string  false  "Driver ID"
+// @Param        vehicleId  query     string  false  "Vehicle ID"
+// @Param        active     query     bool    false  "Only running shifts"
+// @Success      200        {array}   models.Shift
+// @Router       /shifts [get]
+func (h *ShiftHandler) listShifts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	active, _ := strconv.ParseBool(q.Get("active"))
+
+	shifts, err := h.service.ListShifts(r.Context(), page, pageSize, q.Get("driverId"), q.Get("vehicleId"), active)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if shifts == nil {
+		shifts = []models.Shift{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shifts)
+}
+
+// startShift godoc
+// @Summary      Start a shift
+// @Description  Puts a driver on duty with the vehicle they are assigned to; a vehicle has one driver on shift at a time
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Param        body  body      map[string]string  true  "{\"driverId\": \"...\", \"vehicleId\": \"... (optional)\"}"
+// @Success      201   {object}  models.Shift
+// @Router       /shifts/start [post]
+func (h *ShiftHandler) startShift(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DriverID  string `json:"driverId"`
+		VehicleID string `json:"vehicleId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shift, err := h.service.StartShift(r.Context(), body.DriverID, body.VehicleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+// endShift godoc
+// @Summary      End a shift
+// @Description  Takes a driver off duty; not allowed during an active trip
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Param        body  body      map[string]string  true  "{\"driverId\": \"...\"}"
+// @Success      200   {object}  models.Shift
+// @Router       /shifts/end [post]
+func (h *ShiftHandler) endShift(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DriverID string `json:"driverId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.DriverID == "" {
+		http.Error(w, "driverId is required", http.StatusBadRequest)
+		return
+	}
+
+	shift, err := h.service.EndShift(r.Context(), body.DriverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// handover godoc
+// @Summary      Hand a vehicle over
+// @Description  Ends the running shift of the vehicle and starts the incoming driver's, who takes over the vehicle's live location and on duty status
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.HandoverRequest  true  "Vehicle and incoming driver"
+// @Success      200      {object}  models.Shift
+// @Router       /shifts/handover [post]
+func (h *ShiftHandler) handover(w http.ResponseWriter, r *http.Request) {
+	var req models.HandoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shift, err := h.service.Handover(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// hours godoc
+// @Summary      Hours on shift
+// @Description  Sums time on shift per driver between from and to (RFC3339, default the last 7 days); running shifts count until now
+// @Tags         shifts
+// @Produce      json
+// @Param        from      query     string  false  "Start of the period"
+// @Param        to        query     string  false  "End of the period"
+// @Param        driverId  query     string  false  "Driver ID"
+// @Success      200       {array}   models.ShiftHours
+// @Router       /shifts/hours [get]
+func (h *ShiftHandler) hours(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := time.Now()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "from must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "to must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hours, err := h.service.Hours(r.Context(), q.Get("driverId"), from, to)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hours)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type TaxiTypeHandler struct {
+	service service.TaxiTypeService
+}
+
+func NewTaxiTypeHandler(service service.TaxiTypeService) *TaxiTypeHandler {
+	return &TaxiTypeHandler{service: service}
+}
+
+// TaxiTypesRoot handles /taxi-types endpoint
+func (h *TaxiTypeHandler) TaxiTypesRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.createTaxiType(w, r)
+	case http.MethodGet:
+		h.listTaxiTypes(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TaxiTypeByCode handles /taxi-types/{code} endpoint
+func (h *TaxiTypeHandler) TaxiTypeByCode(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimPrefix(r.URL.Path, "/taxi-types/")
+	if code == "" {
+		http.Error(w, "missing taxi type code", http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(code, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getTaxiType(w, r, code)
+	case http.MethodPut:
+		h.updateTaxiType(w, r, code)
+	case http.MethodDelete:
+		h.deleteTaxiType(w, r, code)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createTaxiType godoc
+// @Summary      Add a taxi type
+// @Description  Adds a type to the catalog. The code is lower case and cannot change; tariffCode lets the type use another type's tariff.
+// @Tags         taxi-types
+// @Accept       json
+// @Produce      json
+// @Param        taxiType  body      models.TaxiType  true  "Catalog entry"
+// @Success      201       {object}  models.TaxiType
+// @Router       /taxi-types [post]
+func (h *TaxiTypeHandler) createTaxiType(w http.ResponseWriter, r *http.Request) {
+	var taxiType models.TaxiType
+	if err := json.NewDecoder(r.Body).Decode(&taxiType); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreateTaxiType(r.Context(), &taxiType); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(taxiType)
+}
+
+// listTaxiTypes godoc
+// @Summary      List taxi types
+// @Description  Returns the catalog in display order, e.g. for the map filter buttons; city limits it to the types offered there
+// @Tags         taxi-types
+// @Produce      json
+// @Param        city  query    string  false  "City code"
+// @Success      200   {array}  models.TaxiType
+// @Router       /taxi-types [get]
+func (h *TaxiTypeHandler) listTaxiTypes(w http.ResponseWriter, r *http.Request) {
+	taxiTypes, err := h.service.ListTaxiTypes(r.Context(), r.URL.Query().Get("city"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if taxiTypes == nil {
+		taxiTypes = []models.TaxiType{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taxiTypes)
+}
+
+// getTaxiType godoc
+// @Summary      Get a taxi type
+// @Tags         taxi-types
+// @Produce      json
+// @Param        code  path      string  true  "Taxi type code"
+// @Success      200   {object}  models.TaxiType
+// @Router       /taxi-types/{code} [get]
+func (h *TaxiTypeHandler) getTaxiType(w http.ResponseWriter, r *http.Request, code string) {
+	taxiType, err := h.service.GetTaxiType(r.Context(), code)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taxiType)
+}
+
+// updateTaxiType godoc
+// @Summary      Update a taxi type
+// @Description  Changes names, color, seats, tariff reference and order
+// @Tags         taxi-types
+// @Accept       json
+// @Produce      json
+// @Param        code      path      string           true  "Taxi type code"
+// @Param        taxiType  body      models.TaxiType  true  "Catalog entry"
+// @Success      200       {object}  models.TaxiType
+// @Router       /taxi-types/{code} [put]
+func (h *TaxiTypeHandler) updateTaxiType(w http.ResponseWriter, r *http.Request, code string) {
+	var taxiType models.TaxiType
+	if err := json.NewDecoder(r.Body).Decode(&taxiType); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateTaxiType(r.Context(), code, &taxiType); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taxiType)
+}
+
+// deleteTaxiType godoc
+// @Summary      Delete a taxi type
+// @Description  Removes a type no vehicle is registered with and no other type takes its tariff from
+// @Tags         taxi-types
+// @Param        code  path  string  true  "Taxi type code"
+// @Success      204
+// @Router       /taxi-types/{code} [delete]
+func (h *TaxiTypeHandler) deleteTaxiType(w http.ResponseWriter, r *http.Request, code string) {
+	if err := h.service.DeleteTaxiType(r.Context(), code); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
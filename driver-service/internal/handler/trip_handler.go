@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type TripHandler struct {
+	service service.TripService
+}
+
+func NewTripHandler(service service.TripService) *TripHandler {
+	return &TripHandler{service: service}
+}
+
+// TripsRoot handles /trips endpoint
+func (h *TripHandler) TripsRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.requestTrip(w, r)
+	case http.MethodGet:
+		h.listTrips(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TripByID handles /trips/{id} and /trips/{id}/{action} endpoints
+func (h *TripHandler) TripByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/trips/"), "/")
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "missing trip id", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.getTrip(w, r, id)
+		return
+	}
+
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch parts[1] {
+	case "accept":
+		h.acceptTrip(w, r, id)
+	case "arrive":
+		h.advanceTrip(w, r, id, h.service.ArriveTrip)
+	case "start":
+		h.advanceTrip(w, r, id, h.service.StartTrip)
+	case "complete":
+		h.advanceTrip(w, r, id, h.service.CompleteTrip)
+	case "cancel":
+		h.cancelTrip(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// requestTrip godoc
+// @Summary      Request a trip
+// @Description  Creates a trip in the requested state with pickup and dropoff points
+// @Tags         trips
+// @Accept       json
+// @Produce      json
+// @Param        trip             body      models.Trip  true   "Rider, pickup and dropoff"
+// @Param        Idempotency-Key  header    string       false  "Retries with the same key get the first response"
+// @Success      201              {object}  map[string]string
+// @Failure      422              {string}  string
+// @Router       /trips [post]
+func (h *TripHandler) requestTrip(w http.ResponseWriter, r *http.Request) {
+	var trip models.Trip
+	if err := json.NewDecoder(r.Body).Decode(&trip); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.service.RequestTrip(r.Context(), &trip)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+// listTrips godoc
+// @Summary      List trips
+// @Description  Get trips with pagination, optionally filtered by driver, status and city
+// @Tags         trips
+// @Produce      json
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Param        driverId  query     string  false  "Driver ID"
+// @Param        status    query     string  false  "Trip status"
+// @Param        city      query     string  false  "City code"
+// @Success      200       {array}   models.Trip
+// @Router       /trips [get]
+func (h *TripHandler) listTrips(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+	trips, err := h.service.ListTrips(r.Context(), page, pageSize, q.Get("driverId"), q.Get("status"), q.Get("city"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if trips == nil {
+		trips = []models.Trip{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trips)
+}
+
+// getTrip godoc
+// @Summary      Get a trip
+// @Tags         trips
+// @Produce      json
+// @Param        id   path      string  true  "Trip ID"
+// @Success      200  {object}  models.Trip
+// @Router       /trips/{id} [get]
+func (h *TripHandler) getTrip(w http.ResponseWriter, r *http.Request, id string) {
+	trip, err := h.service.GetTrip(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
+
+// acceptTrip godoc
+// @Summary      Accept a trip
+// @Description  Assigns a free driver to a requested trip
+// @Tags         trips
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string             true  "Trip ID"
+// @Param        body  body      map[string]string  true  "{\"driverId\": \"...\"}"
+// @Success      200   {object}  models.Trip
+// @Router       /trips/{id}/accept [post]
+func (h *TripHandler) acceptTrip(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		DriverID string `json:"driverId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	trip, err := h.service.AcceptTrip(r.Context(), id, body.DriverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
+
+// advanceTrip godoc
+// @Summary      Advance a trip
+// @Description  Moves a trip to arrived, started or completed; completion computes the traveled distance
+// @Tags         trips
+// @Produce      json
+// @Param        id      path      string  true  "Trip ID"
+// @Param        action  path      string  true  "arrive, start or complete"
+// @Success      200     {object}  models.Trip
+// @Router       /trips/{id}/{action} [post]
+func (h *TripHandler) advanceTrip(w http.ResponseWriter, r *http.Request, id string, step func(ctx context.Context, id string) (*models.Trip, error)) {
+	trip, err := step(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
+
+// cancelTrip godoc
+// @Summary      Cancel a trip
+// @Description  Cancels a trip that has not started yet
+// @Tags         trips
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string             true   "Trip ID"
+// @Param        body  body      map[string]string  false  "{\"reason\": \"...\"}"
+// @Success      200   {object}  models.Trip
+// @Router       /trips/{id}/cancel [post]
+func (h *TripHandler) cancelTrip(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	// the reason is optional, so an empty body is fine
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	trip, err := h.service.CancelTrip(r.Context(), id, body.Reason)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trip)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type VehicleHandler struct {
+	service service.VehicleService
+}
+
+func NewVehicleHandler(service service.VehicleService) *VehicleHandler {
+	return &VehicleHandler{service: service}
+}
+
+// VehiclesRoot handles /vehicles endpoint
+func (h *VehicleHandler) VehiclesRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.createVehicle(w, r)
+	case http.MethodGet:
+		h.listVehicles(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// VehicleByID handles /vehicles/{id} and /vehicles/{id}/assignments endpoints
+func (h *VehicleHandler) VehicleByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/vehicles/"), "/")
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "missing vehicle id", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "assignments" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.vehicleAssignments(w, r, id)
+		return
+	}
+	if len(parts) > 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getVehicle(w, r, id)
+	case http.MethodPut:
+		h.updateVehicle(w, r, id)
+	case http.MethodDelete:
+		h.deleteVehicle(w, r, id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createVehicle godoc
+// @Summary      Register a vehicle
+// @Description  Adds a taxi; the plate is stored upper case without spaces and must be unique
+// @Tags         vehicles
+// @Accept       json
+// @Produce      json
+// @Param        vehicle  body      models.Vehicle  true  "Vehicle"
+// @Success      201      {object}  map[string]string
+// @Router       /vehicles [post]
+func (h *VehicleHandler) createVehicle(w http.ResponseWriter, r *http.Request) {
+	var vehicle models.Vehicle
+	if err := json.NewDecoder(r.Body).Decode(&vehicle); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.service.CreateVehicle(r.Context(), &vehicle)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+// listVehicles godoc
+// @Summary      List vehicles
+// @Description  Get vehicles with pagination, ordered by plate
+// @Tags         vehicles
+// @Produce      json
+// @Param        page      query     int  false  "Page number"
+// @Param        pageSize  query     int  false  "Page size"
+// @Success      200       {array}   models.Vehicle
+// @Router       /vehicles [get]
+func (h *VehicleHandler) listVehicles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+	vehicles, err := h.service.ListVehicles(r.Context(), page, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if vehicles == nil {
+		vehicles = []models.Vehicle{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicles)
+}
+
+// getVehicle godoc
+// @Summary      Get a vehicle
+// @Tags         vehicles
+// @Produce      json
+// @Param        id   path      string  true  "Vehicle ID"
+// @Success      200  {object}  models.Vehicle
+// @Router       /vehicles/{id} [get]
+func (h *VehicleHandler) getVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	vehicle, err := h.service.GetVehicle(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// updateVehicle godoc
+// @Summary      Update a vehicle
+// @Description  Changes a vehicle; drivers currently assigned to it see the new data
+// @Tags         vehicles
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string          true  "Vehicle ID"
+// @Param        vehicle  body      models.Vehicle  true  "Vehicle"
+// @Success      200      {object}  map[string]string
+// @Router       /vehicles/{id} [put]
+func (h *VehicleHandler) updateVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	var vehicle models.Vehicle
+	if err := json.NewDecoder(r.Body).Decode(&vehicle); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateVehicle(r.Context(), id, &vehicle); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// deleteVehicle godoc
+// @Summary      Delete a vehicle
+// @Description  Removes a vehicle that no driver is assigned to; the assignment history is kept
+// @Tags         vehicles
+// @Param        id   path  string  true  "Vehicle ID"
+// @Success      204
+// @Router       /vehicles/{id} [delete]
+func (h *VehicleHandler) deleteVehicle(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.service.DeleteVehicle(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// vehicleAssignments godoc
+// @Summary      Vehicle assignment history
+// @Description  Lists which drivers worked with the vehicle and when, newest first
+// @Tags         vehicles
+// @Produce      json
+// @Param        id   path      string  true  "Vehicle ID"
+// @Success      200  {array}   models.VehicleAssignment
+// @Router       /vehicles/{id}/assignments [get]
+func (h *VehicleHandler) vehicleAssignments(w http.ResponseWriter, r *http.Request, id string) {
+	writeAssignments(w, r, h.service, "", id)
+}
+
+// writeAssignments answers an assignment history query for a driver or a vehicle
+func writeAssignments(w http.ResponseWriter, r *http.Request, svc service.VehicleService, driverID, vehicleID string) {
+	assignments, err := svc.History(r.Context(), driverID, vehicleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if assignments == nil {
+		assignments = []models.VehicleAssignment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignments)
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// WebhooksRoot handles /webhooks endpoint
+func (h *WebhookHandler) WebhooksRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.createWebhook(w, r)
+	case http.MethodGet:
+		h.listWebhooks(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// WebhookByID handles /webhooks/{id}, /webhooks/{id}/deliveries, /webhooks/{id}/replay
+// and /webhooks/deliveries/{id}/replay endpoints
+func (h *WebhookHandler) WebhookByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/webhooks/"), "/")
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "missing webhook id", http.StatusBadRequest)
+		return
+	}
+
+	if id == "deliveries" {
+		if len(parts) != 3 || parts[1] == "" || parts[2] != "replay" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.replayDelivery(w, r, parts[1])
+		return
+	}
+
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "deliveries" && r.Method == http.MethodGet:
+			h.listDeliveries(w, r, id)
+		case parts[1] == "replay" && r.Method == http.MethodPost:
+			h.replayDead(w, r, id)
+		case parts[1] == "deliveries", parts[1] == "replay":
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	if len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getWebhook(w, r, id)
+	case http.MethodPut:
+		h.updateWebhook(w, r, id)
+	case http.MethodDelete:
+		h.deleteWebhook(w, r, id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createWebhook godoc
+// @Summary      Subscribe to driver events
+// @Description  Registers a URL that receives matching events as signed POST requests. The response carries the secret, it is not shown again.
+// @Description  X-Webhook-Signature is "sha256=" followed by the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body.
+// @Description  The URL must resolve to a public address; redirects are not followed. Fleet operators receive events of their own drivers only.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        webhook  body      models.WebhookSubscription  true  "URL, event types (all when empty) and optional secret"
+// @Success      201      {object}  map[string]string
+// @Router       /webhooks [post]
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var sub models.WebhookSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.service.CreateWebhook(r.Context(), &sub)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"id": id, "secret": sub.Secret})
+}
+
+// listWebhooks godoc
+// @Summary      List webhook subscriptions
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {array}   models.WebhookSubscription
+// @Router       /webhooks [get]
+func (h *WebhookHandler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// getWebhook godoc
+// @Summary      Get a webhook subscription
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Subscription ID"
+// @Success      200  {object}  models.WebhookSubscription
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) getWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	sub, err := h.service.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// updateWebhook godoc
+// @Summary      Update a webhook subscription
+// @Description  Replaces url and event types. active and the secret change only when they are given; inactive subscriptions keep queueing without sending.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                true  "Subscription ID"
+// @Param        webhook  body      models.WebhookUpdate  true  "URL, event types, and optionally active and a new secret"
+// @Success      200      {object}  map[string]string
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) updateWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	var update models.WebhookUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateWebhook(r.Context(), id, &update); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// deleteWebhook godoc
+// @Summary      Delete a webhook subscription
+// @Description  Removes the subscription with its pending and dead deliveries
+// @Tags         webhooks
+// @Param        id   path  string  true  "Subscription ID"
+// @Success      204
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.service.DeleteWebhook(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeliveries godoc
+// @Summary      List deliveries of a webhook
+// @Description  Lists deliveries newest first with attempts and the last error; status=dead lists the dead letters
+// @Tags         webhooks
+// @Produce      json
+// @Param        id        path      string  true   "Subscription ID"
+// @Param        status    query     string  false  "pending, delivered or dead"
+// @Param        page      query     int     false  "Page number"
+// @Param        pageSize  query     int     false  "Page size"
+// @Success      200       {array}   models.WebhookDelivery
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), id, q.Get("status"), page, pageSize)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// replayDead godoc
+// @Summary      Replay dead deliveries
+// @Description  Queues every dead delivery of the subscription again with fresh attempts
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Subscription ID"
+// @Success      202  {object}  map[string]int64
+// @Router       /webhooks/{id}/replay [post]
+func (h *WebhookHandler) replayDead(w http.ResponseWriter, r *http.Request, id string) {
+	n, err := h.service.ReplayDead(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int64{"requeued": n})
+}
+
+// replayDelivery godoc
+// @Summary      Replay a delivery
+// @Description  Queues one delivery again with fresh attempts, whether it is dead or was delivered
+// @Tags         webhooks
+// @Param        id   path  string  true  "Delivery ID"
+// @Success      202
+// @Router       /webhooks/deliveries/{id}/replay [post]
+func (h *WebhookHandler) replayDelivery(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.service.ReplayDelivery(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
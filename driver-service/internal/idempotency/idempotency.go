@@ -0,0 +1,161 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Header carries the client chosen key, e.g. a UUID per logical request
+	Header = "Idempotency-Key"
+	// ReplayedHeader marks responses served from the store
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// a key stays locked this long while its first request runs, then it is considered abandoned
+	lockTimeout = time.Minute
+)
+
+// Middleware replays the stored response when a request is retried with the same key
+type Middleware struct {
+	store repository.IdempotencyRepository
+	ttl   time.Duration
+}
+
+// New creates a middleware keeping responses for ttl
+func New(store repository.IdempotencyRepository, ttl time.Duration) *Middleware {
+	return &Middleware{store: store, ttl: ttl}
+}
+
+// Wrap makes unsafe requests to next idempotent when they carry an Idempotency-Key.
+// A key reused with another body is answered with 422, one whose request is still running with 409.
+// Server errors are stored like any other response: the request may have been partly applied,
+// so running it again could for example create a driver twice. Clients retry with a new key.
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		// keys are per tenant and endpoint, two fleets may well pick the same one
+		id := strings.Join([]string{tenant.ID(r.Context()), r.Method, r.URL.Path, key}, " ")
+		now := time.Now()
+		owner := primitive.NewObjectID().Hex()
+		record := &models.IdempotencyRecord{ID: id, RequestHash: hash, Owner: owner, CreatedAt: now, ExpiresAt: now.Add(lockTimeout)}
+
+		err = m.store.Reserve(r.Context(), record)
+		if errors.Is(err, repository.ErrIdempotencyKeyInUse) {
+			m.answerRetry(w, r, id, hash)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// a dropped connection is the usual reason for the retry, finish the bookkeeping regardless
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			// a handler that panicked wrote nothing worth replaying
+			if !completed {
+				if err := m.store.Release(ctx, id, owner); err != nil {
+					log.Printf("WARN: could not release Idempotency-Key %q: %v", key, err)
+				}
+			}
+		}()
+
+		rec := &recorder{ResponseWriter: w}
+		next(rec, r)
+		completed = true
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		err = m.store.Complete(ctx, id, owner, rec.status, rec.header, rec.body.Bytes(), time.Now().Add(m.ttl))
+		if errors.Is(err, repository.ErrIdempotencyRecordNotFound) {
+			log.Printf("WARN: request with Idempotency-Key %q outlived its %s lock, another request took the key over", key, lockTimeout)
+		} else if err != nil {
+			log.Printf("WARN: could not store idempotent response for %q: %v", key, err)
+		}
+	}
+}
+
+// answerRetry replays the stored response of a key that is already taken
+func (m *Middleware) answerRetry(w http.ResponseWriter, r *http.Request, id, hash string) {
+	record, err := m.store.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrIdempotencyRecordNotFound) {
+		// released by a first attempt that panicked in the meantime
+		http.Error(w, "request with this Idempotency-Key failed, retry", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case record.RequestHash != hash:
+		http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+	case !record.Done:
+		http.Error(w, "request with this Idempotency-Key is still being processed", http.StatusConflict)
+	default:
+		maps.Copy(w.Header(), record.Header)
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(record.Status)
+		w.Write(record.Body)
+	}
+}
+
+// recorder passes the response through and keeps a copy
+type recorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+		rec.header = rec.Header().Clone()
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// memStore keeps records in memory with the semantics of the mongo repository
+type memStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func (s *memStore) Reserve(ctx context.Context, record *models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.records[record.ID]; ok && old.ExpiresAt.After(time.Now()) {
+		return repository.ErrIdempotencyKeyInUse
+	}
+	s.records[record.ID] = *record
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, id string) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, repository.ErrIdempotencyRecordNotFound
+	}
+	return &record, nil
+}
+
+func (s *memStore) Complete(ctx context.Context, id, owner string, status int, header map[string][]string, body []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.Owner != owner || record.Done {
+		return repository.ErrIdempotencyRecordNotFound
+	}
+	record.Done, record.Status, record.Header, record.Body, record.ExpiresAt = true, status, header, body, expiresAt
+	s.records[id] = record
+	return nil
+}
+
+func (s *memStore) Release(ctx context.Context, id, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	if !ok || record.Owner != owner || record.Done {
+		return repository.ErrIdempotencyRecordNotFound
+	}
+	delete(s.records, id)
+	return nil
+}
+
+func post(h http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/drivers", strings.NewReader(body))
+	r.Header.Set(Header, key)
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestServerErrorsAreReplayed(t *testing.T) {
+	calls := 0
+	h := New(&memStore{records: make(map[string]models.IdempotencyRecord)}, time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// e.g. the driver was inserted, then a later write failed
+		http.Error(w, "vehicle assignment failed", http.StatusInternalServerError)
+	})
+
+	first := post(h, "k1", `{"firstName":"Ali"}`)
+	retry := post(h, "k1", `{"firstName":"Ali"}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Fatalf("retry got %d %q, want the replayed %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if post(h, "k1", `{"firstName":"Veli"}`).Code != http.StatusUnprocessableEntity {
+		t.Fatal("key reused with another body was accepted")
+	}
+}
+
+func TestTakenOverKeyIsNotOverwritten(t *testing.T) {
+	store := &memStore{records: make(map[string]models.IdempotencyRecord)}
+	m := New(store, time.Hour)
+
+	// the first request outlives its lock and a retry takes the key over meanwhile
+	var retry *httptest.ResponseRecorder
+	slow := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		store.mu.Lock()
+		for id, record := range store.records {
+			record.ExpiresAt = time.Now().Add(-time.Second)
+			store.records[id] = record
+		}
+		store.mu.Unlock()
+
+		retry = post(m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("second"))
+		}), "k1", "{}")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("first"))
+	})
+	post(slow, "k1", "{}")
+
+	if retry.Body.String() != "second" {
+		t.Fatalf("retry answered %q", retry.Body.String())
+	}
+	replay := post(m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("completed key ran again")
+	}), "k1", "{}")
+	if replay.Body.String() != "second" {
+		t.Fatalf("replay is %q, want the response of the request owning the key", replay.Body.String())
+	}
+}
+
+func TestPanicReleasesTheKey(t *testing.T) {
+	store := &memStore{records: make(map[string]models.IdempotencyRecord)}
+	h := New(store, time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() { recover() }()
+		post(h, "k1", "{}")
+	}()
+	if len(store.records) != 0 {
+		t.Fatalf("key is still held after a panic: %+v", store.records)
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Attribute is a feature a rider can require, e.g. a wheelchair ramp
+type Attribute string
+
+const (
+	AttrWheelchairAccessible Attribute = "wheelchair_accessible"
+	AttrLargeTrunk           Attribute = "large_trunk"
+	AttrChildSeat            Attribute = "child_seat"
+	AttrPetFriendly          Attribute = "pet_friendly"
+	AttrEnglishSpeaking      Attribute = "english_speaking"
+)
+
+// attribute owners
+const (
+	AttributeScopeDriver  = "driver"
+	AttributeScopeVehicle = "vehicle"
+)
+
+// AttributeScopes tells whether an attribute belongs to the driver or to the vehicle
+var AttributeScopes = map[Attribute]string{
+	AttrWheelchairAccessible: AttributeScopeVehicle,
+	AttrLargeTrunk:           AttributeScopeVehicle,
+	AttrChildSeat:            AttributeScopeVehicle,
+	AttrPetFriendly:          AttributeScopeDriver,
+	AttrEnglishSpeaking:      AttributeScopeDriver,
+}
+
+// ParseAttributes reads a comma separated list like "pet_friendly,large_trunk"
+func ParseAttributes(raw string) ([]Attribute, error) {
+	var attrs []Attribute
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			attrs = append(attrs, Attribute(part))
+		}
+	}
+	return NormalizeAttributes(attrs, "")
+}
+
+// NormalizeAttributes checks that every attribute is known and belongs to the scope
+// ("" allows both) and returns them lower cased, sorted and without duplicates
+func NormalizeAttributes(attrs []Attribute, scope string) ([]Attribute, error) {
+	var out []Attribute
+	for _, a := range attrs {
+		a = Attribute(strings.ToLower(strings.TrimSpace(string(a))))
+		owner, ok := AttributeScopes[a]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute %q", a)
+		}
+		if scope != "" && owner != scope {
+			return nil, fmt.Errorf("attribute %q belongs to the %s", a, owner)
+		}
+		if !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// HasAttributes reports whether the driver and the current vehicle together offer every required attribute
+func (d *Driver) HasAttributes(required []Attribute) bool {
+	for _, a := range required {
+		if !slices.Contains(d.Attributes, a) && !slices.Contains(d.VehicleAttributes, a) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// audited entities
+const (
+	AuditDriver  = "driver"
+	AuditVehicle = "vehicle"
+)
+
+// audit actions
+const (
+	AuditCreate          = "create"
+	AuditUpdate          = "update"
+	AuditDelete          = "delete"
+	AuditStatusChange    = "status_change"
+	AuditVehicleAssign   = "vehicle_assign"
+	AuditVehicleUnassign = "vehicle_unassign"
+	AuditVehicleChange   = "vehicle_change" // the assigned vehicle was edited, the driver's copy follows
+	AuditShiftStart      = "shift_start"
+	AuditShiftEnd        = "shift_end"
+	AuditRatingChange    = "rating_change"
+)
+
+// FieldChange is one field that differs between the record before and after a write.
+// Before is empty on create, After on delete.
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditEntry records one write to a driver or vehicle; entries are never changed or removed
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Entity    string             `bson:"entity" json:"entity"` // "driver" or "vehicle"
+	EntityID  string             `bson:"entityId" json:"entityId"`
+	Action    string             `bson:"action" json:"action"`
+	Actor     string             `bson:"actor,omitempty" json:"actor,omitempty"` // user from the gateway token, "system" for jobs
+	RequestID string             `bson:"requestId,omitempty" json:"requestId,omitempty"`
+	Changes   []FieldChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+
+	// TenantID is the fleet operator owning the record
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// AuditQuery filters the audit log; empty fields match everything
+type AuditQuery struct {
+	Entity    string
+	EntityID  string
+	Action    string
+	Actor     string
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Page      int
+	PageSize  int
+}
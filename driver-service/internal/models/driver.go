@@ -6,7 +6,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// driver struct represents a taxi driver in the system
+// driver struct represents a taxi driver in the system.
+// Plate, TaxiType, CarBrand and CarModel mirror the currently assigned vehicle
+// so searches can filter on them; the vehicles collection is the source of truth.
 type Driver struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	FirstName string             `bson:"firstName" json:"firstName"`
@@ -16,8 +18,29 @@ type Driver struct {
 	CarBrand  string             `bson:"carBrand" json:"carBrand"`
 	CarModel  string             `bson:"carModel" json:"carModel"`
 	Location  Location           `bson:"location" json:"location"`
+	City      string             `bson:"city" json:"city"`                         // licensing city, inferred from the location when not given
+	Status    string             `bson:"status" json:"status"`                     // onboarding state, e.g. "pending", "approved"
+	OnShift   bool               `bson:"onShift" json:"onShift"`                   // maintained by shift start, end and handover
+	Rating    *DriverRating      `bson:"rating,omitempty" json:"rating,omitempty"` // aggregated from visible reviews
+	Vehicle   *Vehicle           `bson:"-" json:"vehicle,omitempty"`               // resolved on read
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+
+	// Attributes are the driver's own, VehicleAttributes mirror the current vehicle
+	Attributes        []Attribute `bson:"attributes,omitempty" json:"attributes,omitempty"`
+	VehicleAttributes []Attribute `bson:"vehicleAttributes,omitempty" json:"vehicleAttributes,omitempty"`
+
+	// LocationAt is the time of the last position update, checked against the city's heartbeat
+	LocationAt *time.Time `bson:"locationAt,omitempty" json:"locationAt,omitempty"`
+
+	// DeletedAt marks a deleted driver; the tombstone is only served by the changes feed
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+
+	// TenantID is the fleet operator the driver belongs to, empty for drivers of the platform itself
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+
+	// Version increases on every write except position updates and is served as the ETag
+	Version int64 `bson:"version" json:"version"`
 }
 
 // location represents geospatial coordinates
@@ -25,3 +48,58 @@ type Location struct {
 	Lat float64 `bson:"lat" json:"lat"`
 	Lon float64 `bson:"lon" json:"lon"`
 }
+
+// LocationUpdate is a position report as sent by the driver app
+type LocationUpdate struct {
+	Lat      float64    `json:"lat"`
+	Lon      float64    `json:"lon"`
+	Accuracy float64    `json:"accuracy,omitempty"` // meters, optional
+	Ts       *time.Time `json:"ts,omitempty"`       // defaults to the time of arrival, at most 30s ahead of the server clock
+}
+
+// LocationPing is a single position report sent by a driver.
+// Location is the smoothed position, Raw the one the phone reported.
+type LocationPing struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DriverID  string             `bson:"driverId" json:"driverId"`
+	Location  Location           `bson:"location" json:"location"`
+	Raw       Location           `bson:"raw" json:"raw"`
+	Accuracy  float64            `bson:"accuracy,omitempty" json:"accuracy,omitempty"`
+	SpeedKmh  float64            `bson:"speedKmh" json:"speedKmh"`
+	Flag      string             `bson:"flag,omitempty" json:"flag,omitempty"` // "teleport" or "out_of_order"
+	Rejected  bool               `bson:"rejected,omitempty" json:"rejected,omitempty"`
+	Timestamp time.Time          `bson:"ts" json:"ts"`
+}
+
+// LocationBatchItem is one entry of a batch location upload
+type LocationBatchItem struct {
+	DriverID string `json:"driverId"`
+	LocationUpdate
+}
+
+// status values of LocationBatchResult
+const (
+	LocationQueued   = "queued" // accepted, the position is written with the next flush
+	LocationFlagged  = "flagged"
+	LocationRejected = "rejected"
+	LocationError    = "error"
+)
+
+// LocationBatchResult reports what happened to one batch entry
+type LocationBatchResult struct {
+	Index    int       `json:"index"`
+	DriverID string    `json:"driverId"`
+	Status   string    `json:"status"` // "queued", "flagged", "rejected" or "error"
+	Location *Location `json:"location,omitempty"`
+	Flag     string    `json:"flag,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// DriverChanges is a page of the changes feed. Next resumes after the last driver in the page,
+// or where the request started when nothing changed; More asks the client to call again at once.
+// Deleted drivers come with DeletedAt set.
+type DriverChanges struct {
+	Drivers []Driver `json:"drivers"`
+	Next    string   `json:"next"`
+	More    bool     `json:"more"`
+}
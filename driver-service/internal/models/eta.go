@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// AnyWeekday and AnyHour make a speed profile apply to every weekday or hour
+const (
+	AnyWeekday = -1
+	AnyHour    = -1
+)
+
+// SpeedProfile is the average taxi speed for a zone at a weekday and hour of the day.
+// Empty zone, AnyWeekday and AnyHour act as wildcards.
+type SpeedProfile struct {
+	Zone      string    `bson:"zone" json:"zone"`
+	Weekday   int       `bson:"weekday" json:"weekday"` // 0 = Sunday, -1 = any
+	Hour      int       `bson:"hour" json:"hour"`       // 0-23, -1 = any
+	SpeedKmh  float64   `bson:"speedKmh" json:"speedKmh"`
+	Samples   int       `bson:"samples" json:"samples"` // completed trips behind a learned profile, 0 when set by hand
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
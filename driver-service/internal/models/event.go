@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// domain event types
+const (
+	EventDriverCreated       = "driver.created"
+	EventDriverUpdated       = "driver.updated"
+	EventDriverStatusChanged = "driver.status_changed"
+	EventDriverLocationMoved = "driver.location_moved"
+	EventDriverDeleted       = "driver.deleted"
+)
+
+// Event is a domain event stored in the outbox together with the change it describes.
+// Seq orders events of all drivers; consumers may see an event more than once.
+type Event struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Seq       int64              `bson:"seq" json:"seq"`
+	Type      string             `bson:"type" json:"type"`
+	DriverID  string             `bson:"driverId" json:"driverId"`
+	TenantID  string             `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+	RequestID string             `bson:"requestId,omitempty" json:"requestId,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+
+	// Data is the driver for created, updated and deleted, the DriverStatusChange for
+	// status_changed and a DriverMoved for location_moved
+	Data json.RawMessage `bson:"data" json:"data" swaggertype:"object"`
+}
+
+// DriverMoved is the data of a driver.location_moved event
+type DriverMoved struct {
+	Location Location  `json:"location"`
+	At       time.Time `json:"at"`
+}
+
+// NewEvent encodes data into an event about a driver
+func NewEvent(eventType string, driverID, tenantID string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, DriverID: driverID, TenantID: tenantID, Data: raw}, nil
+}
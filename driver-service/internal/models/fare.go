@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tariff is one version of the price table of a taxi type.
+// A new version is added instead of editing an old one, so past trips can be re-priced.
+type Tariff struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	City          string             `bson:"city" json:"city"`
+	TaxiType      string             `bson:"taxiType" json:"taxiType"`
+	Version       int                `bson:"version" json:"version"`
+	EffectiveFrom time.Time          `bson:"effectiveFrom" json:"effectiveFrom"`
+	Currency      string             `bson:"currency" json:"currency"`
+
+	OpeningFee       float64 `bson:"openingFee" json:"openingFee"`
+	PerKm            float64 `bson:"perKm" json:"perKm"`
+	PerMinuteWaiting float64 `bson:"perMinuteWaiting" json:"perMinuteWaiting"`
+	MinimumFare      float64 `bson:"minimumFare" json:"minimumFare"`
+
+	// night tariff applies between NightStartHour and NightEndHour local time
+	NightMultiplier float64 `bson:"nightMultiplier" json:"nightMultiplier"`
+	NightStartHour  int     `bson:"nightStartHour" json:"nightStartHour"`
+	NightEndHour    int     `bson:"nightEndHour" json:"nightEndHour"`
+
+	// BridgeSurcharge is added when the trip crosses the Bosphorus
+	BridgeSurcharge float64 `bson:"bridgeSurcharge" json:"bridgeSurcharge"`
+	// Surcharges are named tolls (e.g. "airport", "highway") the caller can ask for
+	Surcharges map[string]float64 `bson:"surcharges,omitempty" json:"surcharges,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// FareEstimateRequest is the input of a fare estimate
+type FareEstimateRequest struct {
+	Pickup         Location   `json:"pickup"`
+	Dropoff        Location   `json:"dropoff"`
+	TaxiType       string     `json:"taxiType"`
+	City           string     `json:"city,omitempty"` // inferred from the pickup point when empty
+	At             *time.Time `json:"at,omitempty"`   // defaults to now
+	WaitingMinutes float64    `json:"waitingMinutes,omitempty"`
+	Surcharges     []string   `json:"surcharges,omitempty"`
+}
+
+// FareLine is one named amount of a fare breakdown
+type FareLine struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// FareEstimate is a priced trip with its breakdown
+type FareEstimate struct {
+	City           string     `json:"city"`
+	TaxiType       string     `json:"taxiType"`
+	TariffVersion  int        `json:"tariffVersion"`
+	Currency       string     `json:"currency"`
+	DistanceKm     float64    `json:"distanceKm"`
+	WaitingMinutes float64    `json:"waitingMinutes"`
+	Night          bool       `json:"night"`
+	Lines          []FareLine `json:"lines"`
+	MinimumApplied bool       `json:"minimumApplied"`
+	Total          float64    `json:"total"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// FleetPosition is where a driver was and which onboarding state they were in at one instant
+type FleetPosition struct {
+	DriverID   string     `bson:"driverId" json:"driverId"`
+	Status     string     `bson:"status" json:"status"`
+	Location   *Location  `bson:"location,omitempty" json:"location,omitempty"` // nil until the driver reported a position
+	LocationAt *time.Time `bson:"locationAt,omitempty" json:"locationAt,omitempty"`
+	TenantID   string     `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// FleetSnapshot is every driver that existed at At, rebuilt from the location history and
+// the status log, starting from the checkpoint taken at CheckpointAt when there is one
+type FleetSnapshot struct {
+	At           time.Time       `json:"at"`
+	CheckpointAt *time.Time      `json:"checkpointAt,omitempty"`
+	Drivers      []FleetPosition `json:"drivers"`
+}
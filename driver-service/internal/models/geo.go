@@ -0,0 +1,19 @@
+package models
+
+// MatrixRequest asks for pairwise distances from every origin to every destination
+type MatrixRequest struct {
+	Origins      []Location `json:"origins"`
+	Destinations []Location `json:"destinations"`
+}
+
+// MatrixElement is the result for one origin/destination pair
+type MatrixElement struct {
+	DistanceKm float64 `json:"distanceKm"`
+	EtaMinutes float64 `json:"etaMinutes"`
+	Routed     bool    `json:"routed"` // true when the distance comes from the road network
+}
+
+// MatrixResponse holds one row per origin and one column per destination
+type MatrixResponse struct {
+	Rows [][]MatrixElement `json:"rows"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the first response to a request sent with an Idempotency-Key
+// so retries get exactly the same answer
+type IdempotencyRecord struct {
+	ID          string              `bson:"_id"`         // tenant, method, path and key
+	RequestHash string              `bson:"requestHash"` // sha256 of the request body
+	Owner       string              `bson:"owner"`       // random per reservation, only the owner completes or releases it
+	Done        bool                `bson:"done"`        // false while the first request is still running
+	Status      int                 `bson:"status,omitempty"`
+	Header      map[string][]string `bson:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty"`
+	CreatedAt   time.Time           `bson:"createdAt"`
+	ExpiresAt   time.Time           `bson:"expiresAt"` // removed by a TTL index afterwards
+}
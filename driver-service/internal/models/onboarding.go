@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// driver onboarding states
+const (
+	DriverPending   = "pending"
+	DriverApproved  = "approved"
+	DriverSuspended = "suspended"
+	DriverRejected  = "rejected"
+)
+
+// DriverStatusTransitions lists the states a driver may move to from each state
+var DriverStatusTransitions = map[string][]string{
+	DriverPending:   {DriverApproved, DriverRejected},
+	DriverApproved:  {DriverSuspended},
+	DriverSuspended: {DriverApproved, DriverRejected},
+	DriverRejected:  {DriverPending},
+}
+
+// CanWork reports whether a driver may be dispatched, take trips and start shifts.
+// Drivers created before onboarding existed have no status and count as approved.
+func (d *Driver) CanWork() bool {
+	return d.Status == DriverApproved || d.Status == ""
+}
+
+// DriverStatusChange records one onboarding transition
+type DriverStatusChange struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DriverID string             `bson:"driverId" json:"driverId"`
+	From     string             `bson:"from" json:"from"`
+	To       string             `bson:"to" json:"to"`
+	Reason   string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Actor    string             `bson:"actor,omitempty" json:"actor,omitempty"` // "system" for automatic suspensions
+	At       time.Time          `bson:"at" json:"at"`
+	// TenantID is the fleet operator of the driver
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// StatusChangeRequest asks for an onboarding transition
+type StatusChangeRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// tracked driver document types
+const (
+	DocLicense           = "license"
+	DocPsychotechnic     = "psychotechnic"
+	DocVehicleInspection = "vehicle_inspection"
+	DocInsurance         = "insurance"
+)
+
+// DocumentTypes are the document types the service tracks
+var DocumentTypes = []string{DocLicense, DocPsychotechnic, DocVehicleInspection, DocInsurance}
+
+// DriverDocument is the current copy of one document type of a driver
+type DriverDocument struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DriverID  string             `bson:"driverId" json:"driverId"`
+	Type      string             `bson:"type" json:"type"`
+	Number    string             `bson:"number,omitempty" json:"number,omitempty"`
+	IssuedAt  *time.Time         `bson:"issuedAt,omitempty" json:"issuedAt,omitempty"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// TenantID is the fleet operator of the driver
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// review moderation states
+const (
+	ReviewVisible = "visible"
+	ReviewHidden  = "hidden"
+)
+
+// ReviewTags are the tags a rider can pick
+var ReviewTags = []string{
+	"clean_car", "polite", "safe_driving", "knows_route", "comfortable_ride",
+	"dirty_car", "rude", "unsafe_driving", "wrong_route", "late",
+}
+
+// Review is a rider's rating of a driver after a completed trip
+type Review struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TripID   string             `bson:"tripId" json:"tripId"`
+	DriverID string             `bson:"driverId" json:"driverId"`
+	RiderID  string             `bson:"riderId" json:"riderId"`
+	Stars    int                `bson:"stars" json:"stars"` // 1 to 5
+	Tags     []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Comment  string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	// hidden reviews stay stored but do not count towards the rating
+	Status           string     `bson:"status" json:"status"`
+	ModerationReason string     `bson:"moderationReason,omitempty" json:"moderationReason,omitempty"`
+	ModeratedAt      *time.Time `bson:"moderatedAt,omitempty" json:"moderatedAt,omitempty"`
+	CreatedAt        time.Time  `bson:"createdAt" json:"createdAt"`
+	// TenantID is the fleet operator of the driver
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// ModerationRequest shows or hides a review
+type ModerationRequest struct {
+	Status string `json:"status"` // "visible" or "hidden"
+	Reason string `json:"reason"`
+}
+
+// DriverRating aggregates the visible reviews of a driver
+type DriverRating struct {
+	Average   float64   `bson:"average" json:"average"`
+	Count     int       `bson:"count" json:"count"`
+	Average30 float64   `bson:"average30d" json:"average30d"` // reviews of the last 30 days
+	Count30   int       `bson:"count30d" json:"count30d"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NearbyQuery holds the filters and ordering of a nearby search
+type NearbyQuery struct {
+	Lat       float64
+	Lon       float64
+	TaxiType  string
+	City      string      // inferred from the coordinates when empty
+	Sort      string      // "distance" (default), "eta" or "rating"
+	MinRating float64     // 0 keeps unrated drivers
+	Requires  []Attribute // drivers must offer all of them
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ride request statuses
+const (
+	RideStatusPending = "pending"
+	RideStatusOffered = "offered"
+	RideStatusExpired = "expired" // no driver was found before the request timed out
+)
+
+// RideRequest is a rider asking for a taxi at a pickup point
+type RideRequest struct {
+	ID          string      `json:"id"`
+	Pickup      Location    `json:"pickup"`
+	TaxiType    string      `json:"taxiType,omitempty"` // empty means any type
+	Requires    []Attribute `json:"requires,omitempty"` // the driver must offer all of them
+	City        string      `json:"city,omitempty"`     // inferred from the pickup point when empty
+	Status      string      `json:"status"`
+	RequestedAt time.Time   `json:"requestedAt"`
+	Offer       *Offer      `json:"offer,omitempty"`
+	TenantID    string      `json:"tenantId,omitempty"` // the fleet operator that requested the ride
+}
+
+// Offer is a dispatch proposal sending a driver to a ride request
+type Offer struct {
+	RequestID  string    `json:"requestId"`
+	DriverID   string    `json:"driverId"`
+	DistanceKm float64   `json:"distanceKm"`
+	OfferedAt  time.Time `json:"offeredAt"`
+}
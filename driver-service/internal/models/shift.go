@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reasons a shift ended
+const (
+	ShiftEnded    = "ended"
+	ShiftHandover = "handover"
+)
+
+// Shift is a period in which a driver is on duty with a vehicle.
+// A vehicle has at most one driver on shift at a time.
+type Shift struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DriverID  string             `bson:"driverId" json:"driverId"`
+	VehicleID string             `bson:"vehicleId" json:"vehicleId"`
+	Plate     string             `bson:"plate" json:"plate"`
+	StartedAt time.Time          `bson:"startedAt" json:"startedAt"`
+	EndedAt   *time.Time         `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	EndReason string             `bson:"endReason,omitempty" json:"endReason,omitempty"` // "ended" or "handover"
+	// HandoverFrom is the driver whose shift this one took over
+	HandoverFrom string `bson:"handoverFrom,omitempty" json:"handoverFrom,omitempty"`
+	Active       bool   `bson:"active" json:"active"`
+	// TenantID is the fleet operator of the driver
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// ShiftHours sums a driver's time on shift over a period
+type ShiftHours struct {
+	DriverID string  `json:"driverId"`
+	Shifts   int     `json:"shifts"`
+	Hours    float64 `json:"hours"`
+}
+
+// HandoverRequest passes a vehicle from the driver on shift to the next one
+type HandoverRequest struct {
+	VehicleID  string `json:"vehicleId"`
+	ToDriverID string `json:"toDriverId"`
+}
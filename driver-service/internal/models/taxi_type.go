@@ -0,0 +1,36 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// TaxiType is a catalog entry, e.g. Istanbul's yellow, turquoise and black taxis.
+// Drivers, vehicles and tariffs refer to it by Code.
+type TaxiType struct {
+	Code   string `bson:"_id" json:"code"` // lower case, e.g. "turquoise"; cannot change
+	NameTR string `bson:"nameTr" json:"nameTr"`
+	NameEN string `bson:"nameEn" json:"nameEn"`
+	Color  string `bson:"color" json:"color"` // hex, e.g. "#FFD100"
+	Seats  int    `bson:"seats" json:"seats"` // passenger capacity
+	// TariffCode is the taxi type whose tariff prices trips, the code itself when empty
+	TariffCode string `bson:"tariffCode,omitempty" json:"tariffCode,omitempty"`
+	Order      int    `bson:"order" json:"order"` // position of the filter button
+	// Cities lists where the type operates, every city when empty
+	Cities    []string  `bson:"cities,omitempty" json:"cities,omitempty"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OperatesIn reports whether the type is offered in a city
+func (t *TaxiType) OperatesIn(city string) bool {
+	return len(t.Cities) == 0 || slices.Contains(t.Cities, city)
+}
+
+// Tariff returns the code tariffs are looked up with
+func (t *TaxiType) Tariff() string {
+	if t.TariffCode != "" {
+		return t.TariffCode
+	}
+	return t.Code
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trip lifecycle states
+const (
+	TripRequested = "requested"
+	TripAccepted  = "accepted"
+	TripArrived   = "arrived"
+	TripStarted   = "started"
+	TripCompleted = "completed"
+	TripCancelled = "cancelled"
+)
+
+// TripTransitions lists the states a trip may move to from each state
+var TripTransitions = map[string][]string{
+	TripRequested: {TripAccepted, TripCancelled},
+	TripAccepted:  {TripArrived, TripCancelled},
+	TripArrived:   {TripStarted, TripCancelled},
+	TripStarted:   {TripCompleted},
+}
+
+// IsActiveTripStatus reports whether a driver is busy with a trip in this state
+func IsActiveTripStatus(status string) bool {
+	return status == TripAccepted || status == TripArrived || status == TripStarted
+}
+
+// Trip is a single ride from pickup to dropoff
+type Trip struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RiderID    string             `bson:"riderId" json:"riderId"`
+	DriverID   string             `bson:"driverId,omitempty" json:"driverId,omitempty"` // set when a driver accepts
+	Pickup     Location           `bson:"pickup" json:"pickup"`
+	Dropoff    Location           `bson:"dropoff" json:"dropoff"`
+	TaxiType   string             `bson:"taxiType,omitempty" json:"taxiType,omitempty"` // requested type, or the accepting driver's
+	City       string             `bson:"city,omitempty" json:"city,omitempty"`         // inferred from the pickup point when empty
+	Status     string             `bson:"status" json:"status"`
+	Active     bool               `bson:"active" json:"-"`              // true while the driver is busy, backs the unique index
+	DistanceKm float64            `bson:"distanceKm" json:"distanceKm"` // traveled distance, computed on completion
+
+	CancelReason string `bson:"cancelReason,omitempty" json:"cancelReason,omitempty"`
+
+	// one timestamp per transition
+	RequestedAt time.Time  `bson:"requestedAt" json:"requestedAt"`
+	AcceptedAt  *time.Time `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+	ArrivedAt   *time.Time `bson:"arrivedAt,omitempty" json:"arrivedAt,omitempty"`
+	StartedAt   *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	CompletedAt *time.Time `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	CancelledAt *time.Time `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+
+	// TenantID is the fleet operator of the accepting driver; requested trips belong to no one yet
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
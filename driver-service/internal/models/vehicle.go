@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Vehicle is a licensed taxi; one plate is often shared by drivers working shifts
+type Vehicle struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Plate     string             `bson:"plate" json:"plate"` // stored upper case without spaces, e.g. "34ABC123"
+	TaxiType  string             `bson:"taxiType" json:"taxiType"`
+	CarBrand  string             `bson:"carBrand" json:"carBrand"`
+	CarModel  string             `bson:"carModel" json:"carModel"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+
+	// only vehicle scoped attributes, e.g. "wheelchair_accessible"
+	Attributes []Attribute `bson:"attributes,omitempty" json:"attributes,omitempty"`
+
+	// TenantID is the fleet operator owning the vehicle, empty for vehicles of the platform itself
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// VehicleAssignment is a period in which a driver works with a vehicle.
+// The active assignment has no end; a driver has at most one.
+type VehicleAssignment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DriverID  string             `bson:"driverId" json:"driverId"`
+	VehicleID string             `bson:"vehicleId" json:"vehicleId"`
+	From      time.Time          `bson:"from" json:"from"`
+	To        *time.Time         `bson:"to,omitempty" json:"to,omitempty"`
+	Active    bool               `bson:"active" json:"active"`
+	// TenantID is the fleet operator of the driver
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// NormalizePlate upper cases a plate and drops spaces so "34 abc 123" and "34ABC123" are the same vehicle
+func NormalizePlate(plate string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(plate), ""))
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventTypes are the event types webhooks can subscribe to
+var EventTypes = []string{EventDriverCreated, EventDriverUpdated, EventDriverStatusChanged, EventDriverLocationMoved, EventDriverDeleted}
+
+// WebhookSubscription asks for driver events to be posted to a URL
+type WebhookSubscription struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL    string             `bson:"url" json:"url"`
+	Events []string           `bson:"events,omitempty" json:"events,omitempty"` // event types to send, every type when empty
+	// Secret signs deliveries; generated when not given and only returned when set
+	Secret    string    `bson:"secret" json:"secret,omitempty"`
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+
+	// TenantID is the fleet operator whose events are sent, every tenant for platform subscriptions
+	TenantID string `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
+
+// WebhookUpdate replaces the url and event types of a subscription; active and the secret
+// only change when they are sent
+type WebhookUpdate struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// Wants reports whether an event goes to the subscription; paused subscriptions want events
+// too, they are queued until the subscription is active again
+func (s *WebhookSubscription) Wants(e *Event) bool {
+	if s.TenantID != "" && s.TenantID != e.TenantID {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // gave up after the last attempt, kept until replayed
+)
+
+// WebhookDelivery is one event on its way to one subscription
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID string             `bson:"subscriptionId" json:"subscriptionId"`
+	Event          Event              `bson:"event" json:"event"`
+	Status         string             `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LastStatusCode int                `bson:"lastStatusCode,omitempty" json:"lastStatusCode,omitempty"`
+	LastError      string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	DeliveredAt    *time.Time         `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+
+	// ExpiresAt removes delivered entries after the outbox retention; dead ones stay
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty" json:"-"`
+	TenantID  string     `bson:"tenantId,omitempty" json:"tenantId,omitempty"`
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+// Handler reacts to an event; an error makes the relay deliver the batch again
+type Handler func(ctx context.Context, event models.Event) error
+
+// Bus is a sink that calls handlers registered in this process
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler // by event type, "" for every type
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for one event type, or for every type when eventType is empty
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+// Name logic
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Publish calls the handlers of each event in order, stopping at the first error
+func (b *Bus) Publish(ctx context.Context, events []models.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, e := range events {
+		if err := call(ctx, e, b.handlers[e.Type]); err != nil {
+			return err
+		}
+		if e.Type == "" {
+			continue
+		}
+		if err := call(ctx, e, b.handlers[""]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func call(ctx context.Context, e models.Event, handlers []Handler) error {
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
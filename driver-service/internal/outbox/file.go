@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+// FileSink appends events to a file, one JSON object per line (NDJSON)
+type FileSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens the file for appending, creating it when needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// Name logic
+func (s *FileSink) Name() string {
+	return "file:" + s.path
+}
+
+// Publish writes the batch and syncs it to disk before the relay moves on
+func (s *FileSink) Publish(ctx context.Context, events []models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
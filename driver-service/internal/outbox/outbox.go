@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// Sink receives events from the relay. Delivery is at least once: after a failure or a restart
+// a sink sees events again, so it has to tolerate duplicates (the Seq of an event is stable).
+type Sink interface {
+	// Name identifies the sink's position in the outbox; renaming a sink starts it over
+	Name() string
+	Publish(ctx context.Context, events []models.Event) error
+}
+
+// Writer stores the events of a change in the same transaction as the change itself
+type Writer struct {
+	tx     repository.Transactor
+	events repository.OutboxRepository
+}
+
+// NewWriter creates a writer
+func NewWriter(tx repository.Transactor, events repository.OutboxRepository) *Writer {
+	return &Writer{tx: tx, events: events}
+}
+
+// Write runs fn in a transaction and appends the events it returns to the outbox. Nothing is
+// stored when fn fails; fn may run more than once when the transaction is retried.
+func (w *Writer) Write(ctx context.Context, fn func(ctx context.Context) ([]models.Event, error)) error {
+	return w.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		events, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		return w.events.Append(ctx, events...)
+	})
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// a gap in the sequence is waited for this long: without transactions a later event may be
+// stored before an earlier one. Older gaps are events that were never stored or have expired;
+// with transactions sequence numbers follow commit order and only expired events leave gaps.
+const gapTimeout = 10 * time.Second
+
+// Relay hands outbox events to sinks. Every sink keeps its own position, so a sink that is
+// down holds back only itself and continues where it stopped.
+type Relay struct {
+	events    repository.OutboxRepository
+	sinks     []Sink
+	interval  time.Duration
+	batchSize int
+}
+
+// NewRelay creates a relay polling the outbox on every interval
+func NewRelay(events repository.OutboxRepository, interval time.Duration, batchSize int, sinks ...Sink) *Relay {
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	return &Relay{events: events, sinks: sinks, interval: interval, batchSize: batchSize}
+}
+
+// Run delivers new events on every interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, sink := range r.sinks {
+			if _, err := r.Drain(ctx, sink); err != nil && ctx.Err() == nil {
+				log.Printf("WARN: outbox relay to %s failed, retrying: %v", sink.Name(), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes everything the sink has not seen yet, one batch at a time, and returns how
+// many events were delivered. The position moves only after the sink accepted a batch.
+func (r *Relay) Drain(ctx context.Context, sink Sink) (int, error) {
+	consumer := "relay:" + sink.Name()
+	position, err := r.events.Position(ctx, consumer)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for {
+		events, err := r.events.After(ctx, position, r.batchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		// stop in front of a recent gap, the missing event may still show up
+		ready := len(events)
+		next := position + 1
+		for i, e := range events {
+			if e.Seq != next && time.Since(e.At) < gapTimeout {
+				ready = i
+				break
+			}
+			if e.Seq != next {
+				// skipping is permanent, an event that is stored after all is never relayed to this sink
+				log.Printf("ERROR: outbox relay to %s skips events %d to %d, missing for over %s: they expired, failed to store or were stored late and are lost for this sink",
+					sink.Name(), next, e.Seq-1, gapTimeout)
+			}
+			next = e.Seq + 1
+		}
+		if ready == 0 {
+			return delivered, nil
+		}
+
+		if err := sink.Publish(ctx, events[:ready]); err != nil {
+			return delivered, err
+		}
+		position = events[ready-1].Seq
+		if err := r.events.SetPosition(ctx, consumer, position); err != nil {
+			return delivered, err
+		}
+		delivered += ready
+
+		if ready < r.batchSize {
+			return delivered, nil
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// memOutbox keeps events and consumer positions in memory; events are kept in seq order
+type memOutbox struct {
+	repository.OutboxRepository
+	events    []models.Event
+	positions map[string]int64
+}
+
+func newMemOutbox() *memOutbox {
+	return &memOutbox{positions: make(map[string]int64)}
+}
+
+// add stores events as a commit would, at is the time they were numbered
+func (r *memOutbox) add(at time.Time, seqs ...int64) {
+	for _, seq := range seqs {
+		r.events = append(r.events, models.Event{Seq: seq, Type: models.EventDriverUpdated, At: at})
+	}
+	slices.SortFunc(r.events, func(a, b models.Event) int { return int(a.Seq - b.Seq) })
+}
+
+func (r *memOutbox) After(ctx context.Context, seq int64, limit int) ([]models.Event, error) {
+	var after []models.Event
+	for _, e := range r.events {
+		if e.Seq > seq && len(after) < limit {
+			after = append(after, e)
+		}
+	}
+	return after, nil
+}
+
+func (r *memOutbox) Position(ctx context.Context, consumer string) (int64, error) {
+	return r.positions[consumer], nil
+}
+
+func (r *memOutbox) SetPosition(ctx context.Context, consumer string, seq int64) error {
+	r.positions[consumer] = seq
+	return nil
+}
+
+type recordingSink struct {
+	name string
+	down bool
+	seqs []int64
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Publish(ctx context.Context, events []models.Event) error {
+	if s.down {
+		return errors.New("sink down")
+	}
+	for _, e := range events {
+		s.seqs = append(s.seqs, e.Seq)
+	}
+	return nil
+}
+
+func drain(t *testing.T, r *Relay, sink Sink, want int) {
+	t.Helper()
+	n, err := r.Drain(context.Background(), sink)
+	if err != nil || n != want {
+		t.Fatalf("Drain to %s = %d, %v, want %d", sink.Name(), n, err, want)
+	}
+}
+
+func TestFailingSinkResumesFromItsOwnPosition(t *testing.T) {
+	events := newMemOutbox()
+	events.add(time.Now(), 1, 2, 3, 4, 5)
+	relay := NewRelay(events, time.Second, 2)
+	up, down := &recordingSink{name: "up"}, &recordingSink{name: "down", down: true}
+
+	drain(t, relay, up, 5)
+	if _, err := relay.Drain(context.Background(), down); err == nil {
+		t.Fatal("Drain to a failing sink reported no error")
+	}
+	if events.positions["relay:down"] != 0 {
+		t.Fatalf("failing sink moved to %d", events.positions["relay:down"])
+	}
+
+	down.down = false
+	events.add(time.Now(), 6)
+	drain(t, relay, down, 6)
+	drain(t, relay, up, 1)
+	if want := []int64{1, 2, 3, 4, 5, 6}; !slices.Equal(down.seqs, want) || !slices.Equal(up.seqs, want) {
+		t.Fatalf("delivered up %v, down %v, want %v to both", up.seqs, down.seqs, want)
+	}
+}
+
+func TestPositionWaitsAtRecentGap(t *testing.T) {
+	events := newMemOutbox()
+	events.add(time.Now(), 1, 2, 4, 5)
+	relay := NewRelay(events, time.Second, 10)
+	sink := &recordingSink{name: "s"}
+
+	drain(t, relay, sink, 2)
+	drain(t, relay, sink, 0)
+	if events.positions["relay:s"] != 2 {
+		t.Fatalf("position %d, want 2 in front of the gap", events.positions["relay:s"])
+	}
+
+	// the transaction holding 3 commits
+	events.add(time.Now(), 3)
+	drain(t, relay, sink, 3)
+	if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(sink.seqs, want) {
+		t.Fatalf("delivered %v, want %v", sink.seqs, want)
+	}
+}
+
+func TestOldGapIsSkipped(t *testing.T) {
+	events := newMemOutbox()
+	events.add(time.Now().Add(-time.Minute), 1, 3)
+	relay := NewRelay(events, time.Second, 10)
+	sink := &recordingSink{name: "s"}
+
+	drain(t, relay, sink, 2)
+	if !slices.Equal(sink.seqs, []int64{1, 3}) || events.positions["relay:s"] != 3 {
+		t.Fatalf("delivered %v up to %d, want 1 and 3 past the expired gap", sink.seqs, events.positions["relay:s"])
+	}
+}
+
+func TestRestartResumesFromStoredPosition(t *testing.T) {
+	events := newMemOutbox()
+	events.add(time.Now(), 1, 2, 3)
+	sink := &recordingSink{name: "s"}
+	drain(t, NewRelay(events, time.Second, 2), sink, 3)
+
+	// a new process with a fresh relay and sink, the outbox and positions are what survives
+	events.add(time.Now(), 4, 5)
+	restarted := &recordingSink{name: "s"}
+	drain(t, NewRelay(events, time.Second, 2), restarted, 2)
+	if !slices.Equal(restarted.seqs, []int64{4, 5}) {
+		t.Fatalf("after restart delivered %v, want only 4 and 5", restarted.seqs)
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAssignmentNotFound = errors.New("driver has no vehicle assigned")
+	// ErrDriverAssigned means the driver's active assignment changed concurrently
+	ErrDriverAssigned = errors.New("driver already has an active vehicle assignment")
+)
+
+// AssignmentRepository stores which driver worked with which vehicle and when
+type AssignmentRepository interface {
+	Create(ctx context.Context, assignment *models.VehicleAssignment) error
+	// End closes the driver's active assignment at the given time
+	End(ctx context.Context, driverID string, at time.Time) (*models.VehicleAssignment, error)
+	ActiveByDriver(ctx context.Context, driverID string) (*models.VehicleAssignment, error)
+	// ActiveByDrivers maps driver ids to their current vehicle id
+	ActiveByDrivers(ctx context.Context, driverIDs []string) (map[string]string, error)
+	CountActiveByVehicle(ctx context.Context, vehicleID string) (int64, error)
+	// ActiveByVehicle returns the open assignments of a vehicle, several drivers may share it
+	ActiveByVehicle(ctx context.Context, vehicleID string) ([]models.VehicleAssignment, error)
+	List(ctx context.Context, driverID, vehicleID string) ([]models.VehicleAssignment, error)
+}
+
+type assignmentRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewAssignmentRepository(db *mongo.Database) AssignmentRepository {
+	r := &assignmentRepositoryImpl{
+		collection: db.Collection("vehicle_assignments"),
+	}
+
+	// a driver drives one vehicle at a time; several drivers may share a vehicle
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}},
+			Options: options.Index().
+				SetName("one_active_vehicle_per_driver").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}),
+		},
+		{
+			Keys: bson.D{{Key: "vehicleId", Value: 1}, {Key: "from", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}, {Key: "from", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "from", Value: -1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create vehicle_assignments indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create opens an assignment owned by the driver's tenant
+func (r *assignmentRepositoryImpl) Create(ctx context.Context, assignment *models.VehicleAssignment) error {
+	stampTenant(ctx, &assignment.TenantID)
+	assignment.Active = assignment.To == nil
+	if _, err := r.collection.InsertOne(ctx, assignment); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDriverAssigned
+		}
+		return err
+	}
+	return nil
+}
+
+// End closes the driver's active assignment and returns it
+func (r *assignmentRepositoryImpl) End(ctx context.Context, driverID string, at time.Time) (*models.VehicleAssignment, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"active": false, "to": at}}
+
+	var assignment models.VehicleAssignment
+	err := r.collection.FindOneAndUpdate(ctx, scoped(ctx, bson.M{"driverId": driverID, "active": true}), update, opts).Decode(&assignment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAssignmentNotFound
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ActiveByDriver returns the driver's open assignment
+func (r *assignmentRepositoryImpl) ActiveByDriver(ctx context.Context, driverID string) (*models.VehicleAssignment, error) {
+	var assignment models.VehicleAssignment
+	if err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"driverId": driverID, "active": true})).Decode(&assignment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAssignmentNotFound
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ActiveByDrivers resolves the current vehicle of many drivers in one query
+func (r *assignmentRepositoryImpl) ActiveByDrivers(ctx context.Context, driverIDs []string) (map[string]string, error) {
+	vehicles := make(map[string]string, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return vehicles, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"driverId": bson.M{"$in": driverIDs}, "active": true}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var assignments []models.VehicleAssignment
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+	for _, a := range assignments {
+		vehicles[a.DriverID] = a.VehicleID
+	}
+	return vehicles, nil
+}
+
+// CountActiveByVehicle returns how many drivers are currently assigned to a vehicle
+func (r *assignmentRepositoryImpl) CountActiveByVehicle(ctx context.Context, vehicleID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, scoped(ctx, bson.M{"vehicleId": vehicleID, "active": true}))
+}
+
+// ActiveByVehicle logic
+func (r *assignmentRepositoryImpl) ActiveByVehicle(ctx context.Context, vehicleID string) ([]models.VehicleAssignment, error) {
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"vehicleId": vehicleID, "active": true}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var assignments []models.VehicleAssignment
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// List returns the assignment history of a driver or a vehicle, newest first
+func (r *assignmentRepositoryImpl) List(ctx context.Context, driverID, vehicleID string) ([]models.VehicleAssignment, error) {
+	filter := bson.M{}
+	if driverID != "" {
+		filter["driverId"] = driverID
+	}
+	if vehicleID != "" {
+		filter["vehicleId"] = vehicleID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "from", Value: -1}})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var assignments []models.VehicleAssignment
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
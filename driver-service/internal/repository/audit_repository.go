@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository is append-only: entries are written once and only read afterwards
+type AuditRepository interface {
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error)
+}
+
+type auditRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	r := &auditRepositoryImpl{
+		collection: db.Collection("audit_log"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "entity", Value: 1}, {Key: "entityId", Value: 1}, {Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "requestId", Value: 1}}},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create audit_log indexes: %v", err)
+	}
+
+	return r
+}
+
+// Append inserts an entry
+func (r *auditRepositoryImpl) Append(ctx context.Context, entry *models.AuditEntry) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// List returns matching entries of the caller's tenant, newest first
+func (r *auditRepositoryImpl) List(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error) {
+	skip := (q.Page - 1) * q.PageSize
+
+	filter := bson.M{}
+	if q.Entity != "" {
+		filter["entity"] = q.Entity
+	}
+	if q.EntityID != "" {
+		filter["entityId"] = q.EntityID
+	}
+	if q.Action != "" {
+		filter["action"] = q.Action
+	}
+	if q.Actor != "" {
+		filter["actor"] = q.Actor
+	}
+	if q.RequestID != "" {
+		filter["requestId"] = q.RequestID
+	}
+	at := bson.M{}
+	if !q.From.IsZero() {
+		at["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		at["$lt"] = q.To
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(q.PageSize)).
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CheckpointRepository stores the whole fleet as it was at regular instants, so snapshots
+// only replay the history since the last checkpoint
+type CheckpointRepository interface {
+	// Latest returns the time of the last complete checkpoint at or before notAfter, nil when there is none
+	Latest(ctx context.Context, notAfter time.Time) (*time.Time, error)
+	// Positions returns the caller's drivers as stored in the checkpoint taken at at
+	Positions(ctx context.Context, at time.Time) ([]models.FleetPosition, error)
+	// Save stores a checkpoint; saving the same instant again overwrites it
+	Save(ctx context.Context, at time.Time, positions []models.FleetPosition) error
+}
+
+type checkpointEntry struct {
+	At                   time.Time `bson:"at"`
+	models.FleetPosition `bson:",inline"`
+}
+
+type checkpointRepositoryImpl struct {
+	collection *mongo.Collection
+	runs       *mongo.Collection
+}
+
+func NewCheckpointRepository(db *mongo.Database) CheckpointRepository {
+	r := &checkpointRepositoryImpl{
+		collection: db.Collection("fleet_checkpoints"),
+		runs:       db.Collection("fleet_checkpoint_runs"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "at", Value: 1}, {Key: "driverId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "at", Value: 1}, {Key: "tenantId", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create fleet_checkpoints indexes: %v", err)
+	}
+
+	return r
+}
+
+// Latest logic; a checkpoint counts once its run is recorded, after all of its positions
+func (r *checkpointRepositoryImpl) Latest(ctx context.Context, notAfter time.Time) (*time.Time, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	var run struct {
+		At time.Time `bson:"_id"`
+	}
+	if err := r.runs.FindOne(ctx, bson.M{"_id": bson.M{"$lte": notAfter}}, opts).Decode(&run); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run.At, nil
+}
+
+// Positions logic
+func (r *checkpointRepositoryImpl) Positions(ctx context.Context, at time.Time) ([]models.FleetPosition, error) {
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"at": at}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []checkpointEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	positions := make([]models.FleetPosition, len(entries))
+	for i, e := range entries {
+		positions[i] = e.FleetPosition
+	}
+	return positions, nil
+}
+
+// Save upserts every position before recording the run, so a checkpoint interrupted halfway is
+// never read and instances racing on the same instant write the same documents
+func (r *checkpointRepositoryImpl) Save(ctx context.Context, at time.Time, positions []models.FleetPosition) error {
+	const batch = 1000
+	for start := 0; start < len(positions); start += batch {
+		end := min(start+batch, len(positions))
+		writes := make([]mongo.WriteModel, 0, end-start)
+		for _, p := range positions[start:end] {
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"at": at, "driverId": p.DriverID}).
+				SetReplacement(checkpointEntry{At: at, FleetPosition: p}).
+				SetUpsert(true))
+		}
+		if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.runs.UpdateOne(ctx, bson.M{"_id": at},
+		bson.M{"$set": bson.M{"drivers": len(positions), "savedAt": time.Now()}},
+		options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentRepository stores the current document of each type per driver
+type DocumentRepository interface {
+	// Upsert replaces the driver's document of the same type
+	Upsert(ctx context.Context, doc *models.DriverDocument) error
+	ListByDriver(ctx context.Context, driverID string) ([]models.DriverDocument, error)
+	// ListExpiring returns documents of the given types that expire before the given time
+	ListExpiring(ctx context.Context, types []string, before time.Time) ([]models.DriverDocument, error)
+}
+
+type documentRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewDocumentRepository(db *mongo.Database) DocumentRepository {
+	r := &documentRepositoryImpl{
+		collection: db.Collection("driver_documents"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "driverId", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// the expiry job scans by date
+			Keys: bson.D{{Key: "expiresAt", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "expiresAt", Value: 1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create driver_documents indexes: %v", err)
+	}
+
+	return r
+}
+
+// Upsert stores a document owned by the driver's tenant, replacing an older one of the same type
+func (r *documentRepositoryImpl) Upsert(ctx context.Context, doc *models.DriverDocument) error {
+	stampTenant(ctx, &doc.TenantID)
+	doc.UpdatedAt = time.Now()
+
+	filter := scoped(ctx, bson.M{"driverId": doc.DriverID, "type": doc.Type})
+	set := bson.M{
+		"number":    doc.Number,
+		"issuedAt":  doc.IssuedAt,
+		"expiresAt": doc.ExpiresAt,
+		"updatedAt": doc.UpdatedAt,
+	}
+	if doc.TenantID != "" {
+		set["tenantId"] = doc.TenantID
+	}
+	update := bson.M{"$set": set}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	return r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(doc)
+}
+
+// ListByDriver returns a driver's documents ordered by type
+func (r *documentRepositoryImpl) ListByDriver(ctx context.Context, driverID string) ([]models.DriverDocument, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "type", Value: 1}})
+	return r.find(ctx, bson.M{"driverId": driverID}, opts)
+}
+
+// ListExpiring returns documents expiring before the given time, soonest first
+func (r *documentRepositoryImpl) ListExpiring(ctx context.Context, types []string, before time.Time) ([]models.DriverDocument, error) {
+	filter := bson.M{"expiresAt": bson.M{"$lt": before}}
+	if len(types) > 0 {
+		filter["type"] = bson.M{"$in": types}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "expiresAt", Value: 1}})
+	return r.find(ctx, filter, opts)
+}
+
+func (r *documentRepositoryImpl) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.DriverDocument, error) {
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []models.DriverDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
@@ -12,13 +13,65 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrDriverNotFound is returned when no driver matches the given id
+	ErrDriverNotFound = errors.New("driver not found")
+	// ErrDriverStatusChanged means the driver left the expected onboarding state before the update landed
+	ErrDriverStatusChanged = errors.New("driver status changed concurrently")
+	// ErrVersionMismatch means the driver was written since the caller read the given version
+	ErrVersionMismatch = errors.New("driver was modified by someone else")
+)
+
+// AnyVersion skips the version check of an update
+const AnyVersion int64 = -1
+
+// statuses that keep a driver out of searches and dispatch
+var hiddenStatuses = bson.A{models.DriverPending, models.DriverSuspended, models.DriverRejected}
+
+// live leaves deleted drivers out; their tombstones only show in the changes feed and in past snapshots
+func live(filter bson.M) bson.M {
+	filter["deletedAt"] = nil
+	return filter
+}
+
 // DriverRepository defines database operations
 type DriverRepository interface {
 	Create(ctx context.Context, driver *models.Driver) (string, error)
-	Update(ctx context.Context, id string, driver *models.Driver) error
-	List(ctx context.Context, page, pageSize int) ([]models.Driver, error)
+	GetByID(ctx context.Context, id string) (*models.Driver, error)
+	Update(ctx context.Context, id string, driver *models.Driver, version int64) error
+	// Delete marks the driver deleted and returns the tombstone
+	Delete(ctx context.Context, id string) (*models.Driver, error)
+	// UpdateLocation moves a driver and returns the moved driver
+	UpdateLocation(ctx context.Context, id string, location models.Location) (*models.Driver, error)
+	UpdateLocations(ctx context.Context, locations map[string]models.Location) error
+	FindByIDs(ctx context.Context, ids []string) ([]models.Driver, error)
+	// SetVehicle copies the vehicle fields onto drivers; nil clears them
+	SetVehicle(ctx context.Context, driverIDs []string, vehicle *models.Vehicle) error
+	SetOnShift(ctx context.Context, id string, onShift bool) error
+	// SetStatus moves a driver between onboarding states only if it is still in the from state
+	SetStatus(ctx context.Context, id, from, to string) error
+	SetRating(ctx context.Context, id string, rating *models.DriverRating) error
+	// List returns a page of drivers, of one city when city is not empty
+	List(ctx context.Context, page, pageSize int, city string) ([]models.Driver, error)
 	// new method :
-	Search(ctx context.Context, taxiType string) ([]models.Driver, error)
+	// Search returns approved drivers matching the query
+	Search(ctx context.Context, q DriverQuery) ([]models.Driver, error)
+	// AssignCity sets the city of drivers that have none, returning how many changed
+	AssignCity(ctx context.Context, city string) (int64, error)
+	// ChangedSince returns up to limit drivers written after the (since, afterID) position and
+	// no later than until, ordered by updatedAt and id; deleted drivers come as tombstones
+	ChangedSince(ctx context.Context, since time.Time, afterID string, until time.Time, limit int) ([]models.Driver, error)
+	// ListCreatedBefore returns id, status, tenant and creation time of every driver created up to at
+	// and not deleted by then
+	ListCreatedBefore(ctx context.Context, at time.Time) ([]models.Driver, error)
+}
+
+// DriverQuery narrows a driver search; zero values do not filter
+type DriverQuery struct {
+	City      string
+	TaxiType  string
+	Requires  []models.Attribute // drivers must offer every attribute
+	SeenSince time.Time          // drivers must have reported a position since, the one they were created with counts
 }
 
 type driverRepositoryImpl struct {
@@ -26,16 +79,39 @@ type driverRepositoryImpl struct {
 }
 
 func NewDriverRepository(db *mongo.Database) DriverRepository {
-	return &driverRepositoryImpl{
+	r := &driverRepositoryImpl{
 		collection: db.Collection("drivers"),
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// searches are always scoped by city; the multikey indexes back the requires filter
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "taxiType", Value: 1}, {Key: "locationAt", Value: 1}}},
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "attributes", Value: 1}, {Key: "taxiType", Value: 1}}},
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "vehicleAttributes", Value: 1}, {Key: "taxiType", Value: 1}}},
+		// fleet operators only ever see their own drivers
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		// the changes feed walks drivers in write order
+		{Keys: bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create drivers indexes: %v", err)
+	}
+
+	return r
 }
 
-// Create inserts a new driver
+// Create inserts a new driver owned by the caller's tenant. The location it is created with is
+// its first report, the heartbeat runs from there.
 func (r *driverRepositoryImpl) Create(ctx context.Context, driver *models.Driver) (string, error) {
+	stampTenant(ctx, &driver.TenantID)
 	now := time.Now()
+	driver.Version = 1
 	driver.CreatedAt = now
 	driver.UpdatedAt = now
+	driver.LocationAt = &now
 
 	result, err := r.collection.InsertOne(ctx, driver)
 	if err != nil {
@@ -43,43 +119,349 @@ func (r *driverRepositoryImpl) Create(ctx context.Context, driver *models.Driver
 	}
 
 	oid, _ := result.InsertedID.(primitive.ObjectID)
+	driver.ID = oid
 	return oid.Hex(), nil
 }
 
-// Update modifies an existing driver
-func (r *driverRepositoryImpl) Update(ctx context.Context, id string, driver *models.Driver) error {
+// Update modifies an existing driver if it is still at the given version, see AnyVersion
+func (r *driverRepositoryImpl) Update(ctx context.Context, id string, driver *models.Driver, version int64) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid id format")
 	}
 
+	filter := live(bson.M{"_id": oid})
+	switch {
+	case version == 0:
+		// drivers written before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	case version > 0:
+		filter["version"] = version
+	}
+
 	driver.UpdatedAt = time.Now()
 
 	update := bson.M{
 		"$set": bson.M{
-			"firstName": driver.FirstName,
-			"lastName":  driver.LastName,
-			"plate":     driver.Plate,
-			"taxiType":  driver.TaxiType,
-			"location":  driver.Location,
-			"updatedAt": driver.UpdatedAt,
+			"firstName":  driver.FirstName,
+			"lastName":   driver.LastName,
+			"plate":      driver.Plate,
+			"taxiType":   driver.TaxiType,
+			"location":   driver.Location,
+			"city":       driver.City,
+			"attributes": driver.Attributes,
+			"updatedAt":  driver.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, filter), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		if version == AnyVersion {
+			return ErrDriverNotFound
+		}
+		// tell a stale version apart from a missing driver
+		count, err := r.collection.CountDocuments(ctx, scoped(ctx, live(bson.M{"_id": oid})))
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrDriverNotFound
+		}
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// Delete leaves a tombstone: the changes feed has to report the driver gone and snapshots of
+// earlier instants still include them. Shifts, reviews and the location history stay.
+func (r *driverRepositoryImpl) Delete(ctx context.Context, id string) (*models.Driver, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}, "$inc": bson.M{"version": 1}}
+
+	var driver models.Driver
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, scoped(ctx, live(bson.M{"_id": oid})), update, opts).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDriverNotFound
+		}
+		return nil, err
+	}
+
+	return &driver, nil
+}
+
+// GetByID returns a single driver
+func (r *driverRepositoryImpl) GetByID(ctx context.Context, id string) (*models.Driver, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	var driver models.Driver
+	if err := r.collection.FindOne(ctx, scoped(ctx, live(bson.M{"_id": oid}))).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDriverNotFound
+		}
+		return nil, err
+	}
+
+	return &driver, nil
+}
+
+// UpdateLocation moves a driver to a new current position. Positions change every few
+// seconds and do not bump the version, or no edit based on a read would ever succeed;
+// locationAt changes instead, which is part of the driver's ETag.
+func (r *driverRepositoryImpl) UpdateLocation(ctx context.Context, id string, location models.Location) (*models.Driver, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"location":   location,
+			"locationAt": now,
+			"updatedAt":  now,
+		},
+	}
+
+	var driver models.Driver
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, scoped(ctx, live(bson.M{"_id": oid})), update, opts).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDriverNotFound
+		}
+		return nil, err
+	}
+
+	return &driver, nil
+}
+
+// UpdateLocations moves many drivers in one unordered bulk write; unknown ids are ignored
+func (r *driverRepositoryImpl) UpdateLocations(ctx context.Context, locations map[string]models.Location) error {
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(locations))
+	for id, location := range locations {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(scoped(ctx, live(bson.M{"_id": oid}))).
+			SetUpdate(bson.M{"$set": bson.M{"location": location, "locationAt": now, "updatedAt": now}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// FindByIDs returns the drivers with the given ids; malformed and unknown ids are skipped
+func (r *driverRepositoryImpl) FindByIDs(ctx context.Context, ids []string) ([]models.Driver, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, live(bson.M{"_id": bson.M{"$in": oids}})))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []models.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, err
+	}
+
+	return drivers, nil
+}
+
+// SetVehicle mirrors the assigned vehicle on the driver documents
+func (r *driverRepositoryImpl) SetVehicle(ctx context.Context, driverIDs []string, vehicle *models.Vehicle) error {
+	oids := make([]primitive.ObjectID, 0, len(driverIDs))
+	for _, id := range driverIDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return errors.New("invalid id format")
+		}
+		oids = append(oids, oid)
+	}
+	if len(oids) == 0 {
+		return nil
+	}
+
+	if vehicle == nil {
+		vehicle = &models.Vehicle{}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"plate":             vehicle.Plate,
+			"taxiType":          vehicle.TaxiType,
+			"carBrand":          vehicle.CarBrand,
+			"carModel":          vehicle.CarModel,
+			"vehicleAttributes": vehicle.Attributes,
+			"updatedAt":         time.Now(),
 		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	_, err := r.collection.UpdateMany(ctx, scoped(ctx, live(bson.M{"_id": bson.M{"$in": oids}})), update)
+	return err
+}
+
+// SetOnShift records whether the driver is on duty
+func (r *driverRepositoryImpl) SetOnShift(ctx context.Context, id string, onShift bool) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	update := bson.M{"$set": bson.M{"onShift": onShift, "updatedAt": time.Now()}, "$inc": bson.M{"version": 1}}
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, live(bson.M{"_id": oid})), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDriverNotFound
+	}
+
+	return nil
+}
+
+// SetStatus changes the onboarding state; drivers without a status are treated as approved
+func (r *driverRepositoryImpl) SetStatus(ctx context.Context, id, from, to string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	filter := live(bson.M{"_id": oid, "status": from})
+	if from == models.DriverApproved {
+		filter["status"] = bson.M{"$in": bson.A{models.DriverApproved, "", nil}}
+	}
+	update := bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}, "$inc": bson.M{"version": 1}}
+
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, filter), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDriverStatusChanged
+	}
+
+	return nil
+}
+
+// AssignCity is the startup backfill for drivers created before cities existed
+func (r *driverRepositoryImpl) AssignCity(ctx context.Context, city string) (int64, error) {
+	filter := live(bson.M{"$or": bson.A{bson.M{"city": bson.M{"$exists": false}}, bson.M{"city": ""}}})
+	result, err := r.collection.UpdateMany(ctx, scoped(ctx, filter), bson.M{"$set": bson.M{"city": city, "updatedAt": time.Now()}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// SetRating stores the review aggregates on the driver
+func (r *driverRepositoryImpl) SetRating(ctx context.Context, id string, rating *models.DriverRating) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, live(bson.M{"_id": oid})), bson.M{"$set": bson.M{"rating": rating, "updatedAt": time.Now()}, "$inc": bson.M{"version": 1}})
 	if err != nil {
 		return err
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("driver not found")
+		return ErrDriverNotFound
 	}
 
 	return nil
 }
 
+// ChangedSince pages through drivers by last write; ties on updatedAt are broken by id
+func (r *driverRepositoryImpl) ChangedSince(ctx context.Context, since time.Time, afterID string, until time.Time, limit int) ([]models.Driver, error) {
+	after := bson.M{"updatedAt": bson.M{"$gt": since}}
+	if afterID != "" {
+		oid, err := primitive.ObjectIDFromHex(afterID)
+		if err != nil {
+			return nil, errors.New("invalid id format")
+		}
+		after = bson.M{"$or": bson.A{
+			bson.M{"updatedAt": bson.M{"$gt": since}},
+			bson.M{"updatedAt": since, "_id": bson.M{"$gt": oid}},
+		}}
+	}
+	filter := bson.M{"$and": bson.A{after, bson.M{"updatedAt": bson.M{"$lte": until}}}}
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []models.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, err
+	}
+
+	return drivers, nil
+}
+
+// ListCreatedBefore logic
+func (r *driverRepositoryImpl) ListCreatedBefore(ctx context.Context, at time.Time) ([]models.Driver, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"status": 1, "tenantId": 1, "createdAt": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	filter := bson.M{
+		"createdAt": bson.M{"$lte": at},
+		"$or":       bson.A{bson.M{"deletedAt": nil}, bson.M{"deletedAt": bson.M{"$gt": at}}},
+	}
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []models.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, err
+	}
+
+	return drivers, nil
+}
+
 // List returns a paginated list of drivers
-func (r *driverRepositoryImpl) List(ctx context.Context, page, pageSize int) ([]models.Driver, error) {
+func (r *driverRepositoryImpl) List(ctx context.Context, page, pageSize int, city string) ([]models.Driver, error) {
 	// calculate skip count (e.g. page 1 -> skip 0, page 2 -> skip 20)
 	skip := (page - 1) * pageSize
 
@@ -88,7 +470,12 @@ func (r *driverRepositoryImpl) List(ctx context.Context, page, pageSize int) ([]
 		SetLimit(int64(pageSize)).
 		SetSort(bson.D{{Key: "createdAt", Value: -1}}) // newest first
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	filter := live(bson.M{})
+	if city != "" {
+		filter["city"] = city
+	}
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -102,16 +489,42 @@ func (r *driverRepositoryImpl) List(ctx context.Context, page, pageSize int) ([]
 	return drivers, nil
 }
 
-// Search returns drivers matching a criteria (e.g. taxi type)
-func (r *driverRepositoryImpl) Search(ctx context.Context, taxiType string) ([]models.Driver, error) {
-	filter := bson.M{}
+// Search returns drivers matching a criteria (e.g. taxi type, attributes).
+// Drivers that are not approved never show up.
+func (r *driverRepositoryImpl) Search(ctx context.Context, q DriverQuery) ([]models.Driver, error) {
+	filter := live(bson.M{"status": bson.M{"$nin": hiddenStatuses}})
+
+	if q.City != "" {
+		filter["city"] = q.City
+	}
 
 	// if taxiType is provided, filter by it
-	if taxiType != "" {
-		filter["taxiType"] = taxiType
+	if q.TaxiType != "" {
+		filter["taxiType"] = q.TaxiType
+	}
+
+	// drivers that went quiet are probably offline, dispatch skips them by the same rule
+	if !q.SeenSince.IsZero() {
+		filter["locationAt"] = bson.M{"$gte": q.SeenSince}
+	}
+
+	// driver attributes live on the driver, vehicle ones on the mirrored copy
+	var own, vehicle []models.Attribute
+	for _, a := range q.Requires {
+		if models.AttributeScopes[a] == models.AttributeScopeVehicle {
+			vehicle = append(vehicle, a)
+		} else {
+			own = append(own, a)
+		}
+	}
+	if len(own) > 0 {
+		filter["attributes"] = bson.M{"$all": own}
+	}
+	if len(vehicle) > 0 {
+		filter["vehicleAttributes"] = bson.M{"$all": vehicle}
 	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrIdempotencyRecordNotFound = errors.New("idempotency record not found")
+	// ErrIdempotencyKeyInUse means an unexpired record exists for the key
+	ErrIdempotencyKeyInUse = errors.New("idempotency key already used")
+)
+
+// IdempotencyRepository stores responses to requests sent with an Idempotency-Key
+type IdempotencyRepository interface {
+	// Reserve claims a key with a pending record, taking over expired ones
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) error
+	Get(ctx context.Context, id string) (*models.IdempotencyRecord, error)
+	// Complete and Release only apply while owner still holds the key; after the lock timed out
+	// and another request took it over they return ErrIdempotencyRecordNotFound
+	Complete(ctx context.Context, id, owner string, status int, header map[string][]string, body []byte, expiresAt time.Time) error
+	Release(ctx context.Context, id, owner string) error
+}
+
+type idempotencyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database) IdempotencyRepository {
+	r := &idempotencyRepositoryImpl{
+		collection: db.Collection("idempotency_keys"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// mongo drops records once expiresAt has passed; the check runs about once a minute
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Printf("WARN: could not create idempotency_keys index: %v", err)
+	}
+
+	return r
+}
+
+// Reserve inserts a pending record for a new key
+func (r *idempotencyRepositoryImpl) Reserve(ctx context.Context, record *models.IdempotencyRecord) error {
+	_, err := r.collection.InsertOne(ctx, record)
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	// the TTL monitor may not have removed an expired record yet
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": record.ID, "expiresAt": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrIdempotencyKeyInUse
+	}
+	if _, err := r.collection.InsertOne(ctx, record); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrIdempotencyKeyInUse
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns the record of a key
+func (r *idempotencyRepositoryImpl) Get(ctx context.Context, id string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrIdempotencyRecordNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request that reserved the key
+func (r *idempotencyRepositoryImpl) Complete(ctx context.Context, id, owner string, status int, header map[string][]string, body []byte, expiresAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"done":      true,
+			"status":    status,
+			"header":    header,
+			"body":      body,
+			"expiresAt": expiresAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "owner": owner, "done": false}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrIdempotencyRecordNotFound
+	}
+
+	return nil
+}
+
+// Release drops a key so the request can be tried again
+func (r *idempotencyRepositoryImpl) Release(ctx context.Context, id, owner string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "owner": owner, "done": false})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrIdempotencyRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LocationRepository stores the history of driver location pings
+type LocationRepository interface {
+	Add(ctx context.Context, ping *models.LocationPing) error
+	AddMany(ctx context.Context, pings []*models.LocationPing) (failed map[int]error, err error)
+	ListByDriver(ctx context.Context, driverID string, from, to time.Time) ([]models.LocationPing, error)
+	// LatestBetween returns the last accepted ping of every driver that reported after from and up to to;
+	// driverIDs narrows it to those drivers, nil reads every driver
+	LatestBetween(ctx context.Context, from, to time.Time, driverIDs []string) ([]models.LocationPing, error)
+}
+
+type locationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewLocationRepository(db *mongo.Database) LocationRepository {
+	r := &locationRepositoryImpl{
+		collection: db.Collection("driver_locations"),
+	}
+
+	// pings are read per driver in time order, and across drivers for fleet snapshots
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "driverId", Value: 1}, {Key: "ts", Value: 1}}},
+		{Keys: bson.D{{Key: "ts", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create driver_locations indexes: %v", err)
+	}
+
+	return r
+}
+
+// Add appends a ping to the history
+func (r *locationRepositoryImpl) Add(ctx context.Context, ping *models.LocationPing) error {
+	if ping.Timestamp.IsZero() {
+		ping.Timestamp = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, ping)
+	return err
+}
+
+// AddMany appends pings with one unordered bulk write.
+// failed holds the per ping errors by slice index; err is set when the write as a whole failed.
+func (r *locationRepositoryImpl) AddMany(ctx context.Context, pings []*models.LocationPing) (map[int]error, error) {
+	if len(pings) == 0 {
+		return nil, nil
+	}
+
+	writes := make([]mongo.WriteModel, len(pings))
+	for i, ping := range pings {
+		if ping.Timestamp.IsZero() {
+			ping.Timestamp = time.Now()
+		}
+		writes[i] = mongo.NewInsertOneModel().SetDocument(ping)
+	}
+
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		failed := make(map[int]error, len(bulkErr.WriteErrors))
+		for _, we := range bulkErr.WriteErrors {
+			failed[we.Index] = errors.New(we.Message)
+		}
+		return failed, nil
+	}
+	return nil, err
+}
+
+// ListByDriver returns a driver's accepted pings between from and to, oldest first
+func (r *locationRepositoryImpl) ListByDriver(ctx context.Context, driverID string, from, to time.Time) ([]models.LocationPing, error) {
+	filter := bson.M{
+		"driverId": driverID,
+		"ts":       bson.M{"$gte": from, "$lte": to},
+		"rejected": bson.M{"$ne": true},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "ts", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pings []models.LocationPing
+	if err := cursor.All(ctx, &pings); err != nil {
+		return nil, err
+	}
+
+	return pings, nil
+}
+
+// LatestBetween logic
+func (r *locationRepositoryImpl) LatestBetween(ctx context.Context, from, to time.Time, driverIDs []string) ([]models.LocationPing, error) {
+	match := bson.M{
+		"ts":       bson.M{"$gt": from, "$lte": to},
+		"rejected": bson.M{"$ne": true},
+	}
+	if driverIDs != nil {
+		match["driverId"] = bson.M{"$in": driverIDs}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "ts", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$driverId", "ping": bson.M{"$last": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$ping"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pings []models.LocationPing
+	if err := cursor.All(ctx, &pings); err != nil {
+		return nil, err
+	}
+
+	return pings, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+// LocationWriter buffers current position updates and writes them with one bulk write per interval.
+// Updates for the same driver within an interval are coalesced, only the newest one is written
+// together with one location_moved event per driver.
+type LocationWriter struct {
+	drivers  DriverRepository
+	outbox   OutboxRepository
+	tx       Transactor
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]pendingLocation
+}
+
+type pendingLocation struct {
+	location models.Location
+	ts       time.Time
+}
+
+// NewLocationWriter creates a writer; call Run to start flushing
+func NewLocationWriter(drivers DriverRepository, outbox OutboxRepository, tx Transactor, interval time.Duration) *LocationWriter {
+	return &LocationWriter{
+		drivers:  drivers,
+		outbox:   outbox,
+		tx:       tx,
+		interval: interval,
+		pending:  make(map[string]pendingLocation),
+	}
+}
+
+// Put queues a position, replacing an older queued one of the same driver
+func (w *LocationWriter) Put(driverID string, location models.Location, ts time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if prev, ok := w.pending[driverID]; ok && prev.ts.After(ts) {
+		return
+	}
+	w.pending[driverID] = pendingLocation{location: location, ts: ts}
+}
+
+// Drop discards a queued position, used when the driver was moved directly
+func (w *LocationWriter) Drop(driverID string) {
+	w.mu.Lock()
+	delete(w.pending, driverID)
+	w.mu.Unlock()
+}
+
+// Flush writes everything queued so far. On failure the positions are queued
+// again unless a newer one arrived in the meantime.
+func (w *LocationWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = make(map[string]pendingLocation)
+	w.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	locations := make(map[string]models.Location, len(batch))
+	for id, p := range batch {
+		locations[id] = p.location
+	}
+	err := w.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := w.drivers.UpdateLocations(ctx, locations); err != nil {
+			return err
+		}
+		return w.appendMoved(ctx, batch)
+	})
+	if err != nil {
+		w.mu.Lock()
+		for id, p := range batch {
+			if _, ok := w.pending[id]; !ok {
+				w.pending[id] = p
+			}
+		}
+		w.mu.Unlock()
+	}
+	return err
+}
+
+// appendMoved records an event for every driver of the batch that exists
+func (w *LocationWriter) appendMoved(ctx context.Context, batch map[string]pendingLocation) error {
+	ids := make([]string, 0, len(batch))
+	for id := range batch {
+		ids = append(ids, id)
+	}
+	drivers, err := w.drivers.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	events := make([]models.Event, 0, len(drivers))
+	for _, d := range drivers {
+		p := batch[d.ID.Hex()]
+		event, err := models.NewEvent(models.EventDriverLocationMoved, d.ID.Hex(), d.TenantID, models.DriverMoved{Location: p.location, At: p.ts})
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	return w.outbox.Append(ctx, events...)
+}
+
+// Run flushes on every interval until ctx is done, then flushes once more
+func (w *LocationWriter) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := w.Flush(flushCtx); err != nil {
+				log.Printf("WARN: final location flush failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := w.Flush(ctx); err != nil {
+				log.Printf("WARN: location flush failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MarkerRepository keeps how far background jobs have got, so a restart continues where the
+// last pass stopped instead of from the time of the boot
+type MarkerRepository interface {
+	// Get returns the time stored under the name, zero when the job never ran
+	Get(ctx context.Context, name string) (time.Time, error)
+	Set(ctx context.Context, name string, at time.Time) error
+}
+
+type markerRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewMarkerRepository needs no extra index, markers are keyed by name
+func NewMarkerRepository(db *mongo.Database) MarkerRepository {
+	return &markerRepositoryImpl{
+		collection: db.Collection("markers"),
+	}
+}
+
+// Get logic
+func (r *markerRepositoryImpl) Get(ctx context.Context, name string) (time.Time, error) {
+	var marker struct {
+		At time.Time `bson:"at"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&marker)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return marker.At, err
+}
+
+// Set stores the time under the name, replacing the previous one
+func (r *markerRepositoryImpl) Set(ctx context.Context, name string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": name},
+		bson.M{"$set": bson.M{"at": at}}, options.Update().SetUpsert(true))
+	return err
+}
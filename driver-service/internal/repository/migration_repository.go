@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrationRepository remembers which one-off data migrations have completed, so they do
+// not run again on every boot
+type MigrationRepository interface {
+	Done(ctx context.Context, name string) (bool, error)
+	MarkDone(ctx context.Context, name string) error
+}
+
+type migrationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewMigrationRepository needs no extra index, migrations are keyed by name
+func NewMigrationRepository(db *mongo.Database) MigrationRepository {
+	return &migrationRepositoryImpl{
+		collection: db.Collection("migrations"),
+	}
+}
+
+// Done reports whether the migration has completed
+func (r *migrationRepositoryImpl) Done(ctx context.Context, name string) (bool, error) {
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkDone records the migration as completed; marking it again keeps the first completion time
+func (r *migrationRepositoryImpl) MarkDone(ctx context.Context, name string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": name},
+		bson.M{"$setOnInsert": bson.M{"doneAt": time.Now()}}, options.Update().SetUpsert(true))
+	return err
+}
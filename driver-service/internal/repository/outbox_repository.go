@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const outboxCounter = "outbox"
+
+// OutboxRepository stores domain events until the relay has handed them to every sink
+type OutboxRepository interface {
+	// Append numbers the events and stores them; call it inside the transaction of the change
+	Append(ctx context.Context, events ...models.Event) error
+	// After returns up to limit events with a sequence number above seq, in order
+	After(ctx context.Context, seq int64, limit int) ([]models.Event, error)
+	// Position returns the last sequence number a consumer handled, 0 for a new consumer
+	Position(ctx context.Context, consumer string) (int64, error)
+	SetPosition(ctx context.Context, consumer string, seq int64) error
+}
+
+type outboxRepositoryImpl struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+	positions  *mongo.Collection
+}
+
+// NewOutboxRepository keeps events for the retention period, long enough for a stopped relay to catch up
+func NewOutboxRepository(db *mongo.Database, retention time.Duration) OutboxRepository {
+	r := &outboxRepositoryImpl{
+		collection: db.Collection("outbox"),
+		counters:   db.Collection("counters"),
+		positions:  db.Collection("outbox_positions"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds()))},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create outbox indexes: %v", err)
+	}
+	// collections cannot always be created inside a transaction, make sure the counter exists
+	_, err = r.counters.UpdateOne(ctx, bson.M{"_id": outboxCounter}, bson.M{"$setOnInsert": bson.M{"seq": int64(0)}}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Printf("WARN: could not create outbox counter: %v", err)
+	}
+
+	return r
+}
+
+// Append takes a block of sequence numbers from the counter. Inside a transaction concurrent
+// writers conflict on the counter, so sequence numbers follow commit order without gaps.
+func (r *outboxRepositoryImpl) Append(ctx context.Context, events ...models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.counters.FindOneAndUpdate(ctx, bson.M{"_id": outboxCounter}, bson.M{"$inc": bson.M{"seq": int64(len(events))}}, opts).Decode(&counter)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	requestID := tenant.RequestID(ctx)
+	docs := make([]interface{}, len(events))
+	first := counter.Seq - int64(len(events)) + 1
+	for i := range events {
+		events[i].Seq = first + int64(i)
+		if events[i].At.IsZero() {
+			events[i].At = now
+		}
+		if events[i].RequestID == "" {
+			events[i].RequestID = requestID
+		}
+		docs[i] = events[i]
+	}
+
+	_, err = r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+// After logic
+func (r *outboxRepositoryImpl) After(ctx context.Context, seq int64, limit int) ([]models.Event, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"seq": bson.M{"$gt": seq}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Position logic
+func (r *outboxRepositoryImpl) Position(ctx context.Context, consumer string) (int64, error) {
+	var position struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.positions.FindOne(ctx, bson.M{"_id": consumer}).Decode(&position)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return position.Seq, err
+}
+
+// SetPosition never moves a consumer backwards
+func (r *outboxRepositoryImpl) SetPosition(ctx context.Context, consumer string, seq int64) error {
+	update := bson.M{
+		"$max": bson.M{"seq": seq},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	_, err := r.positions.UpdateOne(ctx, bson.M{"_id": consumer}, update, options.Update().SetUpsert(true))
+	return err
+}
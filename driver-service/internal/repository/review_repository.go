@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrAlreadyReviewed means the trip already has a review
+	ErrAlreadyReviewed = errors.New("trip already reviewed")
+)
+
+// ReviewRepository defines database operations for reviews
+type ReviewRepository interface {
+	Create(ctx context.Context, review *models.Review) error
+	GetByID(ctx context.Context, id string) (*models.Review, error)
+	SetStatus(ctx context.Context, id, status, reason string) (*models.Review, error)
+	List(ctx context.Context, page, pageSize int, driverID, status string) ([]models.Review, error)
+	// Rating aggregates a driver's visible reviews, the windowed values count reviews from since
+	Rating(ctx context.Context, driverID string, since time.Time) (*models.DriverRating, error)
+	// DriverIDsReviewedBetween returns drivers with visible reviews created in [from, to)
+	DriverIDsReviewedBetween(ctx context.Context, from, to time.Time) ([]string, error)
+}
+
+type reviewRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewReviewRepository(db *mongo.Database) ReviewRepository {
+	r := &reviewRepositoryImpl{
+		collection: db.Collection("reviews"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// one review per trip
+			Keys:    bson.D{{Key: "tripId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "createdAt", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create reviews indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create inserts a review owned by the driver's tenant
+func (r *reviewRepositoryImpl) Create(ctx context.Context, review *models.Review) error {
+	stampTenant(ctx, &review.TenantID)
+	result, err := r.collection.InsertOne(ctx, review)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyReviewed
+		}
+		return err
+	}
+
+	review.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID returns a single review
+func (r *reviewRepositoryImpl) GetByID(ctx context.Context, id string) (*models.Review, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	var review models.Review
+	if err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": oid})).Decode(&review); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// SetStatus records a moderation decision and returns the updated review
+func (r *reviewRepositoryImpl) SetStatus(ctx context.Context, id, status, reason string) (*models.Review, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	update := bson.M{"$set": bson.M{"status": status, "moderationReason": reason, "moderatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	if err := r.collection.FindOneAndUpdate(ctx, scoped(ctx, bson.M{"_id": oid}), update, opts).Decode(&review); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// List returns a paginated list of reviews, newest first
+func (r *reviewRepositoryImpl) List(ctx context.Context, page, pageSize int, driverID, status string) ([]models.Review, error) {
+	skip := (page - 1) * pageSize
+
+	filter := bson.M{}
+	if driverID != "" {
+		filter["driverId"] = driverID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []models.Review
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// Rating computes the all-time and windowed averages in one aggregation
+func (r *reviewRepositoryImpl) Rating(ctx context.Context, driverID string, since time.Time) (*models.DriverRating, error) {
+	recent := bson.M{"$gte": bson.A{"$createdAt", since}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scoped(ctx, bson.M{"driverId": driverID, "status": models.ReviewVisible})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"sum":     bson.M{"$sum": "$stars"},
+			"count":   bson.M{"$sum": 1},
+			"sum30":   bson.M{"$sum": bson.M{"$cond": bson.A{recent, "$stars", 0}}},
+			"count30": bson.M{"$sum": bson.M{"$cond": bson.A{recent, 1, 0}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Sum     float64 `bson:"sum"`
+		Count   int     `bson:"count"`
+		Sum30   float64 `bson:"sum30"`
+		Count30 int     `bson:"count30"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	rating := &models.DriverRating{UpdatedAt: time.Now()}
+	if len(rows) == 1 {
+		row := rows[0]
+		rating.Count, rating.Count30 = row.Count, row.Count30
+		if row.Count > 0 {
+			rating.Average = math.Round(row.Sum/float64(row.Count)*100) / 100
+		}
+		if row.Count30 > 0 {
+			rating.Average30 = math.Round(row.Sum30/float64(row.Count30)*100) / 100
+		}
+	}
+	return rating, nil
+}
+
+// DriverIDsReviewedBetween finds drivers whose rolling window changes as reviews age out
+func (r *reviewRepositoryImpl) DriverIDsReviewedBetween(ctx context.Context, from, to time.Time) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "driverId", scoped(ctx, bson.M{
+		"status":    models.ReviewVisible,
+		"createdAt": bson.M{"$gte": from, "$lt": to},
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
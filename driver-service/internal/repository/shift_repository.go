@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrShiftNotFound = errors.New("no active shift")
+	// ErrVehicleOnShift means another driver is already on shift in the vehicle
+	ErrVehicleOnShift = errors.New("vehicle already has a driver on shift")
+	// ErrDriverOnShift means the driver is already on shift
+	ErrDriverOnShift = errors.New("driver is already on shift")
+)
+
+// index names, used to tell which constraint a duplicate key error hit
+const (
+	shiftVehicleIndex = "one_active_shift_per_vehicle"
+	shiftDriverIndex  = "one_active_shift_per_driver"
+)
+
+// ShiftRepository defines database operations for shifts
+type ShiftRepository interface {
+	Create(ctx context.Context, shift *models.Shift) error
+	// End closes the driver's active shift
+	End(ctx context.Context, driverID string, at time.Time, reason string) (*models.Shift, error)
+	ActiveByDriver(ctx context.Context, driverID string) (*models.Shift, error)
+	ActiveByVehicle(ctx context.Context, vehicleID string) (*models.Shift, error)
+	List(ctx context.Context, page, pageSize int, driverID, vehicleID string, activeOnly bool) ([]models.Shift, error)
+	// ListOverlapping returns shifts that were running at some point between from and to
+	ListOverlapping(ctx context.Context, driverID string, from, to time.Time) ([]models.Shift, error)
+}
+
+type shiftRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewShiftRepository(db *mongo.Database) ShiftRepository {
+	r := &shiftRepositoryImpl{
+		collection: db.Collection("shifts"),
+	}
+
+	// the service checks first, the unique partial indexes are the backstop
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "vehicleId", Value: 1}},
+			Options: options.Index().
+				SetName(shiftVehicleIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}),
+		},
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}},
+			Options: options.Index().
+				SetName(shiftDriverIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}),
+		},
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}, {Key: "startedAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "startedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create shifts indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create opens a shift owned by the driver's tenant
+func (r *shiftRepositoryImpl) Create(ctx context.Context, shift *models.Shift) error {
+	stampTenant(ctx, &shift.TenantID)
+	shift.Active = shift.EndedAt == nil
+
+	result, err := r.collection.InsertOne(ctx, shift)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			if strings.Contains(err.Error(), shiftVehicleIndex) {
+				return ErrVehicleOnShift
+			}
+			return ErrDriverOnShift
+		}
+		return err
+	}
+
+	shift.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// End closes the driver's active shift and returns it
+func (r *shiftRepositoryImpl) End(ctx context.Context, driverID string, at time.Time, reason string) (*models.Shift, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"active": false, "endedAt": at, "endReason": reason}}
+
+	var shift models.Shift
+	err := r.collection.FindOneAndUpdate(ctx, scoped(ctx, bson.M{"driverId": driverID, "active": true}), update, opts).Decode(&shift)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// ActiveByDriver returns the driver's running shift
+func (r *shiftRepositoryImpl) ActiveByDriver(ctx context.Context, driverID string) (*models.Shift, error) {
+	return r.findActive(ctx, bson.M{"driverId": driverID, "active": true})
+}
+
+// ActiveByVehicle returns the running shift of a vehicle
+func (r *shiftRepositoryImpl) ActiveByVehicle(ctx context.Context, vehicleID string) (*models.Shift, error) {
+	return r.findActive(ctx, bson.M{"vehicleId": vehicleID, "active": true})
+}
+
+func (r *shiftRepositoryImpl) findActive(ctx context.Context, filter bson.M) (*models.Shift, error) {
+	var shift models.Shift
+	if err := r.collection.FindOne(ctx, scoped(ctx, filter)).Decode(&shift); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// List returns a paginated list of shifts, newest first
+func (r *shiftRepositoryImpl) List(ctx context.Context, page, pageSize int, driverID, vehicleID string, activeOnly bool) ([]models.Shift, error) {
+	skip := (page - 1) * pageSize
+
+	filter := bson.M{}
+	if driverID != "" {
+		filter["driverId"] = driverID
+	}
+	if vehicleID != "" {
+		filter["vehicleId"] = vehicleID
+	}
+	if activeOnly {
+		filter["active"] = true
+	}
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "startedAt", Value: -1}})
+
+	return r.find(ctx, filter, opts)
+}
+
+// ListOverlapping returns shifts started before to that were still running at from
+func (r *shiftRepositoryImpl) ListOverlapping(ctx context.Context, driverID string, from, to time.Time) ([]models.Shift, error) {
+	filter := bson.M{
+		"startedAt": bson.M{"$lt": to},
+		"$or": bson.A{
+			bson.M{"active": true},
+			bson.M{"endedAt": bson.M{"$gt": from}},
+		},
+	}
+	if driverID != "" {
+		filter["driverId"] = driverID
+	}
+
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "startedAt", Value: 1}}))
+}
+
+func (r *shiftRepositoryImpl) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Shift, error) {
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shifts []models.Shift
+	if err := cursor.All(ctx, &shifts); err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
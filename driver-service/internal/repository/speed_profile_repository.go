@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SpeedProfileRepository stores the ETA speed table
+type SpeedProfileRepository interface {
+	List(ctx context.Context) ([]models.SpeedProfile, error)
+	ReplaceAll(ctx context.Context, profiles []models.SpeedProfile) error
+	// ReplaceLearned swaps the learned profiles and keeps the ones set by hand
+	ReplaceLearned(ctx context.Context, profiles []models.SpeedProfile) error
+}
+
+type speedProfileRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewSpeedProfileRepository(db *mongo.Database) SpeedProfileRepository {
+	return &speedProfileRepositoryImpl{
+		collection: db.Collection("speed_profiles"),
+	}
+}
+
+// List returns every stored profile
+func (r *speedProfileRepositoryImpl) List(ctx context.Context) ([]models.SpeedProfile, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var profiles []models.SpeedProfile
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// ReplaceAll swaps the whole table; the table is small so delete + insert is fine
+func (r *speedProfileRepositoryImpl) ReplaceAll(ctx context.Context, profiles []models.SpeedProfile) error {
+	return r.replace(ctx, bson.M{}, profiles)
+}
+
+// ReplaceLearned logic; profiles set by hand have no samples
+func (r *speedProfileRepositoryImpl) ReplaceLearned(ctx context.Context, profiles []models.SpeedProfile) error {
+	return r.replace(ctx, bson.M{"samples": bson.M{"$gt": 0}}, profiles)
+}
+
+func (r *speedProfileRepositoryImpl) replace(ctx context.Context, filter bson.M, profiles []models.SpeedProfile) error {
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(profiles))
+	for i := range profiles {
+		docs[i] = profiles[i]
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StatusLogRepository keeps every onboarding transition of drivers
+type StatusLogRepository interface {
+	Add(ctx context.Context, change *models.DriverStatusChange) error
+	ListByDriver(ctx context.Context, driverID string) ([]models.DriverStatusChange, error)
+	// LatestBetween returns the last transition of every driver that changed after from and up to to
+	LatestBetween(ctx context.Context, from, to time.Time) ([]models.DriverStatusChange, error)
+	// FirstAfter returns the first transition after at of each of the given drivers that has one
+	FirstAfter(ctx context.Context, at time.Time, driverIDs []string) ([]models.DriverStatusChange, error)
+}
+
+type statusLogRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewStatusLogRepository(db *mongo.Database) StatusLogRepository {
+	r := &statusLogRepositoryImpl{
+		collection: db.Collection("driver_status_log"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "driverId", Value: 1}, {Key: "at", Value: 1}}},
+		{Keys: bson.D{{Key: "at", Value: 1}}},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "at", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create driver_status_log indexes: %v", err)
+	}
+
+	return r
+}
+
+// Add appends a transition owned by the driver's tenant
+func (r *statusLogRepositoryImpl) Add(ctx context.Context, change *models.DriverStatusChange) error {
+	stampTenant(ctx, &change.TenantID)
+	if change.At.IsZero() {
+		change.At = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, change)
+	return err
+}
+
+// ListByDriver returns a driver's transitions, oldest first
+func (r *statusLogRepositoryImpl) ListByDriver(ctx context.Context, driverID string) ([]models.DriverStatusChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"driverId": driverID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []models.DriverStatusChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// LatestBetween logic
+func (r *statusLogRepositoryImpl) LatestBetween(ctx context.Context, from, to time.Time) ([]models.DriverStatusChange, error) {
+	return r.onePerDriver(ctx, bson.M{"at": bson.M{"$gt": from, "$lte": to}}, "$last")
+}
+
+// FirstAfter logic
+func (r *statusLogRepositoryImpl) FirstAfter(ctx context.Context, at time.Time, driverIDs []string) ([]models.DriverStatusChange, error) {
+	if len(driverIDs) == 0 {
+		return nil, nil
+	}
+	return r.onePerDriver(ctx, bson.M{"driverId": bson.M{"$in": driverIDs}, "at": bson.M{"$gt": at}}, "$first")
+}
+
+// onePerDriver keeps the first or last matching transition of each driver in time order
+func (r *statusLogRepositoryImpl) onePerDriver(ctx context.Context, match bson.M, pick string) ([]models.DriverStatusChange, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scoped(ctx, match)}},
+		{{Key: "$sort", Value: bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$driverId", "change": bson.M{pick: "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$change"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []models.DriverStatusChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
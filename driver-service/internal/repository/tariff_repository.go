@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrTariffNotFound is returned when no tariff was in force for a taxi type at a given time
+var ErrTariffNotFound = errors.New("no tariff in force")
+
+// TariffRepository stores versioned tariff tables
+type TariffRepository interface {
+	Create(ctx context.Context, tariff *models.Tariff) error
+	List(ctx context.Context, city, taxiType string) ([]models.Tariff, error)
+	// FindEffective returns the newest version of the city's taxi type whose effective date is not after at
+	FindEffective(ctx context.Context, city, taxiType string, at time.Time) (*models.Tariff, error)
+	Count(ctx context.Context) (int64, error)
+	// AssignCity sets the city of tariffs that have none, returning how many changed
+	AssignCity(ctx context.Context, city string) (int64, error)
+}
+
+type tariffRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewTariffRepository(db *mongo.Database) TariffRepository {
+	r := &tariffRepositoryImpl{
+		collection: db.Collection("tariffs"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// versions used to be numbered per taxi type only; the same type now has versions in every city
+	_, _ = r.collection.Indexes().DropOne(ctx, "taxiType_1_version_1")
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "city", Value: 1}, {Key: "taxiType", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "city", Value: 1}, {Key: "taxiType", Value: 1}, {Key: "effectiveFrom", Value: -1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create tariffs indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create stores a new tariff version, numbering it after the latest one of its city and taxi type
+func (r *tariffRepositoryImpl) Create(ctx context.Context, tariff *models.Tariff) error {
+	var latest models.Tariff
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{"city": tariff.City, "taxiType": tariff.TaxiType}, opts).Decode(&latest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	tariff.Version = latest.Version + 1
+	tariff.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, tariff)
+	if err != nil {
+		return err
+	}
+
+	tariff.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// List returns the tariff versions, optionally of one city and taxi type, newest first
+func (r *tariffRepositoryImpl) List(ctx context.Context, city, taxiType string) ([]models.Tariff, error) {
+	filter := bson.M{}
+	if city != "" {
+		filter["city"] = city
+	}
+	if taxiType != "" {
+		filter["taxiType"] = taxiType
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "city", Value: 1}, {Key: "taxiType", Value: 1}, {Key: "effectiveFrom", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tariffs []models.Tariff
+	if err := cursor.All(ctx, &tariffs); err != nil {
+		return nil, err
+	}
+
+	return tariffs, nil
+}
+
+// FindEffective returns the tariff in force at the given time
+func (r *tariffRepositoryImpl) FindEffective(ctx context.Context, city, taxiType string, at time.Time) (*models.Tariff, error) {
+	filter := bson.M{
+		"city":          city,
+		"taxiType":      taxiType,
+		"effectiveFrom": bson.M{"$lte": at},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "version", Value: -1}})
+
+	var tariff models.Tariff
+	if err := r.collection.FindOne(ctx, filter, opts).Decode(&tariff); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTariffNotFound
+		}
+		return nil, err
+	}
+
+	return &tariff, nil
+}
+
+// Count returns the number of stored tariff versions
+func (r *tariffRepositoryImpl) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
+
+// AssignCity is the startup backfill for tariffs created before cities existed
+func (r *tariffRepositoryImpl) AssignCity(ctx context.Context, city string) (int64, error) {
+	filter := bson.M{"$or": bson.A{bson.M{"city": bson.M{"$exists": false}}, bson.M{"city": ""}}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"city": city}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrTaxiTypeNotFound = errors.New("taxi type not found")
+	// ErrTaxiTypeExists means the code is already in the catalog
+	ErrTaxiTypeExists = errors.New("taxi type already exists")
+)
+
+// TaxiTypeRepository defines database operations for the taxi type catalog
+type TaxiTypeRepository interface {
+	Create(ctx context.Context, taxiType *models.TaxiType) error
+	Get(ctx context.Context, code string) (*models.TaxiType, error)
+	Update(ctx context.Context, taxiType *models.TaxiType) error
+	Delete(ctx context.Context, code string) error
+	List(ctx context.Context) ([]models.TaxiType, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+type taxiTypeRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewTaxiTypeRepository needs no extra index, entries are keyed by code
+func NewTaxiTypeRepository(db *mongo.Database) TaxiTypeRepository {
+	return &taxiTypeRepositoryImpl{
+		collection: db.Collection("taxi_types"),
+	}
+}
+
+// Create inserts a catalog entry
+func (r *taxiTypeRepositoryImpl) Create(ctx context.Context, taxiType *models.TaxiType) error {
+	now := time.Now()
+	taxiType.CreatedAt = now
+	taxiType.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(ctx, taxiType); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTaxiTypeExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a catalog entry by code
+func (r *taxiTypeRepositoryImpl) Get(ctx context.Context, code string) (*models.TaxiType, error) {
+	var taxiType models.TaxiType
+	if err := r.collection.FindOne(ctx, bson.M{"_id": code}).Decode(&taxiType); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTaxiTypeNotFound
+		}
+		return nil, err
+	}
+	return &taxiType, nil
+}
+
+// Update changes everything but the code and the creation time
+func (r *taxiTypeRepositoryImpl) Update(ctx context.Context, taxiType *models.TaxiType) error {
+	taxiType.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"nameTr":     taxiType.NameTR,
+			"nameEn":     taxiType.NameEN,
+			"color":      taxiType.Color,
+			"seats":      taxiType.Seats,
+			"tariffCode": taxiType.TariffCode,
+			"order":      taxiType.Order,
+			"cities":     taxiType.Cities,
+			"updatedAt":  taxiType.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": taxiType.Code}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrTaxiTypeNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a catalog entry
+func (r *taxiTypeRepositoryImpl) Delete(ctx context.Context, code string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": code})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrTaxiTypeNotFound
+	}
+
+	return nil
+}
+
+// List returns the whole catalog in filter button order
+func (r *taxiTypeRepositoryImpl) List(ctx context.Context) ([]models.TaxiType, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var taxiTypes []models.TaxiType
+	if err := cursor.All(ctx, &taxiTypes); err != nil {
+		return nil, err
+	}
+
+	return taxiTypes, nil
+}
+
+// Count returns the number of catalog entries
+func (r *taxiTypeRepositoryImpl) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// scoped limits a filter to the caller's tenant; platform admins and internal jobs see every
+// tenant unless they narrowed the request to one
+func scoped(ctx context.Context, filter bson.M) bson.M {
+	if id := tenant.ID(ctx); id != "" {
+		filter["tenantId"] = id
+	}
+	return filter
+}
+
+// stampTenant sets the owner of a new record to the caller's tenant, whatever the body said
+func stampTenant(ctx context.Context, tenantID *string) {
+	if id := tenant.ID(ctx); id != "" {
+		*tenantID = id
+	}
+}
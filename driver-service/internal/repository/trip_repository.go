@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrTripNotFound = errors.New("trip not found")
+	// ErrTripStateChanged means the trip left the expected state before the update landed
+	ErrTripStateChanged = errors.New("trip state changed concurrently")
+	// ErrDriverBusy means the driver already holds another active trip
+	ErrDriverBusy = errors.New("driver already has an active trip")
+)
+
+// TripRepository defines database operations for trips
+type TripRepository interface {
+	Create(ctx context.Context, trip *models.Trip) (string, error)
+	GetByID(ctx context.Context, id string) (*models.Trip, error)
+	List(ctx context.Context, page, pageSize int, driverID, status, city string) ([]models.Trip, error)
+	// Transition saves the new state of a trip only if it is still in the from state
+	Transition(ctx context.Context, trip *models.Trip, from string) error
+	FindActiveByDriver(ctx context.Context, driverID string) (*models.Trip, error)
+	ActiveDriverIDs(ctx context.Context) (map[string]bool, error)
+	ListCompletedSince(ctx context.Context, since time.Time) ([]models.Trip, error)
+}
+
+type tripRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewTripRepository(db *mongo.Database) TripRepository {
+	r := &tripRepositoryImpl{
+		collection: db.Collection("trips"),
+	}
+
+	// a driver can appear on at most one active trip; the service checks first, this index is the backstop
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "driverId", Value: 1}},
+			Options: options.Index().
+				SetName("one_active_trip_per_driver").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "requestedAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "requestedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create trips indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create inserts a new trip
+func (r *tripRepositoryImpl) Create(ctx context.Context, trip *models.Trip) (string, error) {
+	trip.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, trip)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", ErrDriverBusy
+		}
+		return "", err
+	}
+
+	oid, _ := result.InsertedID.(primitive.ObjectID)
+	trip.ID = oid
+	return oid.Hex(), nil
+}
+
+// scopedTrip limits a filter to the trips of the caller's drivers; fleet operators also see
+// requested trips, which have no driver yet, so their drivers can accept them
+func scopedTrip(ctx context.Context, filter bson.M) bson.M {
+	id := tenant.ID(ctx)
+	if id == "" {
+		return filter
+	}
+	return bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+		bson.M{"tenantId": id},
+		bson.M{"status": models.TripRequested},
+	}}}}
+}
+
+// GetByID returns a single trip
+func (r *tripRepositoryImpl) GetByID(ctx context.Context, id string) (*models.Trip, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	var trip models.Trip
+	if err := r.collection.FindOne(ctx, scopedTrip(ctx, bson.M{"_id": oid})).Decode(&trip); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTripNotFound
+		}
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+// List returns a paginated list of trips, newest first
+func (r *tripRepositoryImpl) List(ctx context.Context, page, pageSize int, driverID, status, city string) ([]models.Trip, error) {
+	skip := (page - 1) * pageSize
+
+	filter := bson.M{}
+	if driverID != "" {
+		filter["driverId"] = driverID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	if city != "" {
+		filter["city"] = city
+	}
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "requestedAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, scopedTrip(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var trips []models.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
+
+// Transition writes the trip's new state, guarded by its previous status
+func (r *tripRepositoryImpl) Transition(ctx context.Context, trip *models.Trip, from string) error {
+	trip.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":       trip.Status,
+			"active":       trip.Active,
+			"driverId":     trip.DriverID,
+			"taxiType":     trip.TaxiType,
+			"distanceKm":   trip.DistanceKm,
+			"cancelReason": trip.CancelReason,
+			"acceptedAt":   trip.AcceptedAt,
+			"arrivedAt":    trip.ArrivedAt,
+			"startedAt":    trip.StartedAt,
+			"completedAt":  trip.CompletedAt,
+			"cancelledAt":  trip.CancelledAt,
+			"updatedAt":    trip.UpdatedAt,
+			"tenantId":     trip.TenantID,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, scopedTrip(ctx, bson.M{"_id": trip.ID, "status": from}), update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDriverBusy
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrTripStateChanged
+	}
+
+	return nil
+}
+
+// FindActiveByDriver returns the driver's active trip or nil when the driver is free
+func (r *tripRepositoryImpl) FindActiveByDriver(ctx context.Context, driverID string) (*models.Trip, error) {
+	var trip models.Trip
+	err := r.collection.FindOne(ctx, scopedTrip(ctx, bson.M{"driverId": driverID, "active": true})).Decode(&trip)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+// ActiveDriverIDs returns the set of drivers that are currently on a trip
+func (r *tripRepositoryImpl) ActiveDriverIDs(ctx context.Context) (map[string]bool, error) {
+	ids, err := r.collection.Distinct(ctx, "driverId", scopedTrip(ctx, bson.M{"active": true}))
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			busy[s] = true
+		}
+	}
+	return busy, nil
+}
+
+// ListCompletedSince returns trips completed after the given time
+func (r *tripRepositoryImpl) ListCompletedSince(ctx context.Context, since time.Time) ([]models.Trip, error) {
+	filter := bson.M{
+		"status":      models.TripCompleted,
+		"completedAt": bson.M{"$gte": since},
+	}
+
+	cursor, err := r.collection.Find(ctx, scopedTrip(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var trips []models.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
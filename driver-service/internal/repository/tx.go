@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs a function so its writes land together or not at all. Repository calls made
+// with the context handed to fn join the transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+	// transactions need a replica set; a standalone server runs fn as it is
+	enabled bool
+}
+
+// NewTransactor checks once whether the deployment supports transactions
+func NewTransactor(client *mongo.Client) Transactor {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var hello bson.M
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	_, replicaSet := hello["setName"]
+	enabled := err == nil && (replicaSet || hello["msg"] == "isdbgrid")
+	if !enabled {
+		log.Printf("WARN: MongoDB is not a replica set, writes and their outbox events are not transactional")
+	}
+	return &mongoTransactor{client: client, enabled: enabled}
+}
+
+// WithTransaction retries fn on transient errors, so fn must be safe to run again. Called
+// inside a transaction, fn joins it and commits or aborts with it.
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.enabled || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrVehicleNotFound = errors.New("vehicle not found")
+	// ErrPlateTaken means another vehicle is already registered with the plate
+	ErrPlateTaken = errors.New("plate already registered")
+)
+
+// VehicleRepository defines database operations for vehicles
+type VehicleRepository interface {
+	Create(ctx context.Context, vehicle *models.Vehicle) (string, error)
+	GetByID(ctx context.Context, id string) (*models.Vehicle, error)
+	GetByPlate(ctx context.Context, plate string) (*models.Vehicle, error)
+	FindByIDs(ctx context.Context, ids []string) ([]models.Vehicle, error)
+	Update(ctx context.Context, id string, vehicle *models.Vehicle) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, page, pageSize int) ([]models.Vehicle, error)
+	CountByTaxiType(ctx context.Context, taxiType string) (int64, error)
+}
+
+type vehicleRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewVehicleRepository(db *mongo.Database) VehicleRepository {
+	r := &vehicleRepositoryImpl{
+		collection: db.Collection("vehicles"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// plates are unique across tenants, a car is registered only once
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "plate", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "plate", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create vehicles indexes: %v", err)
+	}
+
+	return r
+}
+
+// Create inserts a new vehicle owned by the caller's tenant
+func (r *vehicleRepositoryImpl) Create(ctx context.Context, vehicle *models.Vehicle) (string, error) {
+	stampTenant(ctx, &vehicle.TenantID)
+	now := time.Now()
+	vehicle.Plate = models.NormalizePlate(vehicle.Plate)
+	vehicle.CreatedAt = now
+	vehicle.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, vehicle)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", ErrPlateTaken
+		}
+		return "", err
+	}
+
+	oid, _ := result.InsertedID.(primitive.ObjectID)
+	vehicle.ID = oid
+	return oid.Hex(), nil
+}
+
+// GetByID returns a single vehicle
+func (r *vehicleRepositoryImpl) GetByID(ctx context.Context, id string) (*models.Vehicle, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+// GetByPlate returns the vehicle registered with a plate, in any spelling
+func (r *vehicleRepositoryImpl) GetByPlate(ctx context.Context, plate string) (*models.Vehicle, error) {
+	return r.findOne(ctx, bson.M{"plate": models.NormalizePlate(plate)})
+}
+
+func (r *vehicleRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := r.collection.FindOne(ctx, scoped(ctx, filter)).Decode(&vehicle); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrVehicleNotFound
+		}
+		return nil, err
+	}
+	return &vehicle, nil
+}
+
+// FindByIDs returns the vehicles with the given ids; malformed and unknown ids are skipped
+func (r *vehicleRepositoryImpl) FindByIDs(ctx context.Context, ids []string) ([]models.Vehicle, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"_id": bson.M{"$in": oids}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vehicles []models.Vehicle
+	if err := cursor.All(ctx, &vehicles); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+// Update modifies an existing vehicle
+func (r *vehicleRepositoryImpl) Update(ctx context.Context, id string, vehicle *models.Vehicle) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	vehicle.Plate = models.NormalizePlate(vehicle.Plate)
+	vehicle.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"plate":      vehicle.Plate,
+			"taxiType":   vehicle.TaxiType,
+			"carBrand":   vehicle.CarBrand,
+			"carModel":   vehicle.CarModel,
+			"attributes": vehicle.Attributes,
+			"updatedAt":  vehicle.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, bson.M{"_id": oid}), update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrPlateTaken
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrVehicleNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a vehicle
+func (r *vehicleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	result, err := r.collection.DeleteOne(ctx, scoped(ctx, bson.M{"_id": oid}))
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrVehicleNotFound
+	}
+
+	return nil
+}
+
+// List returns a paginated list of vehicles ordered by plate
+func (r *vehicleRepositoryImpl) List(ctx context.Context, page, pageSize int) ([]models.Vehicle, error) {
+	skip := (page - 1) * pageSize
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "plate", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vehicles []models.Vehicle
+	if err := cursor.All(ctx, &vehicles); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+// CountByTaxiType returns how many vehicles are registered with a taxi type
+func (r *vehicleRepositoryImpl) CountByTaxiType(ctx context.Context, taxiType string) (int64, error) {
+	return r.collection.CountDocuments(ctx, scoped(ctx, bson.M{"taxiType": taxiType}))
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDeliveryNotFound is returned when no delivery matches, or none is due
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// DeliveryRepository queues webhook deliveries and keeps the dead ones
+type DeliveryRepository interface {
+	// Enqueue stores new deliveries, skipping events a subscription already has
+	Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ClaimDue takes the next pending delivery that is due and hides it from other workers for lease
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	// SaveAttempt stores the outcome of an attempt: status, attempts, next attempt and last error
+	SaveAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	List(ctx context.Context, subscriptionID, status string, page, pageSize int) ([]models.WebhookDelivery, error)
+	// Requeue makes a delivery pending again with fresh attempts
+	Requeue(ctx context.Context, id string) error
+	// RequeueDead does the same for every dead delivery of a subscription
+	RequeueDead(ctx context.Context, subscriptionID string) (int64, error)
+	// Resume makes the pending deliveries of a subscription due now, once it is active again
+	Resume(ctx context.Context, subscriptionID string) error
+	DeleteBySubscription(ctx context.Context, subscriptionID string) error
+}
+
+type deliveryRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewDeliveryRepository(db *mongo.Database) DeliveryRepository {
+	r := &deliveryRepositoryImpl{
+		collection: db.Collection("webhook_deliveries"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// an event is queued once per subscription however often the relay hands it over
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscriptionId", Value: 1}, {Key: "event.seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create webhook_deliveries indexes: %v", err)
+	}
+
+	return r
+}
+
+// Enqueue logic
+func (r *deliveryRepositoryImpl) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(deliveries))
+	for i := range deliveries {
+		docs[i] = deliveries[i]
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, we := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(we) {
+				return err
+			}
+		}
+		return nil
+	}
+	return err
+}
+
+// ClaimDue logic
+func (r *deliveryRepositoryImpl) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	filter := bson.M{"status": models.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// SaveAttempt logic
+func (r *deliveryRepositoryImpl) SaveAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":         delivery.Status,
+			"attempts":       delivery.Attempts,
+			"nextAttemptAt":  delivery.NextAttemptAt,
+			"lastStatusCode": delivery.LastStatusCode,
+			"lastError":      delivery.LastError,
+			"deliveredAt":    delivery.DeliveredAt,
+			"expiresAt":      delivery.ExpiresAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// GetByID returns a single delivery
+func (r *deliveryRepositoryImpl) GetByID(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	var delivery models.WebhookDelivery
+	if err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": oid})).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// List returns deliveries of a subscription, optionally in one state, newest first
+func (r *deliveryRepositoryImpl) List(ctx context.Context, subscriptionID, status string, page, pageSize int) ([]models.WebhookDelivery, error) {
+	skip := (page - 1) * pageSize
+
+	filter := bson.M{"subscriptionId": subscriptionID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Requeue logic
+func (r *deliveryRepositoryImpl) Requeue(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, bson.M{"_id": oid}), requeue())
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// RequeueDead logic
+func (r *deliveryRepositoryImpl) RequeueDead(ctx context.Context, subscriptionID string) (int64, error) {
+	filter := bson.M{"subscriptionId": subscriptionID, "status": models.DeliveryDead}
+	result, err := r.collection.UpdateMany(ctx, scoped(ctx, filter), requeue())
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteBySubscription drops everything queued for a removed subscription
+func (r *deliveryRepositoryImpl) DeleteBySubscription(ctx context.Context, subscriptionID string) error {
+	_, err := r.collection.DeleteMany(ctx, scoped(ctx, bson.M{"subscriptionId": subscriptionID}))
+	return err
+}
+
+// Resume logic
+func (r *deliveryRepositoryImpl) Resume(ctx context.Context, subscriptionID string) error {
+	filter := bson.M{"subscriptionId": subscriptionID, "status": models.DeliveryPending}
+	_, err := r.collection.UpdateMany(ctx, scoped(ctx, filter), bson.M{"$set": bson.M{"nextAttemptAt": time.Now()}})
+	return err
+}
+
+func requeue() bson.M {
+	return bson.M{
+		"$set":   bson.M{"status": models.DeliveryPending, "attempts": 0, "nextAttemptAt": time.Now()},
+		"$unset": bson.M{"deliveredAt": "", "expiresAt": "", "lastError": "", "lastStatusCode": ""},
+	}
+}
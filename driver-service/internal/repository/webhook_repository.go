@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrWebhookNotFound is returned when no subscription matches the given id
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+// WebhookRepository defines database operations for webhook subscriptions
+type WebhookRepository interface {
+	Create(ctx context.Context, sub *models.WebhookSubscription) (string, error)
+	GetByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	// Update changes url, events and active, and the secret when one is given
+	Update(ctx context.Context, id string, sub *models.WebhookSubscription) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, activeOnly bool) ([]models.WebhookSubscription, error)
+}
+
+type webhookRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Database) WebhookRepository {
+	r := &webhookRepositoryImpl{
+		collection: db.Collection("webhook_subscriptions"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("WARN: could not create webhook_subscriptions index: %v", err)
+	}
+
+	return r
+}
+
+// Create inserts a subscription owned by the caller's tenant
+func (r *webhookRepositoryImpl) Create(ctx context.Context, sub *models.WebhookSubscription) (string, error) {
+	stampTenant(ctx, &sub.TenantID)
+	now := time.Now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, sub)
+	if err != nil {
+		return "", err
+	}
+
+	oid, _ := result.InsertedID.(primitive.ObjectID)
+	sub.ID = oid
+	return oid.Hex(), nil
+}
+
+// GetByID returns a single subscription
+func (r *webhookRepositoryImpl) GetByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	var sub models.WebhookSubscription
+	if err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": oid})).Decode(&sub); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// Update logic
+func (r *webhookRepositoryImpl) Update(ctx context.Context, id string, sub *models.WebhookSubscription) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	sub.UpdatedAt = time.Now()
+	set := bson.M{
+		"url":       sub.URL,
+		"events":    sub.Events,
+		"active":    sub.Active,
+		"updatedAt": sub.UpdatedAt,
+	}
+	if sub.Secret != "" {
+		set["secret"] = sub.Secret
+	}
+
+	result, err := r.collection.UpdateOne(ctx, scoped(ctx, bson.M{"_id": oid}), bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a subscription
+func (r *webhookRepositoryImpl) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+
+	result, err := r.collection.DeleteOne(ctx, scoped(ctx, bson.M{"_id": oid}))
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// List returns the caller's subscriptions, oldest first
+func (r *webhookRepositoryImpl) List(ctx context.Context, activeOnly bool) ([]models.WebhookSubscription, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subs []models.WebhookSubscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
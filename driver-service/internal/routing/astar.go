@@ -0,0 +1,91 @@
+package routing
+
+import "container/heap"
+
+// points farther than this from any road are not routed
+const maxSnapMeters = 300
+
+// Route finds the fastest path between two coordinates with A*.
+// The heuristic is the straight-line distance at the fastest speed in the graph.
+func (g *Graph) Route(fromLat, fromLon, toLat, toLon float64) (Route, bool) {
+	src, ok := g.grid.nearest(fromLat, fromLon, maxSnapMeters)
+	if !ok {
+		return Route{}, false
+	}
+	dst, ok := g.grid.nearest(toLat, toLon, maxSnapMeters)
+	if !ok {
+		return Route{}, false
+	}
+	if src == dst {
+		return Route{}, true
+	}
+
+	type state struct {
+		secs   float64
+		meters float64
+		done   bool
+	}
+	seen := map[int32]*state{src: {}}
+
+	open := &queue{}
+	heap.Push(open, item{node: src, priority: g.heuristic(src, dst)})
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(item)
+		st := seen[cur.node]
+		if st.done {
+			continue
+		}
+		st.done = true
+
+		if cur.node == dst {
+			return Route{DistanceKm: st.meters / 1000, DurationMinutes: st.secs / 60}, true
+		}
+
+		for k := g.edgeStart[cur.node]; k < g.edgeStart[cur.node+1]; k++ {
+			next := g.edgeTarget[k]
+			secs := st.secs + float64(g.edgeSecs[k])
+
+			ns, ok := seen[next]
+			if ok && (ns.done || ns.secs <= secs) {
+				continue
+			}
+			if !ok {
+				ns = &state{}
+				seen[next] = ns
+			}
+			ns.secs = secs
+			ns.meters = st.meters + float64(g.edgeMeters[k])
+			heap.Push(open, item{node: next, priority: secs + g.heuristic(next, dst)})
+		}
+	}
+
+	return Route{}, false
+}
+
+// heuristic is a lower bound of the seconds needed from a to b
+func (g *Graph) heuristic(a, b int32) float64 {
+	if g.maxSpeed <= 0 {
+		return 0
+	}
+	return g.metersBetween(a, b) / g.maxSpeed
+}
+
+type item struct {
+	node     int32
+	priority float64
+}
+
+// queue is a min-heap of items by priority
+type queue []item
+
+func (q queue) Len() int            { return len(q) }
+func (q queue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(item)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
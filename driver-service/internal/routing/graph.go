@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"math"
+
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// Route is the answer of a shortest path query
+type Route struct {
+	DistanceKm      float64 `json:"distanceKm"`
+	DurationMinutes float64 `json:"durationMinutes"` // free-flow time at the road speed limits
+}
+
+// Router answers road distance queries between two coordinates.
+// ok is false when either point is off the network or no path connects them.
+type Router interface {
+	Route(fromLat, fromLon, toLat, toLon float64) (route Route, ok bool)
+}
+
+// DistanceKm returns the road distance when a router is configured and can answer,
+// and the straight-line distance otherwise
+func DistanceKm(r Router, fromLat, fromLon, toLat, toLon float64) float64 {
+	if r != nil {
+		if route, ok := r.Route(fromLat, fromLon, toLat, toLon); ok {
+			return route.DistanceKm
+		}
+	}
+	return geo.Distance(fromLat, fromLon, toLat, toLon)
+}
+
+// Graph is a directed road network in compressed sparse row form.
+// Edges of node i are edgeTarget[edgeStart[i]:edgeStart[i+1]].
+type Graph struct {
+	lat []float64
+	lon []float64
+
+	edgeStart  []int32
+	edgeTarget []int32
+	edgeMeters []float32
+	edgeSecs   []float32
+
+	// fastest speed in the graph in meters per second, keeps the A* heuristic admissible
+	maxSpeed float64
+
+	grid *grid
+}
+
+// NodeCount returns the number of routable nodes
+func (g *Graph) NodeCount() int {
+	return len(g.lat)
+}
+
+// EdgeCount returns the number of directed edges
+func (g *Graph) EdgeCount() int {
+	return len(g.edgeTarget)
+}
+
+// metersBetween is the Haversine distance between two graph nodes in meters
+func (g *Graph) metersBetween(a, b int32) float64 {
+	return geo.Distance(g.lat[a], g.lon[a], g.lat[b], g.lon[b]) * 1000
+}
+
+// edge is used while building the graph
+type edge struct {
+	from, to int32
+	meters   float32
+	secs     float32
+}
+
+// newGraph packs node coordinates and an edge list into a Graph
+func newGraph(lat, lon []float64, edges []edge) *Graph {
+	n := len(lat)
+	g := &Graph{
+		lat:        lat,
+		lon:        lon,
+		edgeStart:  make([]int32, n+1),
+		edgeTarget: make([]int32, len(edges)),
+		edgeMeters: make([]float32, len(edges)),
+		edgeSecs:   make([]float32, len(edges)),
+	}
+
+	// counting sort by source node
+	for _, e := range edges {
+		g.edgeStart[e.from+1]++
+	}
+	for i := 1; i <= n; i++ {
+		g.edgeStart[i] += g.edgeStart[i-1]
+	}
+	next := make([]int32, n)
+	copy(next, g.edgeStart[:n])
+	for _, e := range edges {
+		k := next[e.from]
+		next[e.from]++
+		g.edgeTarget[k] = e.to
+		g.edgeMeters[k] = e.meters
+		g.edgeSecs[k] = e.secs
+		if e.secs > 0 {
+			g.maxSpeed = math.Max(g.maxSpeed, float64(e.meters/e.secs))
+		}
+	}
+
+	g.grid = newGrid(lat, lon)
+	return g
+}
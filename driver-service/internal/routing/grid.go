@@ -0,0 +1,55 @@
+package routing
+
+import (
+	"math"
+
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// grid cells are about 500 m tall at Istanbul's latitude
+const cellDeg = 0.005
+
+type cell struct{ x, y int32 }
+
+// grid buckets nodes by coordinate so snapping a point does not scan the whole graph
+type grid struct {
+	cells map[cell][]int32
+	lat   []float64
+	lon   []float64
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{int32(math.Floor(lon / cellDeg)), int32(math.Floor(lat / cellDeg))}
+}
+
+func newGrid(lat, lon []float64) *grid {
+	g := &grid{cells: make(map[cell][]int32), lat: lat, lon: lon}
+	for i := range lat {
+		c := cellOf(lat[i], lon[i])
+		g.cells[c] = append(g.cells[c], int32(i))
+	}
+	return g
+}
+
+// nearest returns the closest node within maxMeters of the point, looking at the 3x3 surrounding cells
+func (g *grid) nearest(lat, lon, maxMeters float64) (int32, bool) {
+	center := cellOf(lat, lon)
+
+	best, bestDist := int32(-1), math.Inf(1)
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for _, n := range g.cells[cell{center.x + dx, center.y + dy}] {
+				// equirectangular approximation is plenty for a few hundred meters
+				d := geo.Equirectangular(lat, lon, g.lat[n], g.lon[n]) * 1000
+				if d < bestDist {
+					best, bestDist = n, d
+				}
+			}
+		}
+	}
+
+	if best < 0 || bestDist > maxMeters {
+		return -1, false
+	}
+	return best, true
+}
@@ -0,0 +1,204 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-geo"
+	"github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmpbf"
+)
+
+// default speeds in km/h by highway class when a way has no usable maxspeed tag
+var highwaySpeeds = map[string]float64{
+	"motorway":       90,
+	"motorway_link":  50,
+	"trunk":          70,
+	"trunk_link":     40,
+	"primary":        50,
+	"primary_link":   35,
+	"secondary":      40,
+	"secondary_link": 30,
+	"tertiary":       35,
+	"tertiary_link":  25,
+	"unclassified":   30,
+	"residential":    25,
+	"living_street":  10,
+	"service":        15,
+}
+
+// LoadPBF builds a road graph from an OpenStreetMap PBF extract.
+// The file is read twice: first for drivable ways, then for the coordinates of their nodes.
+func LoadPBF(ctx context.Context, path string) (*Graph, error) {
+	ways, err := readWays(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(ways) == 0 {
+		return nil, fmt.Errorf("no drivable roads in %s", path)
+	}
+
+	// compact node ids to dense indices
+	index := make(map[osm.NodeID]int32)
+	for _, w := range ways {
+		for _, id := range w.nodes {
+			if _, ok := index[id]; !ok {
+				index[id] = int32(len(index))
+			}
+		}
+	}
+
+	lat := make([]float64, len(index))
+	lon := make([]float64, len(index))
+	found := make([]bool, len(index))
+	if err := readNodes(ctx, path, index, lat, lon, found); err != nil {
+		return nil, err
+	}
+
+	g := buildGraph(ways, index, lat, lon, found)
+	if g == nil {
+		return nil, fmt.Errorf("no road nodes in %s", path)
+	}
+	return g, nil
+}
+
+// buildGraph turns the ways into edges between the nodes that were found, nil when none was.
+// Extracts clip ways at their border: nodes outside it are missing and would sit at 0,0,
+// so they are left out and the segments reaching them dropped.
+func buildGraph(ways []road, index map[osm.NodeID]int32, lat, lon []float64, found []bool) *Graph {
+	dense := make([]int32, len(index))
+	kept := int32(0)
+	for i := range found {
+		dense[i] = -1
+		if found[i] {
+			dense[i] = kept
+			lat[kept], lon[kept] = lat[i], lon[i]
+			kept++
+		}
+	}
+	if kept == 0 {
+		return nil
+	}
+	lat, lon = lat[:kept], lon[:kept]
+
+	var edges []edge
+	for _, w := range ways {
+		mps := w.speedKmh / 3.6
+		for i := 1; i < len(w.nodes); i++ {
+			a, b := dense[index[w.nodes[i-1]]], dense[index[w.nodes[i]]]
+			if a < 0 || b < 0 {
+				continue
+			}
+			meters := geo.Distance(lat[a], lon[a], lat[b], lon[b]) * 1000
+			secs := float32(meters / mps)
+			if w.oneway >= 0 {
+				edges = append(edges, edge{from: a, to: b, meters: float32(meters), secs: secs})
+			}
+			if w.oneway <= 0 {
+				edges = append(edges, edge{from: b, to: a, meters: float32(meters), secs: secs})
+			}
+		}
+	}
+
+	return newGraph(lat, lon, edges)
+}
+
+// road is the part of an OSM way the graph needs.
+// oneway is 1 for forward only, -1 for backward only and 0 for both directions.
+type road struct {
+	nodes    []osm.NodeID
+	speedKmh float64
+	oneway   int
+}
+
+func readWays(ctx context.Context, path string) ([]road, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := osmpbf.New(ctx, f, runtime.GOMAXPROCS(0))
+	defer scanner.Close()
+	scanner.SkipNodes = true
+	scanner.SkipRelations = true
+	scanner.FilterWay = func(w *osm.Way) bool {
+		_, ok := highwaySpeeds[w.Tags.Find("highway")]
+		return ok && w.Tags.Find("access") != "no" && w.Tags.Find("motor_vehicle") != "no"
+	}
+
+	var roads []road
+	for scanner.Scan() {
+		w, ok := scanner.Object().(*osm.Way)
+		if !ok || len(w.Nodes) < 2 {
+			continue
+		}
+		roads = append(roads, road{
+			nodes:    w.Nodes.NodeIDs(),
+			speedKmh: waySpeed(w.Tags),
+			oneway:   wayOneway(w.Tags),
+		})
+	}
+
+	return roads, scanner.Err()
+}
+
+// readNodes fills the coordinates of the indexed nodes and marks the ones the extract has
+func readNodes(ctx context.Context, path string, index map[osm.NodeID]int32, lat, lon []float64, found []bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := osmpbf.New(ctx, f, runtime.GOMAXPROCS(0))
+	defer scanner.Close()
+	scanner.SkipWays = true
+	scanner.SkipRelations = true
+	scanner.FilterNode = func(n *osm.Node) bool {
+		_, ok := index[n.ID]
+		return ok
+	}
+
+	for scanner.Scan() {
+		n, ok := scanner.Object().(*osm.Node)
+		if !ok {
+			continue
+		}
+		i := index[n.ID]
+		lat[i], lon[i] = n.Lat, n.Lon
+		found[i] = true
+	}
+
+	return scanner.Err()
+}
+
+// wayOneway reads the oneway tag; motorways and roundabouts are one way by default
+func wayOneway(tags osm.Tags) int {
+	switch tags.Find("oneway") {
+	case "yes", "true", "1":
+		return 1
+	case "-1", "reverse":
+		return -1
+	case "no", "false", "0":
+		return 0
+	}
+	if tags.Find("highway") == "motorway" || tags.Find("junction") == "roundabout" {
+		return 1
+	}
+	return 0
+}
+
+// waySpeed uses a numeric maxspeed tag in km/h when present, else the class default
+func waySpeed(tags osm.Tags) float64 {
+	if v := strings.TrimSpace(tags.Find("maxspeed")); v != "" {
+		if kmh, err := strconv.ParseFloat(strings.TrimSuffix(v, " km/h"), 64); err == nil && kmh > 0 {
+			return kmh
+		}
+	}
+	return highwaySpeeds[tags.Find("highway")]
+}
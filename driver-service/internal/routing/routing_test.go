@@ -0,0 +1,135 @@
+package routing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/eneszeyt/bitaksi-geo"
+	"github.com/paulmach/osm"
+)
+
+// test network: a short but slow street from a to b, and a longer fast road a-c-b.
+// d only has a one way exit towards a, nothing leads back to it.
+var (
+	nodeLat = []float64{41.000, 41.000, 41.010, 41.002}
+	nodeLon = []float64{29.000, 29.010, 29.005, 28.995}
+)
+
+const a, b, c, d = 0, 1, 2, 3
+
+func link(from, to int32, kmh float64) []edge {
+	meters := geo.Distance(nodeLat[from], nodeLon[from], nodeLat[to], nodeLon[to]) * 1000
+	secs := float32(meters / (kmh / 3.6))
+	return []edge{{from: from, to: to, meters: float32(meters), secs: secs}, {from: to, to: from, meters: float32(meters), secs: secs}}
+}
+
+func testGraph() *Graph {
+	var edges []edge
+	edges = append(edges, link(a, b, 10)...)
+	edges = append(edges, link(a, c, 90)...)
+	edges = append(edges, link(c, b, 90)...)
+	edges = append(edges, link(d, a, 30)[0])
+	return newGraph(nodeLat, nodeLon, edges)
+}
+
+func km(from, to int) float64 {
+	return geo.Distance(nodeLat[from], nodeLon[from], nodeLat[to], nodeLon[to])
+}
+
+func TestRoutePrefersFastestPath(t *testing.T) {
+	g := testGraph()
+
+	route, ok := g.Route(nodeLat[a], nodeLon[a], nodeLat[b], nodeLon[b])
+	if !ok {
+		t.Fatal("a to b not routed")
+	}
+	want := km(a, c) + km(c, b)
+	if math.Abs(route.DistanceKm-want) > 0.001 {
+		t.Fatalf("distance %.3f km, want the fast detour of %.3f km, not the %.3f km street", route.DistanceKm, want, km(a, b))
+	}
+	if wantMin := want / 90 * 60; math.Abs(route.DurationMinutes-wantMin) > 0.01 {
+		t.Fatalf("duration %.2f min, want %.2f", route.DurationMinutes, wantMin)
+	}
+	if slow := km(a, b) / 10 * 60; route.DurationMinutes >= slow {
+		t.Fatalf("duration %.2f min is not faster than the direct street's %.2f", route.DurationMinutes, slow)
+	}
+}
+
+func TestRouteUnreachable(t *testing.T) {
+	g := testGraph()
+
+	if _, ok := g.Route(nodeLat[a], nodeLon[a], nodeLat[d], nodeLon[d]); ok {
+		t.Fatal("a to d routed, but no edge leads to d")
+	}
+	if _, ok := g.Route(nodeLat[d], nodeLon[d], nodeLat[b], nodeLon[b]); !ok {
+		t.Fatal("d to b not routed over the one way exit")
+	}
+}
+
+func TestRouteSnapping(t *testing.T) {
+	g := testGraph()
+
+	// about 110 m off a still snaps to it
+	if _, ok := g.Route(nodeLat[a]-0.001, nodeLon[a], nodeLat[b], nodeLon[b]); !ok {
+		t.Fatal("point near a not snapped")
+	}
+	// about 1.1 km from the nearest node is off the network
+	if _, ok := g.Route(nodeLat[a]-0.01, nodeLon[a], nodeLat[b], nodeLon[b]); ok {
+		t.Fatal("point beyond maxSnapMeters routed")
+	}
+	if _, ok := g.Route(nodeLat[a], nodeLon[a], nodeLat[b], nodeLon[b]+0.01); ok {
+		t.Fatal("destination beyond maxSnapMeters routed")
+	}
+}
+
+func TestRouteSameNode(t *testing.T) {
+	g := testGraph()
+
+	route, ok := g.Route(nodeLat[a], nodeLon[a], nodeLat[a]+0.0005, nodeLon[a])
+	if !ok || route != (Route{}) {
+		t.Fatalf("both ends at a: %+v, %v, want an empty route", route, ok)
+	}
+}
+
+func TestDistanceKmFallsBackToStraightLine(t *testing.T) {
+	g := testGraph()
+	straight := km(a, d)
+
+	if got := DistanceKm(nil, nodeLat[a], nodeLon[a], nodeLat[d], nodeLon[d]); got != straight {
+		t.Fatalf("nil router: %.3f km, want %.3f", got, straight)
+	}
+	if got := DistanceKm(g, nodeLat[a], nodeLon[a], nodeLat[d], nodeLon[d]); got != straight {
+		t.Fatalf("unreachable: %.3f km, want %.3f", got, straight)
+	}
+	if got := DistanceKm(g, nodeLat[a], nodeLon[a], nodeLat[b], nodeLon[b]); got <= km(a, b) {
+		t.Fatalf("routed: %.3f km, want the road distance above %.3f", got, km(a, b))
+	}
+}
+
+func TestBuildGraphDropsMissingNodes(t *testing.T) {
+	index := map[osm.NodeID]int32{10: 0, 11: 1, 12: 2}
+	ways := []road{{nodes: []osm.NodeID{10, 11, 12}, speedKmh: 50}}
+
+	// 11 lies outside the extract
+	lat := []float64{41.000, 0, 41.001}
+	lon := []float64{29.000, 0, 29.001}
+	g := buildGraph(ways, index, lat, lon, []bool{true, false, true})
+	if g.NodeCount() != 2 || g.EdgeCount() != 0 {
+		t.Fatalf("graph has %d nodes and %d edges, want 2 nodes and no segment through the missing one", g.NodeCount(), g.EdgeCount())
+	}
+	for i := 0; i < g.NodeCount(); i++ {
+		if g.lat[i] == 0 || g.lon[i] == 0 {
+			t.Fatalf("node %d at %v,%v", i, g.lat[i], g.lon[i])
+		}
+	}
+
+	lat, lon = []float64{41.000, 41.001, 0}, []float64{29.000, 29.001, 0}
+	g = buildGraph(ways, index, lat, lon, []bool{true, true, false})
+	if g.NodeCount() != 2 || g.EdgeCount() != 2 {
+		t.Fatalf("graph has %d nodes and %d edges, want the 10-11 segment both ways", g.NodeCount(), g.EdgeCount())
+	}
+
+	if g := buildGraph(ways, index, make([]float64, 3), make([]float64, 3), make([]bool, 3)); g != nil {
+		t.Fatal("graph built without any node")
+	}
+}
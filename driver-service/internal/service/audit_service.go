@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+)
+
+// auditIgnored are fields every write touches or the service derives; they only add noise to diffs
+var auditIgnored = map[string]bool{
+	"id": true, "createdAt": true, "updatedAt": true, "version": true,
+	"vehicle": true, "locationAt": true, "rating": true,
+}
+
+// auditSkipUnchanged are the actions of edits, recorded only when a field actually changed
+var auditSkipUnchanged = map[string]bool{
+	models.AuditUpdate: true, models.AuditVehicleChange: true, models.AuditRatingChange: true,
+}
+
+// AuditService keeps the trail of who changed which driver or vehicle
+type AuditService interface {
+	// Record appends an entry with the fields that differ between before and after; nil before
+	// means the record was created, nil after that it was deleted. It is called with the context
+	// of the write's transaction, so the entry is stored with the write or neither is.
+	Record(ctx context.Context, entry *models.AuditEntry, before, after interface{}) error
+	History(ctx context.Context, driverID string, page, pageSize int) ([]models.AuditEntry, error)
+	// Search looks through the whole log, platform admins only
+	Search(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error)
+}
+
+type auditServiceImpl struct {
+	repo    repository.AuditRepository
+	drivers repository.DriverRepository
+}
+
+// NewAuditService creates service instance
+func NewAuditService(repo repository.AuditRepository, drivers repository.DriverRepository) AuditService {
+	return &auditServiceImpl{repo: repo, drivers: drivers}
+}
+
+// Record logic; edits that change nothing are not recorded, writes outside a request are the system's
+func (s *auditServiceImpl) Record(ctx context.Context, entry *models.AuditEntry, before, after interface{}) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return fmt.Errorf("audit diff of %s %s: %w", entry.Entity, entry.EntityID, err)
+	}
+	if len(changes) == 0 && auditSkipUnchanged[entry.Action] {
+		return nil
+	}
+	entry.Changes = changes
+	if entry.Actor == "" {
+		entry.Actor = tenant.Actor(ctx)
+	}
+	entry.RequestID = tenant.RequestID(ctx)
+	if entry.Actor == "" && entry.RequestID == "" {
+		entry.Actor = SystemActor
+	}
+	return s.repo.Append(ctx, entry)
+}
+
+// History lists the entries of a driver the caller can see, newest first
+func (s *auditServiceImpl) History(ctx context.Context, driverID string, page, pageSize int) ([]models.AuditEntry, error) {
+	if _, err := s.drivers.GetByID(ctx, driverID); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, models.AuditQuery{Entity: models.AuditDriver, EntityID: driverID, Page: page, PageSize: pageSize})
+}
+
+// Search logic
+func (s *auditServiceImpl) Search(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error) {
+	if err := requirePlatform(ctx); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, q)
+}
+
+func (s *auditServiceImpl) list(ctx context.Context, q models.AuditQuery) ([]models.AuditEntry, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = 20
+	}
+	return s.repo.List(ctx, q)
+}
+
+// diff compares the JSON form of two records field by field; empty values count as absent
+func diff(before, after interface{}) ([]models.FieldChange, error) {
+	b, err := fieldsOf(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fieldsOf(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(a)+len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []models.FieldChange
+	for _, name := range names {
+		if !reflect.DeepEqual(b[name], a[name]) {
+			changes = append(changes, models.FieldChange{Field: name, Before: b[name], After: a[name]})
+		}
+	}
+	return changes, nil
+}
+
+func fieldsOf(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if auditIgnored[name] || isEmpty(value) {
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, inner := range v {
+			if !isEmpty(inner) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+)
+
+// resolveCity picks the city of a request and reports unknown city codes as validation errors
+func resolveCity(cities *city.Registry, code string, lat, lon float64) (*city.City, error) {
+	c, err := cities.Resolve(code, lat, lon)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return c, nil
+}
+
+// checkCity accepts an empty filter or a configured city code
+func checkCity(cities *city.Registry, code string) error {
+	if code == "" {
+		return nil
+	}
+	if _, err := cities.Get(code); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return nil
+}
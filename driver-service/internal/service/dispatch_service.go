@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/dispatch"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// offered rides are kept this long so clients can poll their result
+const rideRetention = 10 * time.Minute
+
+// DispatchService collects ride requests and matches them to drivers in batches
+type DispatchService interface {
+	RequestRide(ctx context.Context, req *models.RideRequest) (string, error)
+	GetRide(ctx context.Context, id string) (*models.RideRequest, error)
+	Run(ctx context.Context)
+}
+
+type dispatchServiceImpl struct {
+	repo       repository.DriverRepository
+	trips      repository.TripRepository
+	matcher    *dispatch.Matcher
+	cities     *city.Registry
+	window     time.Duration
+	offerTTL   time.Duration
+	pendingTTL time.Duration
+
+	mu       sync.Mutex
+	pending  []string                       // request ids waiting for the next batch, oldest first
+	rides    map[string]*models.RideRequest // all known requests by id
+	reserved map[string]time.Time           // driver id -> offer expiry
+}
+
+// NewDispatchService creates a batch dispatcher that matches every window and gives up on
+// requests still unmatched after pendingTTL
+func NewDispatchService(repo repository.DriverRepository, trips repository.TripRepository, matcher *dispatch.Matcher, cities *city.Registry, window, offerTTL, pendingTTL time.Duration) DispatchService {
+	return &dispatchServiceImpl{
+		repo:       repo,
+		trips:      trips,
+		matcher:    matcher,
+		cities:     cities,
+		window:     window,
+		offerTTL:   offerTTL,
+		pendingTTL: pendingTTL,
+		rides:      make(map[string]*models.RideRequest),
+		reserved:   make(map[string]time.Time),
+	}
+}
+
+// RequestRide queues a ride request for the next batch; the city is taken from the pickup unless given
+func (s *dispatchServiceImpl) RequestRide(ctx context.Context, req *models.RideRequest) (string, error) {
+	c, err := resolveCity(s.cities, req.City, req.Pickup.Lat, req.Pickup.Lon)
+	if err != nil {
+		return "", err
+	}
+	req.City = c.Code
+	requires, err := models.NormalizeAttributes(req.Requires, "")
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	req.Requires = requires
+	req.ID = primitive.NewObjectID().Hex()
+	req.Status = models.RideStatusPending
+	req.RequestedAt = time.Now()
+	req.Offer = nil
+	req.TenantID = tenant.ID(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rides[req.ID] = req
+	s.pending = append(s.pending, req.ID)
+
+	return req.ID, nil
+}
+
+// GetRide returns a copy of a ride request with its offer, if any. Operators only see the
+// rides they requested, like every other read scoped to the caller's tenant.
+func (s *dispatchServiceImpl) GetRide(ctx context.Context, id string) (*models.RideRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ride, ok := s.rides[id]
+	if !ok || (tenant.ID(ctx) != "" && ride.TenantID != tenant.ID(ctx)) {
+		return nil, errors.New("ride request not found")
+	}
+	copied := *ride
+	return &copied, nil
+}
+
+// Run matches pending requests every window until ctx is cancelled
+func (s *dispatchServiceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.matchBatch(ctx); err != nil {
+				log.Printf("dispatch: batch failed: %v", err)
+			}
+		}
+	}
+}
+
+// stale reports whether the driver's last position is older than its city allows; the nearby
+// search leaves out the same drivers
+func (s *dispatchServiceImpl) stale(d models.Driver, now time.Time) bool {
+	c, err := s.cities.Get(d.City)
+	if err != nil || c.Heartbeat <= 0 {
+		return false
+	}
+	return d.LocationAt == nil || now.Sub(*d.LocationAt) > time.Duration(c.Heartbeat)
+}
+
+// matchBatch solves one assignment round over the pending requests and free drivers.
+// The matcher works on a snapshot outside the lock, so requests keep coming in while it runs.
+func (s *dispatchServiceImpl) matchBatch(ctx context.Context) error {
+	now := time.Now()
+	s.mu.Lock()
+	s.expire(now)
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	requests := make([]models.RideRequest, 0, len(s.pending))
+	for _, id := range s.pending {
+		requests = append(requests, *s.rides[id])
+	}
+	reserved := make(map[string]time.Time, len(s.reserved))
+	for id, until := range s.reserved {
+		if now.Before(until) {
+			reserved[id] = until
+		} else {
+			delete(s.reserved, id)
+		}
+	}
+	s.mu.Unlock()
+
+	// requirements and cities differ per request, the matcher checks them per pair
+	drivers, err := s.repo.Search(ctx, repository.DriverQuery{})
+	if err != nil {
+		return err
+	}
+	busy, err := s.trips.ActiveDriverIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// drivers on a trip, holding an unexpired offer or silent past their city's heartbeat are not available
+	free := drivers[:0]
+	for _, d := range drivers {
+		if busy[d.ID.Hex()] || s.stale(d, now) {
+			continue
+		}
+		if _, ok := reserved[d.ID.Hex()]; ok {
+			continue
+		}
+		free = append(free, d)
+	}
+
+	offers := s.matcher.Match(requests, free)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched := make(map[string]bool, len(offers))
+	for i := range offers {
+		offer := offers[i]
+		ride, ok := s.rides[offer.RequestID]
+		if !ok || ride.Status != models.RideStatusPending {
+			continue
+		}
+		ride.Status = models.RideStatusOffered
+		ride.Offer = &offer
+		s.reserved[offer.DriverID] = offer.OfferedAt.Add(s.offerTTL)
+		matched[offer.RequestID] = true
+		log.Printf("dispatch: offered driver %s to request %s (%.2f km)", offer.DriverID, offer.RequestID, offer.DistanceKm)
+	}
+
+	// unmatched requests stay queued; requests added during matching are kept too
+	remaining := s.pending[:0]
+	for _, id := range s.pending {
+		if !matched[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	s.pending = remaining
+
+	return nil
+}
+
+// expire gives up on requests pending longer than pendingTTL and forgets old requests, so the
+// in-memory store does not grow forever; the caller holds s.mu
+func (s *dispatchServiceImpl) expire(now time.Time) {
+	if s.pendingTTL > 0 {
+		remaining := s.pending[:0]
+		for _, id := range s.pending {
+			ride := s.rides[id]
+			if now.Sub(ride.RequestedAt) > s.pendingTTL {
+				ride.Status = models.RideStatusExpired
+				log.Printf("dispatch: request %s expired without a driver", id)
+				continue
+			}
+			remaining = append(remaining, id)
+		}
+		s.pending = remaining
+	}
+
+	for id, ride := range s.rides {
+		switch {
+		case ride.Offer != nil && now.Sub(ride.Offer.OfferedAt) > rideRetention:
+			delete(s.rides, id)
+		case ride.Status == models.RideStatusExpired && now.Sub(ride.RequestedAt) > s.pendingTTL+rideRetention:
+			delete(s.rides, id)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+)
+
+func TestDispatchExpire(t *testing.T) {
+	now := time.Now()
+	s := &dispatchServiceImpl{
+		pendingTTL: time.Minute,
+		rides: map[string]*models.RideRequest{
+			"fresh":   {ID: "fresh", Status: models.RideStatusPending, RequestedAt: now.Add(-30 * time.Second)},
+			"old":     {ID: "old", Status: models.RideStatusPending, RequestedAt: now.Add(-2 * time.Minute)},
+			"gone":    {ID: "gone", Status: models.RideStatusExpired, RequestedAt: now.Add(-time.Minute - rideRetention - time.Second)},
+			"offered": {ID: "offered", Status: models.RideStatusOffered, Offer: &models.Offer{OfferedAt: now.Add(-rideRetention - time.Second)}},
+		},
+		pending: []string{"old", "fresh"},
+	}
+
+	s.expire(now)
+
+	if len(s.pending) != 1 || s.pending[0] != "fresh" {
+		t.Fatalf("pending = %v, want [fresh]", s.pending)
+	}
+	if got := s.rides["old"].Status; got != models.RideStatusExpired {
+		t.Fatalf("old request status = %q, want %q", got, models.RideStatusExpired)
+	}
+	for _, id := range []string{"gone", "offered"} {
+		if _, ok := s.rides[id]; ok {
+			t.Fatalf("request %s past retention is still stored", id)
+		}
+	}
+}
+
+func TestGetRideIsScopedToTenant(t *testing.T) {
+	s := &dispatchServiceImpl{rides: map[string]*models.RideRequest{
+		"a": {ID: "a", Status: models.RideStatusPending, TenantID: "fleet-a"},
+		"p": {ID: "p", Status: models.RideStatusPending},
+	}}
+	fleetA := tenant.NewContext(context.Background(), tenant.Scope{TenantID: "fleet-a"})
+	fleetB := tenant.NewContext(context.Background(), tenant.Scope{TenantID: "fleet-b"})
+	platform := tenant.NewContext(context.Background(), tenant.Scope{Platform: true})
+
+	for _, tc := range []struct {
+		name  string
+		ctx   context.Context
+		id    string
+		found bool
+	}{
+		{"own ride", fleetA, "a", true},
+		{"another fleet's ride", fleetB, "a", false},
+		{"platform ride from a fleet", fleetB, "p", false},
+		{"platform admin", platform, "a", true},
+		{"internal caller", context.Background(), "a", true},
+	} {
+		ride, err := s.GetRide(tc.ctx, tc.id)
+		if found := err == nil; found != tc.found {
+			t.Errorf("%s: GetRide = %v, %v, want found %v", tc.name, ride, err, tc.found)
+		}
+	}
+}
@@ -1,85 +1,663 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
 	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
-	"github.com/eneszeyt/bitaksi-driver-service/internal/utils"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tracking"
+	"github.com/eneszeyt/bitaksi-geo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // DriverService defines business logic
 type DriverService interface {
 	CreateDriver(ctx context.Context, driver *models.Driver) (string, error)
-	UpdateDriver(ctx context.Context, id string, driver *models.Driver) error
-	ListDrivers(ctx context.Context, page, pageSize int) ([]models.Driver, error)
-	FindNearby(ctx context.Context, lat, lon float64, taxiType string) ([]map[string]interface{}, error)
+	GetDriver(ctx context.Context, id string) (*models.Driver, error)
+	// UpdateDriver and PatchDriver fail with repository.ErrVersionMismatch when the driver is
+	// no longer at version; repository.AnyVersion skips the check
+	UpdateDriver(ctx context.Context, id string, driver *models.Driver, version int64) error
+	PatchDriver(ctx context.Context, id string, patch []byte, version int64) error
+	// DeleteDriver removes a driver who is off shift, ending their vehicle assignment
+	DeleteDriver(ctx context.Context, id string) error
+	UpdateLocation(ctx context.Context, id string, update models.LocationUpdate) (*models.LocationPing, error)
+	IngestLocations(ctx context.Context, items []models.LocationBatchItem) ([]models.LocationBatchResult, error)
+	GetByPlate(ctx context.Context, plate string) (*models.Driver, error)
+	ListDrivers(ctx context.Context, page, pageSize int, city string) ([]models.Driver, error)
+	FindNearby(ctx context.Context, q models.NearbyQuery) ([]map[string]interface{}, error)
+	// Changes returns drivers created, updated or deleted since the token, "" for every driver
+	Changes(ctx context.Context, since string, limit int) (*models.DriverChanges, error)
 }
 
 type driverServiceImpl struct {
-	repo repository.DriverRepository
+	repo      repository.DriverRepository
+	locations repository.LocationRepository
+	estimator *eta.Estimator
+	router    routing.Router // nil when no road network is loaded
+	filter    *tracking.Filter
+	writer    *repository.LocationWriter // batched position writes
+	vehicles  VehicleService
+	taxiTypes TaxiTypeService
+	cities    *city.Registry
+	audit     AuditService
+	events    *outbox.Writer
 }
 
 // NewDriverService creates service instance
-func NewDriverService(repo repository.DriverRepository) DriverService {
-	return &driverServiceImpl{repo: repo}
+func NewDriverService(repo repository.DriverRepository, locations repository.LocationRepository, estimator *eta.Estimator, router routing.Router, filter *tracking.Filter, writer *repository.LocationWriter, vehicles VehicleService, taxiTypes TaxiTypeService, cities *city.Registry, audit AuditService, events *outbox.Writer) DriverService {
+	return &driverServiceImpl{repo: repo, locations: locations, estimator: estimator, router: router, filter: filter, writer: writer, vehicles: vehicles, taxiTypes: taxiTypes, cities: cities, audit: audit, events: events}
 }
 
-// CreateDriver implements the business logic for creating a driver
+// CreateDriver implements the business logic for creating a driver.
+// A plate on the driver assigns the vehicle with that plate, registering it if needed.
 func (s *driverServiceImpl) CreateDriver(ctx context.Context, driver *models.Driver) (string, error) {
-	return s.repo.Create(ctx, driver)
+	// new drivers start off duty and wait for approval before they show up in searches; the
+	// rating, heartbeat and tombstone are the server's, whatever the body said
+	driver.OnShift = false
+	driver.Status = models.DriverPending
+	driver.Rating = nil
+	driver.LocationAt = nil
+	driver.DeletedAt = nil
+	if err := s.validateDriver(ctx, driver); err != nil {
+		return "", err
+	}
+	// the vehicle is registered up front, a driver is never stored without the vehicle it was sent with
+	var vehicle *models.Vehicle
+	if driver.Plate != "" {
+		var err error
+		if vehicle, err = s.vehicles.RegisterPlate(ctx, vehicleOf(driver)); err != nil {
+			return "", err
+		}
+	}
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if _, err := s.repo.Create(ctx, driver); err != nil {
+			return nil, err
+		}
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driver.ID.Hex(), Action: models.AuditCreate, TenantID: driver.TenantID}
+		if err := s.audit.Record(ctx, entry, nil, driver); err != nil {
+			return nil, err
+		}
+		if vehicle != nil {
+			if err := s.vehicles.AssignVehicle(ctx, driver.ID.Hex(), vehicle, driver.CreatedAt); err != nil {
+				return nil, err
+			}
+		}
+		return eventsOf(models.EventDriverCreated, driver.ID.Hex(), driver.TenantID, driver)
+	})
+	if err != nil {
+		return "", err
+	}
+	return driver.ID.Hex(), nil
+}
+
+// GetDriver returns a driver with the current vehicle
+func (s *driverServiceImpl) GetDriver(ctx context.Context, id string) (*models.Driver, error) {
+	driver, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	drivers := []models.Driver{*driver}
+	if err := s.vehicles.ResolveVehicles(ctx, drivers); err != nil {
+		return nil, err
+	}
+	return &drivers[0], nil
+}
+
+// UpdateDriver logic; the plate moves the driver to that vehicle, an empty plate unassigns
+func (s *driverServiceImpl) UpdateDriver(ctx context.Context, id string, driver *models.Driver, version int64) error {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, id, current, driver, version)
+}
+
+// PatchDriver merges a JSON document into the driver (RFC 7396); fields it leaves out keep their value
+func (s *driverServiceImpl) PatchDriver(ctx context.Context, id string, patch []byte, version int64) error {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// fail before the vehicle and city lookups when the version is already stale
+	if version != repository.AnyVersion && current.Version != version {
+		return repository.ErrVersionMismatch
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+		return fmt.Errorf("%w: a merge patch of a driver must be a JSON object", ErrValidation)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if doc, err = mergePatch(doc, patch); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	// removed members are back at their zero value
+	var merged models.Driver
+	if err := json.Unmarshal(doc, &merged); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return s.update(ctx, id, current, &merged, version)
+}
+
+func (s *driverServiceImpl) update(ctx context.Context, id string, current, driver *models.Driver, version int64) error {
+	// a driver keeps their city unless the update names another one, and always their tenant
+	if driver.City == "" {
+		driver.City = current.City
+	}
+	driver.TenantID = current.TenantID
+	if err := s.validateDriver(ctx, driver); err != nil {
+		return err
+	}
+	// as on create the vehicle is registered before the transaction; the edit and the move to
+	// that vehicle are stored together, a driver on shift keeps both the old fields and vehicle
+	var vehicle *models.Vehicle
+	if driver.Plate != "" {
+		var err error
+		if vehicle, err = s.vehicles.RegisterPlate(ctx, vehicleOf(driver)); err != nil {
+			return err
+		}
+	}
+	var after *models.Driver
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if err := s.repo.Update(ctx, id, driver, version); err != nil {
+			return nil, err
+		}
+		if vehicle == nil {
+			if _, err := s.vehicles.Unassign(ctx, id); err != nil && !errors.Is(err, repository.ErrAssignmentNotFound) {
+				return nil, err
+			}
+		} else if err := s.vehicles.AssignVehicle(ctx, id, vehicle, time.Now()); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if after, err = s.repo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		// the vehicle change is recorded on its own
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: id, Action: models.AuditUpdate, TenantID: current.TenantID}
+		if err := s.audit.Record(ctx, entry, current, after); err != nil {
+			return nil, err
+		}
+		return eventsOf(models.EventDriverUpdated, id, after.TenantID, after)
+	})
+	if err != nil {
+		return err
+	}
+	// the taxi type may have changed, pick up its thresholds on the next ping
+	s.filter.Forget(id)
+	return nil
+}
+
+// DeleteDriver logic
+func (s *driverServiceImpl) DeleteDriver(ctx context.Context, id string) error {
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		driver, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if driver.OnShift {
+			return nil, repository.ErrDriverOnShift
+		}
+		if _, err := s.vehicles.Unassign(ctx, id); err != nil && !errors.Is(err, repository.ErrAssignmentNotFound) {
+			return nil, err
+		}
+		deleted, err := s.repo.Delete(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: id, Action: models.AuditDelete, TenantID: driver.TenantID}
+		if err := s.audit.Record(ctx, entry, driver, nil); err != nil {
+			return nil, err
+		}
+		return eventsOf(models.EventDriverDeleted, id, deleted.TenantID, deleted)
+	})
+	if err != nil {
+		return err
+	}
+	s.filter.Forget(id)
+	return nil
+}
+
+// validateDriver settles the city, checks the taxi type against the city's catalog and accepts
+// only driver scoped attributes; the mirrored vehicle ones are not client writable
+func (s *driverServiceImpl) validateDriver(ctx context.Context, driver *models.Driver) error {
+	c, err := resolveCity(s.cities, driver.City, driver.Location.Lat, driver.Location.Lon)
+	if err != nil {
+		return err
+	}
+	driver.City = c.Code
+
+	if driver.TaxiType != "" {
+		if err := s.taxiTypes.Validate(ctx, driver.TaxiType, driver.City); err != nil {
+			return err
+		}
+	}
+	attrs, err := models.NormalizeAttributes(driver.Attributes, models.AttributeScopeDriver)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	driver.Attributes = attrs
+	driver.VehicleAttributes = nil
+	return nil
+}
+
+// seedFilter starts the driver's filter from the last reported position. updatedAt moves on every
+// write, only locationAt dates the position; without it just the taxi type is known.
+func (s *driverServiceImpl) seedFilter(d *models.Driver) {
+	if d.LocationAt == nil {
+		s.filter.Seed(d.ID.Hex(), d.TaxiType, 0, 0, time.Time{})
+		return
+	}
+	s.filter.Seed(d.ID.Hex(), d.TaxiType, d.Location.Lat, d.Location.Lon, *d.LocationAt)
+}
+
+// eventsOf builds the single event most writes record
+func eventsOf(eventType, driverID, tenantID string, data interface{}) ([]models.Event, error) {
+	event, err := models.NewEvent(eventType, driverID, tenantID, data)
+	if err != nil {
+		return nil, err
+	}
+	return []models.Event{event}, nil
+}
+
+// updatedEvents reads drivers back and reports each as driver.updated. It runs inside the
+// transaction that changed them, so the events carry what was written.
+func updatedEvents(ctx context.Context, drivers repository.DriverRepository, ids ...string) ([]models.Event, error) {
+	changed, err := drivers.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]models.Event, 0, len(changed))
+	for i := range changed {
+		event, err := models.NewEvent(models.EventDriverUpdated, changed[i].ID.Hex(), changed[i].TenantID, &changed[i])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// vehicleOf reads the car fields older clients send on the driver
+func vehicleOf(driver *models.Driver) *models.Vehicle {
+	return &models.Vehicle{Plate: driver.Plate, TaxiType: driver.TaxiType, CarBrand: driver.CarBrand, CarModel: driver.CarModel, TenantID: driver.TenantID}
+}
+
+// UpdateLocation runs the ping through the driver's GPS filter, moves the driver to the
+// smoothed position and records raw and smoothed positions in the location history.
+// Rejected pings are stored for inspection but do not move the driver.
+func (s *driverServiceImpl) UpdateLocation(ctx context.Context, id string, update models.LocationUpdate) (*models.LocationPing, error) {
+	now := time.Now()
+	if err := validateLocation(update, now); err != nil {
+		return nil, err
+	}
+	ts := pingTime(update, now)
+
+	// after a restart the filter continues from the stored position
+	if !s.filter.Known(id) {
+		driver, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		s.seedFilter(driver)
+	}
+
+	ping := s.filterPing(id, update, ts)
+	if !ping.Rejected {
+		// a direct write supersedes anything still queued from a batch
+		s.writer.Drop(id)
+		err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+			driver, err := s.repo.UpdateLocation(ctx, id, ping.Location)
+			if err != nil {
+				return nil, err
+			}
+			return eventsOf(models.EventDriverLocationMoved, id, driver.TenantID, models.DriverMoved{Location: ping.Location, At: ping.Timestamp})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.locations.Add(ctx, ping); err != nil {
+		return nil, err
+	}
+	return ping, nil
 }
 
-// UpdateDriver logic
-func (s *driverServiceImpl) UpdateDriver(ctx context.Context, id string, driver *models.Driver) error {
-	return s.repo.Update(ctx, id, driver)
+// IngestLocations runs a batch of pings through the same filter as UpdateLocation.
+// Pings are applied in time order, current positions go through the buffered writer
+// and the history is appended with one bulk write. Results follow the input order.
+func (s *driverServiceImpl) IngestLocations(ctx context.Context, items []models.LocationBatchItem) ([]models.LocationBatchResult, error) {
+	now := time.Now()
+	results := make([]models.LocationBatchResult, len(items))
+	var valid []int
+	for i, item := range items {
+		results[i] = models.LocationBatchResult{Index: i, DriverID: item.DriverID}
+		err := validateLocation(item.LocationUpdate, now)
+		if err == nil && item.DriverID == "" {
+			err = fmt.Errorf("%w: driverId is required", ErrValidation)
+		}
+		if err != nil {
+			results[i].Status, results[i].Error = models.LocationError, err.Error()
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	// drivers the filter has not seen yet are looked up in one query; fleet operators
+	// have every driver looked up so they cannot move drivers of another tenant
+	var unknown []string
+	seen := make(map[string]bool)
+	checkAll := tenant.ID(ctx) != ""
+	for _, i := range valid {
+		id := items[i].DriverID
+		if !seen[id] && (checkAll || !s.filter.Known(id)) {
+			unknown = append(unknown, id)
+		}
+		seen[id] = true
+	}
+	missing := make(map[string]bool, len(unknown))
+	if len(unknown) > 0 {
+		drivers, err := s.repo.FindByIDs(ctx, unknown)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range unknown {
+			missing[id] = true
+		}
+		for _, d := range drivers {
+			id := d.ID.Hex()
+			delete(missing, id)
+			if !s.filter.Known(id) {
+				s.seedFilter(&d)
+			}
+		}
+	}
+
+	sort.SliceStable(valid, func(a, b int) bool {
+		return pingTime(items[valid[a]].LocationUpdate, now).Before(pingTime(items[valid[b]].LocationUpdate, now))
+	})
+
+	var pings []*models.LocationPing
+	var pingIndex []int
+	for _, i := range valid {
+		item := items[i]
+		if missing[item.DriverID] {
+			results[i].Status, results[i].Error = models.LocationError, repository.ErrDriverNotFound.Error()
+			continue
+		}
+
+		ping := s.filterPing(item.DriverID, item.LocationUpdate, pingTime(item.LocationUpdate, now))
+		location := ping.Location
+		results[i].Location = &location
+		results[i].Flag = ping.Flag
+		switch {
+		case ping.Rejected:
+			results[i].Status = models.LocationRejected
+		case ping.Flag != "":
+			results[i].Status = models.LocationFlagged
+		default:
+			results[i].Status = models.LocationQueued
+		}
+		if !ping.Rejected {
+			s.writer.Put(item.DriverID, ping.Location, ping.Timestamp)
+		}
+		pings = append(pings, ping)
+		pingIndex = append(pingIndex, i)
+	}
+
+	failed, err := s.locations.AddMany(ctx, pings)
+	if err != nil {
+		return nil, err
+	}
+	for k, werr := range failed {
+		i := pingIndex[k]
+		results[i].Status, results[i].Error = models.LocationError, werr.Error()
+	}
+
+	return results, nil
+}
+
+// filterPing runs one ping through the driver's GPS filter
+func (s *driverServiceImpl) filterPing(id string, update models.LocationUpdate, ts time.Time) *models.LocationPing {
+	res := s.filter.Apply(id, update.Lat, update.Lon, update.Accuracy, ts)
+	return &models.LocationPing{
+		DriverID:  id,
+		Location:  models.Location{Lat: res.Lat, Lon: res.Lon},
+		Raw:       models.Location{Lat: update.Lat, Lon: update.Lon},
+		Accuracy:  update.Accuracy,
+		SpeedKmh:  res.SpeedKmh,
+		Flag:      res.Flag,
+		Rejected:  res.Rejected,
+		Timestamp: ts,
+	}
+}
+
+// maxClockSkew is how far ahead of the server clock a phone may report a ping. A ping from
+// further in the future would make every later ping look out of order to the GPS filter.
+const maxClockSkew = 30 * time.Second
+
+func validateLocation(update models.LocationUpdate, now time.Time) error {
+	if update.Lat < -90 || update.Lat > 90 || update.Lon < -180 || update.Lon > 180 {
+		return fmt.Errorf("%w: coordinates out of range", ErrValidation)
+	}
+	if update.Ts != nil && update.Ts.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("%w: ts is more than %s in the future", ErrValidation, maxClockSkew)
+	}
+	return nil
+}
+
+// pingTime is the reported time of a ping, or the arrival time when it has none
+func pingTime(update models.LocationUpdate, arrived time.Time) time.Time {
+	if update.Ts != nil {
+		return *update.Ts
+	}
+	return arrived
+}
+
+// GetByPlate resolves a vehicle plate to the driver working with the vehicle
+func (s *driverServiceImpl) GetByPlate(ctx context.Context, plate string) (*models.Driver, error) {
+	if strings.TrimSpace(plate) == "" {
+		return nil, fmt.Errorf("%w: plate is required", ErrValidation)
+	}
+	id, err := s.vehicles.DriverByPlate(ctx, plate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
 }
 
-// ListDrivers logic
-func (s *driverServiceImpl) ListDrivers(ctx context.Context, page, pageSize int) ([]models.Driver, error) {
+// ListDrivers logic; city optionally narrows the list to one service area
+func (s *driverServiceImpl) ListDrivers(ctx context.Context, page, pageSize int, city string) ([]models.Driver, error) {
+	if err := checkCity(s.cities, city); err != nil {
+		return nil, err
+	}
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 {
 		pageSize = 20
 	}
-	return s.repo.List(ctx, page, pageSize)
+	drivers, err := s.repo.List(ctx, page, pageSize, city)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vehicles.ResolveVehicles(ctx, drivers); err != nil {
+		return nil, err
+	}
+	return drivers, nil
+}
+
+// the changes feed stops this far before now, so writes that were stamped earlier but
+// committed later, or stamped by an instance with a slightly slow clock, are not skipped
+const changesSettle = 5 * time.Second
+
+// Changes logic; deleted drivers come as tombstones, rejected and suspended ones as updates with their status
+func (s *driverServiceImpl) Changes(ctx context.Context, since string, limit int) (*models.DriverChanges, error) {
+	at, afterID, err := decodeChangesToken(since)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	drivers, err := s.repo.ChangedSince(ctx, at, afterID, time.Now().Add(-changesSettle), limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vehicles.ResolveVehicles(ctx, drivers); err != nil {
+		return nil, err
+	}
+
+	changes := &models.DriverChanges{Drivers: drivers, Next: since, More: len(drivers) == limit}
+	if len(drivers) > 0 {
+		last := drivers[len(drivers)-1]
+		changes.Next = encodeChangesToken(last.UpdatedAt, last.ID.Hex())
+	}
+	return changes, nil
+}
+
+// a changes token is the updatedAt in milliseconds, as stored, and the id of the last driver sent
+func encodeChangesToken(at time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(at.UnixMilli(), 10) + "." + id))
+}
+
+func decodeChangesToken(token string) (time.Time, string, error) {
+	if token == "" {
+		return time.Time{}, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: invalid since token", ErrValidation)
+	}
+	ms, id, ok := strings.Cut(string(raw), ".")
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if !ok || err != nil || !primitive.IsValidObjectID(id) {
+		return time.Time{}, "", fmt.Errorf("%w: invalid since token", ErrValidation)
+	}
+	return time.UnixMilli(millis), id, nil
+}
+
+// rating weighted ordering: averages are pulled towards a prior so a single five star
+// review does not beat a long record; a one star driver ranks as if 50% further away
+const (
+	ratingPrior      = 4.5
+	ratingPriorCount = 5
+	ratingPenalty    = 0.5
+	ratingMaxStars   = 5
+	ratingScaleStars = 4 // best minus worst possible rating
+)
+
+// ratingScore is the ETA stretched by how far the driver's smoothed rating is below the maximum
+func ratingScore(etaMinutes float64, rating *models.DriverRating) float64 {
+	avg := ratingPrior
+	if rating != nil && rating.Count > 0 {
+		avg = (ratingPrior*ratingPriorCount + rating.Average*float64(rating.Count)) / float64(ratingPriorCount+rating.Count)
+	}
+	return etaMinutes * (1 + ratingPenalty*(ratingMaxStars-avg)/ratingScaleStars)
 }
 
-// FindNearby logic: filter by radius and sort by distance
-func (s *driverServiceImpl) FindNearby(ctx context.Context, lat, lon float64, taxiType string) ([]map[string]interface{}, error) {
+// FindNearby logic: search the city given or containing the point, filter by the city's radius
+// and heartbeat, required attributes and minimum rating, sort by distance (default), "eta" or "rating"
+func (s *driverServiceImpl) FindNearby(ctx context.Context, q models.NearbyQuery) ([]map[string]interface{}, error) {
+	lat, lon := q.Lat, q.Lon
+	now := time.Now()
+
+	c, err := resolveCity(s.cities, q.City, lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	query := repository.DriverQuery{City: c.Code, TaxiType: q.TaxiType, Requires: q.Requires}
+	if c.Heartbeat > 0 {
+		query.SeenSince = now.Add(-time.Duration(c.Heartbeat))
+	}
+
 	// 1. Get candidate drivers from DB
-	drivers, err := s.repo.Search(ctx, taxiType)
+	drivers, err := s.repo.Search(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []map[string]interface{}
 
-	// 2. Filter by distance (Haversine)
+	// 2. Filter by distance (Haversine) within the city's radius, and by rating; unrated drivers fail any minimum
+	var inRange []models.Driver
 	for _, d := range drivers {
-		dist := utils.CalculateDistance(lat, lon, d.Location.Lat, d.Location.Lon)
-
-		// Radius check: 6 km
-		if dist <= 6.0 {
-			// Create a response object with distance
-			res := map[string]interface{}{
-				"id":         d.ID,
-				"firstName":  d.FirstName,
-				"lastName":   d.LastName,
-				"plate":      d.Plate,
-				"taxiType":   d.TaxiType,
-				"location":   d.Location,
-				"distanceKm": dist,
+		if q.MinRating > 0 && (d.Rating == nil || d.Rating.Count == 0 || d.Rating.Average < q.MinRating) {
+			continue
+		}
+		if geo.Distance(lat, lon, d.Location.Lat, d.Location.Lon) <= c.RadiusKm {
+			inRange = append(inRange, d)
+		}
+	}
+
+	// 3. Attach the vehicle each driver is currently assigned to
+	if err := s.vehicles.ResolveVehicles(ctx, inRange); err != nil {
+		return nil, err
+	}
+
+	for _, d := range inRange {
+		// report the road distance when the routing graph can answer; one route serves distance and eta
+		dist := geo.Distance(d.Location.Lat, d.Location.Lon, lat, lon)
+		roadKm := dist * s.estimator.Detour()
+		if s.router != nil {
+			if route, ok := s.router.Route(d.Location.Lat, d.Location.Lon, lat, lon); ok {
+				dist, roadKm = route.DistanceKm, route.DistanceKm
 			}
-			results = append(results, res)
 		}
+		eta := s.estimator.MinutesForKm(roadKm, d.Location.Lat, d.Location.Lon, now)
+
+		// Create a response object with distance
+		res := map[string]interface{}{
+			"id":         d.ID,
+			"firstName":  d.FirstName,
+			"lastName":   d.LastName,
+			"plate":      d.Plate,
+			"taxiType":   d.TaxiType,
+			"city":       d.City,
+			"vehicle":    d.Vehicle,
+			"attributes": d.Attributes,
+			"rating":     d.Rating,
+			"location":   d.Location,
+			"distanceKm": dist,
+			"etaMinutes": eta,
+		}
+		if q.Sort == "rating" {
+			res["score"] = ratingScore(eta, d.Rating)
+		}
+		results = append(results, res)
 	}
 
-	// 3. Sort by distance (nearest first)
+	// 4. Sort by distance, arrival time or rating weighted arrival time (lowest first)
+	key := "distanceKm"
+	switch q.Sort {
+	case "eta":
+		key = "etaMinutes"
+	case "rating":
+		key = "score"
+	}
 	sort.Slice(results, func(i, j int) bool {
-		return results[i]["distanceKm"].(float64) < results[j]["distanceKm"].(float64)
+		return results[i][key].(float64) < results[j][key].(float64)
 	})
 
 	return results, nil
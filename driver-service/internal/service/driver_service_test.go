@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tracking"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateLocationRejectsFutureTimestamps(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+
+	for _, tc := range []struct {
+		ts    *time.Time
+		valid bool
+	}{
+		{nil, true},
+		{at(-time.Hour), true},
+		{at(maxClockSkew), true},
+		{at(maxClockSkew + time.Second), false},
+		{at(24 * time.Hour), false},
+	} {
+		err := validateLocation(models.LocationUpdate{Lat: 41, Lon: 29, Ts: tc.ts}, now)
+		if tc.valid != (err == nil) || (err != nil && !errors.Is(err, ErrValidation)) {
+			t.Errorf("ts %v: got %v", tc.ts, err)
+		}
+	}
+}
+
+func TestCreateDriverIgnoresServerFields(t *testing.T) {
+	cities, err := city.NewRegistry(city.DefaultCities, "istanbul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memDrivers{drivers: map[string]*models.Driver{}}
+	s := &driverServiceImpl{
+		repo:     repo,
+		cities:   cities,
+		vehicles: unassignedVehicles{},
+		audit:    nopAudit{},
+		events:   outbox.NewWriter(directTx{}, &memOutbox{}),
+	}
+
+	sent := time.Now().Add(time.Hour)
+	id, err := s.CreateDriver(context.Background(), &models.Driver{
+		FirstName:  "Ayse",
+		Location:   models.Location{Lat: 41.0, Lon: 29.0},
+		Status:     models.DriverApproved,
+		OnShift:    true,
+		Rating:     &models.DriverRating{Average: 5, Count: 1000},
+		LocationAt: &sent,
+		DeletedAt:  &sent,
+	})
+	if err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	stored := repo.drivers[id]
+	if stored.Rating != nil || stored.LocationAt != nil || stored.DeletedAt != nil {
+		t.Fatalf("stored rating %v, locationAt %v, deletedAt %v, want none from the body", stored.Rating, stored.LocationAt, stored.DeletedAt)
+	}
+	if stored.OnShift || stored.Status != models.DriverPending {
+		t.Fatalf("stored onShift %v, status %q, want off shift and pending", stored.OnShift, stored.Status)
+	}
+}
+
+// memDrivers keeps drivers in memory with the tombstone rules of the repository
+type memDrivers struct {
+	repository.DriverRepository
+	drivers map[string]*models.Driver
+	now     time.Time // stamped on writes, before the changes feed settle time
+}
+
+func (r *memDrivers) Create(ctx context.Context, driver *models.Driver) (string, error) {
+	driver.ID = primitive.NewObjectID()
+	copied := *driver
+	r.drivers[driver.ID.Hex()] = &copied
+	return driver.ID.Hex(), nil
+}
+
+func (r *memDrivers) GetByID(ctx context.Context, id string) (*models.Driver, error) {
+	d, ok := r.drivers[id]
+	if !ok || d.DeletedAt != nil {
+		return nil, repository.ErrDriverNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (r *memDrivers) Delete(ctx context.Context, id string) (*models.Driver, error) {
+	d, ok := r.drivers[id]
+	if !ok || d.DeletedAt != nil {
+		return nil, repository.ErrDriverNotFound
+	}
+	at := r.now
+	d.DeletedAt, d.UpdatedAt = &at, at
+	d.Version++
+	copied := *d
+	return &copied, nil
+}
+
+func (r *memDrivers) ChangedSince(ctx context.Context, since time.Time, afterID string, until time.Time, limit int) ([]models.Driver, error) {
+	var changed []models.Driver
+	for _, d := range r.drivers {
+		if d.UpdatedAt.After(since) && !d.UpdatedAt.After(until) {
+			changed = append(changed, *d)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].UpdatedAt.Before(changed[j].UpdatedAt) })
+	if len(changed) > limit {
+		changed = changed[:limit]
+	}
+	return changed, nil
+}
+
+type directTx struct{}
+
+func (directTx) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type memOutbox struct {
+	repository.OutboxRepository
+	events []models.Event
+}
+
+func (r *memOutbox) Append(ctx context.Context, events ...models.Event) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+type nopAudit struct{ AuditService }
+
+func (nopAudit) Record(ctx context.Context, entry *models.AuditEntry, before, after interface{}) error {
+	return nil
+}
+
+type unassignedVehicles struct{ VehicleService }
+
+func (unassignedVehicles) Unassign(ctx context.Context, driverID string) (*models.VehicleAssignment, error) {
+	return nil, repository.ErrAssignmentNotFound
+}
+
+func (unassignedVehicles) ResolveVehicles(ctx context.Context, drivers []models.Driver) error {
+	return nil
+}
+
+func TestDeletedDriverShowsInChanges(t *testing.T) {
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour)
+	id := primitive.NewObjectID()
+	repo := &memDrivers{
+		drivers: map[string]*models.Driver{id.Hex(): {ID: id, FirstName: "Ali", CreatedAt: created, UpdatedAt: created, Version: 1}},
+		now:     time.Now().Add(-time.Minute),
+	}
+	events := &memOutbox{}
+	s := &driverServiceImpl{
+		repo:     repo,
+		filter:   tracking.NewFilter(nil, tracking.Profile{}),
+		vehicles: unassignedVehicles{},
+		audit:    nopAudit{},
+		events:   outbox.NewWriter(directTx{}, events),
+	}
+
+	first, err := s.Changes(ctx, "", 10)
+	if err != nil || len(first.Drivers) != 1 || first.Drivers[0].DeletedAt != nil {
+		t.Fatalf("changes before delete = %+v, %v", first, err)
+	}
+
+	if err := s.DeleteDriver(ctx, id.Hex()); err != nil {
+		t.Fatalf("DeleteDriver: %v", err)
+	}
+	if _, err := s.repo.GetByID(ctx, id.Hex()); !errors.Is(err, repository.ErrDriverNotFound) {
+		t.Fatalf("GetByID after delete: err = %v, want ErrDriverNotFound", err)
+	}
+	if len(events.events) != 1 || events.events[0].Type != models.EventDriverDeleted {
+		t.Fatalf("events = %+v, want one %s", events.events, models.EventDriverDeleted)
+	}
+
+	next, err := s.Changes(ctx, first.Next, 10)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	if len(next.Drivers) != 1 || next.Drivers[0].ID != id || next.Drivers[0].DeletedAt == nil {
+		t.Fatalf("changes after delete = %+v, want the tombstone", next.Drivers)
+	}
+
+	if err := s.DeleteDriver(ctx, id.Hex()); !errors.Is(err, repository.ErrDriverNotFound) {
+		t.Fatalf("second delete: err = %v, want ErrDriverNotFound", err)
+	}
+}
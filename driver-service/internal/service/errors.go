@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+)
+
+// ErrValidation wraps errors caused by bad client input
+var ErrValidation = errors.New("validation failed")
+
+// ErrForbidden is returned when a fleet operator changes data shared by every tenant
+var ErrForbidden = errors.New("platform role required")
+
+// requirePlatform lets platform admins and internal callers through
+func requirePlatform(ctx context.Context) error {
+	if tenant.IsOperator(ctx) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// EtaService manages the speed profiles behind ETA estimates
+type EtaService interface {
+	Load(ctx context.Context) error
+	ListProfiles(ctx context.Context) ([]models.SpeedProfile, error)
+	SetProfiles(ctx context.Context, profiles []models.SpeedProfile) error
+	Learn(ctx context.Context, days, minSamples int) ([]models.SpeedProfile, error)
+}
+
+type etaServiceImpl struct {
+	estimator *eta.Estimator
+	profiles  repository.SpeedProfileRepository
+	trips     repository.TripRepository
+}
+
+// NewEtaService creates service instance
+func NewEtaService(estimator *eta.Estimator, profiles repository.SpeedProfileRepository, trips repository.TripRepository) EtaService {
+	return &etaServiceImpl{estimator: estimator, profiles: profiles, trips: trips}
+}
+
+// Load puts the stored profiles into the estimator
+func (s *etaServiceImpl) Load(ctx context.Context) error {
+	profiles, err := s.profiles.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.estimator.SetProfiles(profiles)
+	return nil
+}
+
+// ListProfiles logic
+func (s *etaServiceImpl) ListProfiles(ctx context.Context) ([]models.SpeedProfile, error) {
+	return s.profiles.List(ctx)
+}
+
+// SetProfiles replaces the speed table with hand-made profiles
+func (s *etaServiceImpl) SetProfiles(ctx context.Context, profiles []models.SpeedProfile) error {
+	now := time.Now()
+	for i := range profiles {
+		p := &profiles[i]
+		if p.SpeedKmh <= 0 {
+			return fmt.Errorf("%w: speedKmh must be positive", ErrValidation)
+		}
+		if p.Hour < models.AnyHour || p.Hour > 23 || p.Weekday < models.AnyWeekday || p.Weekday > 6 {
+			return fmt.Errorf("%w: hour must be -1..23 and weekday -1..6", ErrValidation)
+		}
+		p.Samples = 0
+		p.UpdatedAt = now
+	}
+
+	if err := s.profiles.ReplaceAll(ctx, profiles); err != nil {
+		return err
+	}
+	s.estimator.SetProfiles(profiles)
+	return nil
+}
+
+// Learn rebuilds the learned profiles from trips completed in the last days. Profiles set by
+// hand stay and win over a learned profile with the same zone, weekday and hour.
+func (s *etaServiceImpl) Learn(ctx context.Context, days, minSamples int) ([]models.SpeedProfile, error) {
+	if days < 1 {
+		days = 30
+	}
+	if minSamples < 1 {
+		minSamples = 5
+	}
+
+	trips, err := s.trips.ListCompletedSince(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.profiles.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		zone          string
+		weekday, hour int
+	}
+	manual := make(map[key]bool)
+	var table []models.SpeedProfile
+	for _, p := range stored {
+		if p.Samples == 0 {
+			manual[key{p.Zone, p.Weekday, p.Hour}] = true
+			table = append(table, p)
+		}
+	}
+
+	var learned []models.SpeedProfile
+	for _, p := range s.estimator.Learn(trips, minSamples) {
+		if !manual[key{p.Zone, p.Weekday, p.Hour}] {
+			learned = append(learned, p)
+		}
+	}
+	if err := s.profiles.ReplaceLearned(ctx, learned); err != nil {
+		return nil, err
+	}
+	s.estimator.SetProfiles(append(table, learned...))
+	return learned, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// FareService prices trips with the tariff that is in force at the time of the trip
+type FareService interface {
+	Estimate(ctx context.Context, req *models.FareEstimateRequest) (*models.FareEstimate, error)
+	PriceTrip(ctx context.Context, tripID string) (*models.FareEstimate, error)
+	AddTariff(ctx context.Context, tariff *models.Tariff) error
+	ListTariffs(ctx context.Context, city, taxiType string) ([]models.Tariff, error)
+	SeedDefaults(ctx context.Context) error
+}
+
+type fareServiceImpl struct {
+	tariffs   repository.TariffRepository
+	trips     repository.TripRepository
+	router    routing.Router // nil when no road network is loaded
+	taxiTypes TaxiTypeService
+	cities    *city.Registry
+}
+
+// NewFareService creates service instance
+func NewFareService(tariffs repository.TariffRepository, trips repository.TripRepository, router routing.Router, taxiTypes TaxiTypeService, cities *city.Registry) FareService {
+	return &fareServiceImpl{tariffs: tariffs, trips: trips, router: router, taxiTypes: taxiTypes, cities: cities}
+}
+
+// Estimate prices a prospective trip with the tariff of its city, using the road distance,
+// or the straight-line distance without a road network
+func (s *fareServiceImpl) Estimate(ctx context.Context, req *models.FareEstimateRequest) (*models.FareEstimate, error) {
+	if req.TaxiType == "" {
+		return nil, fmt.Errorf("%w: taxiType is required", ErrValidation)
+	}
+	if req.WaitingMinutes < 0 {
+		return nil, fmt.Errorf("%w: waitingMinutes cannot be negative", ErrValidation)
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	c, err := resolveCity(s.cities, req.City, req.Pickup.Lat, req.Pickup.Lon)
+	if err != nil {
+		return nil, err
+	}
+	tariff, err := s.findTariff(ctx, c.Code, req.TaxiType, at)
+	if err != nil {
+		return nil, err
+	}
+
+	distance := routing.DistanceKm(s.router, req.Pickup.Lat, req.Pickup.Lon, req.Dropoff.Lat, req.Dropoff.Lon)
+	bridge := geo.CrossesBosphorus(req.Pickup.Lat, req.Pickup.Lon, req.Dropoff.Lat, req.Dropoff.Lon)
+
+	return calculateFare(tariff, distance, req.WaitingMinutes, at, c.Location(), bridge, req.Surcharges)
+}
+
+// PriceTrip re-prices a past trip with the tariff that was in force when it was requested
+func (s *fareServiceImpl) PriceTrip(ctx context.Context, tripID string) (*models.FareEstimate, error) {
+	trip, err := s.trips.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip.Status != models.TripCompleted {
+		return nil, fmt.Errorf("%w: only completed trips can be priced", ErrValidation)
+	}
+	if trip.TaxiType == "" {
+		return nil, fmt.Errorf("%w: trip has no taxi type", ErrValidation)
+	}
+
+	// trips from before cities were introduced fall back to the pickup point
+	c, err := resolveCity(s.cities, trip.City, trip.Pickup.Lat, trip.Pickup.Lon)
+	if err != nil {
+		return nil, err
+	}
+	tariff, err := s.findTariff(ctx, c.Code, trip.TaxiType, trip.RequestedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// the meter waits while the driver is at the pickup point
+	waiting := 0.0
+	if trip.ArrivedAt != nil && trip.StartedAt != nil {
+		waiting = trip.StartedAt.Sub(*trip.ArrivedAt).Minutes()
+	}
+	bridge := geo.CrossesBosphorus(trip.Pickup.Lat, trip.Pickup.Lon, trip.Dropoff.Lat, trip.Dropoff.Lon)
+
+	return calculateFare(tariff, trip.DistanceKm, waiting, *trip.StartedAt, c.Location(), bridge, nil)
+}
+
+// AddTariff stores a new tariff version for a city, the default city when none is given
+func (s *fareServiceImpl) AddTariff(ctx context.Context, tariff *models.Tariff) error {
+	// tariffs apply to every fleet
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
+	if tariff.TaxiType == "" {
+		return fmt.Errorf("%w: taxiType is required", ErrValidation)
+	}
+	if tariff.City == "" {
+		tariff.City = s.cities.Default().Code
+	}
+	if err := checkCity(s.cities, tariff.City); err != nil {
+		return err
+	}
+	if err := s.taxiTypes.Validate(ctx, tariff.TaxiType, tariff.City); err != nil {
+		return err
+	}
+	if tariff.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effectiveFrom is required", ErrValidation)
+	}
+	if tariff.OpeningFee < 0 || tariff.PerKm < 0 || tariff.PerMinuteWaiting < 0 || tariff.MinimumFare < 0 {
+		return fmt.Errorf("%w: fees cannot be negative", ErrValidation)
+	}
+	if tariff.NightMultiplier == 0 {
+		tariff.NightMultiplier = 1
+	}
+	if tariff.Currency == "" {
+		tariff.Currency = "TRY"
+	}
+
+	return s.tariffs.Create(ctx, tariff)
+}
+
+// ListTariffs logic
+func (s *fareServiceImpl) ListTariffs(ctx context.Context, city, taxiType string) ([]models.Tariff, error) {
+	if err := checkCity(s.cities, city); err != nil {
+		return nil, err
+	}
+	return s.tariffs.List(ctx, city, taxiType)
+}
+
+// SeedDefaults inserts a starting tariff for the known taxi types in the default city when the table is empty.
+// The numbers are placeholders and should be replaced with the current UKOME decision.
+func (s *fareServiceImpl) SeedDefaults(ctx context.Context) error {
+	count, err := s.tariffs.Count(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	home := s.cities.Default()
+	since := time.Date(2025, time.January, 1, 0, 0, 0, 0, home.Location())
+	defaults := []models.Tariff{
+		{TaxiType: "yellow", OpeningFee: 54.5, PerKm: 36.3, PerMinuteWaiting: 7.5, MinimumFare: 175},
+		{TaxiType: "turquoise", OpeningFee: 62.5, PerKm: 41.75, PerMinuteWaiting: 8.5, MinimumFare: 200},
+		{TaxiType: "black", OpeningFee: 81.75, PerKm: 54.45, PerMinuteWaiting: 11.25, MinimumFare: 260},
+	}
+
+	for i := range defaults {
+		t := &defaults[i]
+		t.City = home.Code
+		t.EffectiveFrom = since
+		t.NightMultiplier = 1
+		t.NightStartHour = 0
+		t.NightEndHour = 6
+		t.BridgeSurcharge = 47
+		t.Surcharges = map[string]float64{"airport": 50}
+		if err := s.AddTariff(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findTariff follows the catalog's tariff reference; types missing from the catalog use their own tariffs
+func (s *fareServiceImpl) findTariff(ctx context.Context, city, taxiType string, at time.Time) (*models.Tariff, error) {
+	code := taxiType
+	entry, err := s.taxiTypes.GetTaxiType(ctx, taxiType)
+	switch {
+	case err == nil:
+		code = entry.Tariff()
+	case !errors.Is(err, repository.ErrTaxiTypeNotFound):
+		return nil, err
+	}
+	return s.tariffs.FindEffective(ctx, city, code, at)
+}
+
+// calculateFare applies a tariff to a trip and returns the itemized fare; night hours are read in loc
+func calculateFare(t *models.Tariff, distanceKm, waitingMinutes float64, at time.Time, loc *time.Location, bridge bool, surcharges []string) (*models.FareEstimate, error) {
+	est := &models.FareEstimate{
+		City:           t.City,
+		TaxiType:       t.TaxiType,
+		TariffVersion:  t.Version,
+		Currency:       t.Currency,
+		DistanceKm:     round2(distanceKm),
+		WaitingMinutes: round2(waitingMinutes),
+		Night:          isNight(t, at.In(loc)),
+	}
+
+	// metered part: opening + distance + waiting, multiplied at night
+	metered := t.OpeningFee + distanceKm*t.PerKm + waitingMinutes*t.PerMinuteWaiting
+	est.Lines = append(est.Lines,
+		models.FareLine{Name: "opening", Amount: round2(t.OpeningFee)},
+		models.FareLine{Name: "distance", Amount: round2(distanceKm * t.PerKm)},
+	)
+	if waitingMinutes > 0 {
+		est.Lines = append(est.Lines, models.FareLine{Name: "waiting", Amount: round2(waitingMinutes * t.PerMinuteWaiting)})
+	}
+	if est.Night && t.NightMultiplier != 1 {
+		extra := metered * (t.NightMultiplier - 1)
+		est.Lines = append(est.Lines, models.FareLine{Name: "night", Amount: round2(extra)})
+		metered += extra
+	}
+
+	// the minimum fare only covers the metered part, tolls are always on top
+	if metered < t.MinimumFare {
+		est.Lines = append(est.Lines, models.FareLine{Name: "minimum", Amount: round2(t.MinimumFare - metered)})
+		est.MinimumApplied = true
+		metered = t.MinimumFare
+	}
+
+	total := metered
+	if bridge && t.BridgeSurcharge > 0 {
+		est.Lines = append(est.Lines, models.FareLine{Name: "bridge", Amount: round2(t.BridgeSurcharge)})
+		total += t.BridgeSurcharge
+	}
+	for _, name := range surcharges {
+		amount, ok := t.Surcharges[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown surcharge %q", ErrValidation, name)
+		}
+		est.Lines = append(est.Lines, models.FareLine{Name: name, Amount: round2(amount)})
+		total += amount
+	}
+
+	est.Total = round2(total)
+	return est, nil
+}
+
+// isNight reports whether the hour of the local time falls in the tariff's night window, which may wrap midnight
+func isNight(t *models.Tariff, local time.Time) bool {
+	if t.NightStartHour == t.NightEndHour {
+		return false
+	}
+	hour := local.Hour()
+	if t.NightStartHour < t.NightEndHour {
+		return hour >= t.NightStartHour && hour < t.NightEndHour
+	}
+	return hour >= t.NightStartHour || hour < t.NightEndHour
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
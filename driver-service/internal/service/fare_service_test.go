@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+)
+
+// the city's zone is three hours ahead of UTC, night hours are read there
+var fareZone = time.FixedZone("TRT", 3*60*60)
+
+func testTariff() *models.Tariff {
+	return &models.Tariff{
+		City: "istanbul", TaxiType: "yellow", Version: 2, Currency: "TRY",
+		OpeningFee: 20, PerKm: 10, PerMinuteWaiting: 2, MinimumFare: 60,
+		NightMultiplier: 1.5, NightStartHour: 22, NightEndHour: 6,
+		BridgeSurcharge: 15, Surcharges: map[string]float64{"airport": 25},
+	}
+}
+
+func TestIsNightBoundaries(t *testing.T) {
+	local := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, fareZone)
+	}
+	wrapping := testTariff()
+	inside := &models.Tariff{NightStartHour: 1, NightEndHour: 5}
+	never := &models.Tariff{NightStartHour: 0, NightEndHour: 0}
+
+	for _, tc := range []struct {
+		name   string
+		tariff *models.Tariff
+		at     time.Time
+		want   bool
+	}{
+		{"before the window", wrapping, local(21, 59), false},
+		{"window starts", wrapping, local(22, 0), true},
+		{"past midnight", wrapping, local(0, 30), true},
+		{"last night minute", wrapping, local(5, 59), true},
+		{"window ends", wrapping, local(6, 0), false},
+		{"window within a day, start", inside, local(1, 0), true},
+		{"window within a day, end", inside, local(5, 0), false},
+		{"window within a day, before", inside, local(0, 59), false},
+		{"empty window", never, local(0, 0), false},
+	} {
+		if got := isNight(tc.tariff, tc.at); got != tc.want {
+			t.Errorf("%s: isNight at %s = %v, want %v", tc.name, tc.at.Format("15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestCalculateFare(t *testing.T) {
+	// 19:30 UTC is 22:30 in the city: night there, not in UTC
+	nightUTC := time.Date(2026, 10, 19, 19, 30, 0, 0, time.UTC)
+	day := time.Date(2026, 10, 19, 12, 0, 0, 0, fareZone)
+
+	for _, tc := range []struct {
+		name       string
+		distanceKm float64
+		waiting    float64
+		at         time.Time
+		bridge     bool
+		surcharges []string
+		night      bool
+		minimum    bool
+		total      float64
+		lines      map[string]float64
+	}{
+		{
+			name: "day fare", distanceKm: 10, waiting: 5, at: day,
+			total: 130, lines: map[string]float64{"opening": 20, "distance": 100, "waiting": 10},
+		},
+		{
+			name: "night in the city's zone", distanceKm: 10, at: nightUTC, night: true,
+			total: 180, lines: map[string]float64{"opening": 20, "distance": 100, "night": 60},
+		},
+		{
+			name: "minimum fare", distanceKm: 1, at: day, minimum: true,
+			total: 60, lines: map[string]float64{"opening": 20, "distance": 10, "minimum": 30},
+		},
+		{
+			name: "minimum covers the night multiplier", distanceKm: 1, at: nightUTC, night: true, minimum: true,
+			total: 60, lines: map[string]float64{"night": 15, "minimum": 15},
+		},
+		{
+			name: "tolls on top of the minimum", distanceKm: 1, at: day, bridge: true, surcharges: []string{"airport"}, minimum: true,
+			total: 100, lines: map[string]float64{"minimum": 30, "bridge": 15, "airport": 25},
+		},
+		{
+			// the total rounds the exact sum, the rounded lines add up to 105.55
+			name: "rounded to cents", distanceKm: 8.3333, waiting: 1.111, at: day,
+			total: 105.56, lines: map[string]float64{"distance": 83.33, "waiting": 2.22},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			est, err := calculateFare(testTariff(), tc.distanceKm, tc.waiting, tc.at, fareZone, tc.bridge, tc.surcharges)
+			if err != nil {
+				t.Fatalf("calculateFare: %v", err)
+			}
+			if est.Total != tc.total || est.Night != tc.night || est.MinimumApplied != tc.minimum {
+				t.Fatalf("total %v, night %v, minimum %v, want %v, %v, %v", est.Total, est.Night, est.MinimumApplied, tc.total, tc.night, tc.minimum)
+			}
+			got := make(map[string]float64, len(est.Lines))
+			for _, l := range est.Lines {
+				got[l.Name] = l.Amount
+			}
+			for name, want := range tc.lines {
+				if got[name] != want {
+					t.Errorf("line %s = %v, want %v (lines %v)", name, got[name], want, est.Lines)
+				}
+			}
+		})
+	}
+
+	if _, err := calculateFare(testTariff(), 5, 0, day, fareZone, false, []string{"ferry"}); err == nil {
+		t.Fatal("unknown surcharge accepted")
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tenant"
+)
+
+// checkpoints are taken this long after their instant, so pings uploaded late by phones
+// that were briefly offline are part of them
+const checkpointLag = 5 * time.Minute
+
+// FleetService rebuilds the fleet as it was at a point in time
+type FleetService interface {
+	// Snapshot returns the position and onboarding status of every driver that existed at at
+	Snapshot(ctx context.Context, at time.Time) (*models.FleetSnapshot, error)
+	// Checkpoint stores the whole fleet at at unless that checkpoint exists
+	Checkpoint(ctx context.Context, at time.Time) error
+	// Run takes a checkpoint at every multiple of interval
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type fleetServiceImpl struct {
+	drivers     repository.DriverRepository
+	locations   repository.LocationRepository
+	statusLog   repository.StatusLogRepository
+	checkpoints repository.CheckpointRepository
+}
+
+// NewFleetService creates service instance
+func NewFleetService(drivers repository.DriverRepository, locations repository.LocationRepository, statusLog repository.StatusLogRepository, checkpoints repository.CheckpointRepository) FleetService {
+	return &fleetServiceImpl{drivers: drivers, locations: locations, statusLog: statusLog, checkpoints: checkpoints}
+}
+
+// Snapshot logic
+func (s *fleetServiceImpl) Snapshot(ctx context.Context, at time.Time) (*models.FleetSnapshot, error) {
+	if at.After(time.Now()) {
+		return nil, fmt.Errorf("%w: at must not be in the future", ErrValidation)
+	}
+	// without a checkpoint the whole history would be replayed, which only Checkpoint may do
+	checkpointAt, err := s.checkpoints.Latest(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if checkpointAt == nil {
+		return nil, fmt.Errorf("%w: at is before the first fleet checkpoint", ErrValidation)
+	}
+	return s.rebuild(ctx, at)
+}
+
+// rebuild starts from the last checkpoint at or before at and replays the pings and status
+// transitions since then; without a checkpoint, for the first one, the whole history is replayed
+func (s *fleetServiceImpl) rebuild(ctx context.Context, at time.Time) (*models.FleetSnapshot, error) {
+	snapshot := &models.FleetSnapshot{At: at, Drivers: []models.FleetPosition{}}
+
+	drivers, err := s.drivers.ListCreatedBefore(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(drivers) == 0 {
+		return snapshot, nil
+	}
+
+	var from time.Time
+	base := make(map[string]models.FleetPosition)
+	checkpointAt, err := s.checkpoints.Latest(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if checkpointAt != nil {
+		from = *checkpointAt
+		snapshot.CheckpointAt = checkpointAt
+		positions, err := s.checkpoints.Positions(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range positions {
+			base[p.DriverID] = p
+		}
+	}
+
+	// the location history has no tenant, operators read the pings of their own drivers only
+	var driverIDs []string
+	if tenant.ID(ctx) != "" {
+		driverIDs = make([]string, len(drivers))
+		for i, d := range drivers {
+			driverIDs[i] = d.ID.Hex()
+		}
+	}
+	pings, err := s.locations.LatestBetween(ctx, from, at, driverIDs)
+	if err != nil {
+		return nil, err
+	}
+	lastPing := make(map[string]models.LocationPing, len(pings))
+	for _, p := range pings {
+		lastPing[p.DriverID] = p
+	}
+
+	changes, err := s.statusLog.LatestBetween(ctx, from, at)
+	if err != nil {
+		return nil, err
+	}
+	lastChange := make(map[string]models.DriverStatusChange, len(changes))
+	for _, c := range changes {
+		lastChange[c.DriverID] = c
+	}
+
+	// a driver the checkpoint does not know that did not change since was in the state its
+	// next transition left, or is still in the state it has now
+	var unknown []string
+	for _, d := range drivers {
+		id := d.ID.Hex()
+		if _, ok := base[id]; ok {
+			continue
+		}
+		if _, ok := lastChange[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	later, err := s.statusLog.FirstAfter(ctx, at, unknown)
+	if err != nil {
+		return nil, err
+	}
+	nextChange := make(map[string]models.DriverStatusChange, len(later))
+	for _, c := range later {
+		nextChange[c.DriverID] = c
+	}
+
+	for _, d := range drivers {
+		id := d.ID.Hex()
+		position, known := base[id]
+		position.DriverID = id
+		position.TenantID = d.TenantID
+
+		if c, ok := lastChange[id]; ok {
+			position.Status = c.To
+		} else if !known {
+			position.Status = d.Status
+			if c, ok := nextChange[id]; ok {
+				position.Status = c.From
+			}
+		}
+
+		if p, ok := lastPing[id]; ok {
+			location, ts := p.Location, p.Timestamp
+			position.Location = &location
+			position.LocationAt = &ts
+		}
+
+		snapshot.Drivers = append(snapshot.Drivers, position)
+	}
+
+	return snapshot, nil
+}
+
+// Checkpoint logic; it runs without a tenant and stores every fleet
+func (s *fleetServiceImpl) Checkpoint(ctx context.Context, at time.Time) error {
+	latest, err := s.checkpoints.Latest(ctx, at)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.Equal(at) {
+		return nil
+	}
+
+	snapshot, err := s.rebuild(ctx, at)
+	if err != nil {
+		return err
+	}
+	return s.checkpoints.Save(ctx, at, snapshot.Drivers)
+}
+
+// Run checks for a missing checkpoint on every interval until ctx is done
+func (s *fleetServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(min(interval, checkpointLag))
+	defer ticker.Stop()
+
+	for {
+		at := time.Now().Add(-checkpointLag).Truncate(interval).UTC()
+		if err := s.Checkpoint(ctx, at); err != nil {
+			log.Printf("WARN: fleet checkpoint at %s failed: %v", at.Format(time.RFC3339), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
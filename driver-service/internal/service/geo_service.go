@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/eta"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/routing"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// GeoService answers bulk geometry queries for dispatch planning and ops tooling
+type GeoService interface {
+	Matrix(ctx context.Context, req *models.MatrixRequest) (*models.MatrixResponse, error)
+}
+
+type geoServiceImpl struct {
+	estimator   *eta.Estimator
+	router      routing.Router // nil when no road network is loaded
+	maxElements int
+}
+
+// NewGeoService creates service instance; maxElements caps origins x destinations
+func NewGeoService(estimator *eta.Estimator, router routing.Router, maxElements int) GeoService {
+	return &geoServiceImpl{estimator: estimator, router: router, maxElements: maxElements}
+}
+
+// Matrix computes every origin/destination pair, spreading the rows over all CPU cores
+func (s *geoServiceImpl) Matrix(ctx context.Context, req *models.MatrixRequest) (*models.MatrixResponse, error) {
+	if len(req.Origins) == 0 || len(req.Destinations) == 0 {
+		return nil, fmt.Errorf("%w: origins and destinations are required", ErrValidation)
+	}
+	if n := len(req.Origins) * len(req.Destinations); n > s.maxElements {
+		return nil, fmt.Errorf("%w: matrix has %d elements, limit is %d", ErrValidation, n, s.maxElements)
+	}
+	for _, p := range append(req.Origins[:len(req.Origins):len(req.Origins)], req.Destinations...) {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return nil, fmt.Errorf("%w: coordinate out of range", ErrValidation)
+		}
+	}
+
+	rows := make([][]models.MatrixElement, len(req.Origins))
+	now := time.Now()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rows[i] = s.matrixRow(req.Origins[i], req.Destinations, now)
+			}
+		}()
+	}
+
+	// stop handing out rows once the client is gone
+	var err error
+feed:
+	for i := range req.Origins {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return &models.MatrixResponse{Rows: rows}, nil
+}
+
+// matrixRow computes one origin against every destination
+func (s *geoServiceImpl) matrixRow(from models.Location, destinations []models.Location, at time.Time) []models.MatrixElement {
+	row := make([]models.MatrixElement, len(destinations))
+	for j, to := range destinations {
+		el := models.MatrixElement{
+			DistanceKm: geo.Distance(from.Lat, from.Lon, to.Lat, to.Lon),
+		}
+
+		// eta runs on road distance, or on Haversine x detour like the estimator's own fallback
+		roadKm := el.DistanceKm * s.estimator.Detour()
+		if s.router != nil {
+			if route, ok := s.router.Route(from.Lat, from.Lon, to.Lat, to.Lon); ok {
+				el.DistanceKm = route.DistanceKm
+				el.Routed = true
+				roadKm = route.DistanceKm
+			}
+		}
+		el.EtaMinutes = s.estimator.MinutesForKm(roadKm, from.Lat, from.Lon, at)
+		row[j] = el
+	}
+	return row
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// mergePatch applies a JSON merge patch (RFC 7396) to a JSON document: members of a patch
+// object replace those of the target, null removes them, objects merge recursively and any
+// other value, arrays included, replaces the target as a whole
+func mergePatch(target, patch []byte) ([]byte, error) {
+	var doc, p interface{}
+	if err := decodeJSON(target, &doc); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(doc, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = make(map[string]interface{}, len(members))
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(doc, name)
+			continue
+		}
+		doc[name] = mergeValue(doc[name], value)
+	}
+	return doc
+}
+
+// decodeJSON keeps numbers as written, so large integers survive the round trip
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// the examples of RFC 7396, appendix A
+func TestMergePatch(t *testing.T) {
+	for _, tc := range []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"n":9007199254740993}`, `{"m":1}`, `{"n":9007199254740993,"m":1}`},
+	} {
+		got, err := mergePatch([]byte(tc.target), []byte(tc.patch))
+		if err != nil {
+			t.Fatalf("%s + %s: %v", tc.target, tc.patch, err)
+		}
+		var gotValue, wantValue interface{}
+		if err := decodeJSON(got, &gotValue); err != nil {
+			t.Fatal(err)
+		}
+		if err := decodeJSON([]byte(tc.want), &wantValue); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("%s + %s = %s, want %s", tc.target, tc.patch, got, tc.want)
+		}
+	}
+
+	if _, err := mergePatch([]byte(`{}`), []byte(`{"a":1} {}`)); err == nil {
+		t.Error("trailing data accepted")
+	}
+}
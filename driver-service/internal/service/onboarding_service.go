@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+var (
+	// ErrInvalidStatusChange is returned when a driver cannot move to the requested onboarding state
+	ErrInvalidStatusChange = errors.New("invalid driver status transition")
+	// ErrDriverNotApproved is returned when a driver who is not approved tries to work
+	ErrDriverNotApproved = errors.New("driver is not approved")
+)
+
+// SystemActor marks status changes made by scheduled jobs
+const SystemActor = "system"
+
+// OnboardingService vets drivers and tracks their documents
+type OnboardingService interface {
+	ChangeStatus(ctx context.Context, driverID string, req *models.StatusChangeRequest, actor string) (*models.DriverStatusChange, error)
+	StatusHistory(ctx context.Context, driverID string) ([]models.DriverStatusChange, error)
+	PutDocument(ctx context.Context, doc *models.DriverDocument) error
+	ListDocuments(ctx context.Context, driverID string) ([]models.DriverDocument, error)
+	ExpiringDocuments(ctx context.Context, within time.Duration) ([]models.DriverDocument, error)
+	// SuspendExpired suspends approved drivers with an expired mandatory document
+	SuspendExpired(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type onboardingServiceImpl struct {
+	drivers   repository.DriverRepository
+	statusLog repository.StatusLogRepository
+	documents repository.DocumentRepository
+	mandatory []string
+	audit     AuditService
+	events    *outbox.Writer
+}
+
+// NewOnboardingService creates service instance; mandatory lists the document types needed to work
+func NewOnboardingService(drivers repository.DriverRepository, statusLog repository.StatusLogRepository, documents repository.DocumentRepository, mandatory []string, audit AuditService, events *outbox.Writer) OnboardingService {
+	return &onboardingServiceImpl{drivers: drivers, statusLog: statusLog, documents: documents, mandatory: mandatory, audit: audit, events: events}
+}
+
+// ChangeStatus moves a driver to another onboarding state and records why.
+// Suspensions and rejections need a reason; approval needs valid mandatory documents.
+func (s *onboardingServiceImpl) ChangeStatus(ctx context.Context, driverID string, req *models.StatusChangeRequest, actor string) (*models.DriverStatusChange, error) {
+	driver, err := s.drivers.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	from := driver.Status
+	if from == "" {
+		from = models.DriverApproved
+	}
+
+	if !slices.Contains(models.DriverStatusTransitions[from], req.Status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusChange, from, req.Status)
+	}
+	if (req.Status == models.DriverSuspended || req.Status == models.DriverRejected) && strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrValidation)
+	}
+	if req.Status == models.DriverApproved {
+		if missing, err := s.missingDocuments(ctx, driverID, time.Now()); err != nil {
+			return nil, err
+		} else if len(missing) > 0 {
+			return nil, fmt.Errorf("%w: missing or expired documents: %s", ErrValidation, strings.Join(missing, ", "))
+		}
+	}
+
+	change := &models.DriverStatusChange{
+		DriverID: driverID,
+		From:     from,
+		To:       req.Status,
+		Reason:   req.Reason,
+		Actor:    actor,
+		At:       time.Now(),
+		TenantID: driver.TenantID,
+	}
+	err = s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if err := s.drivers.SetStatus(ctx, driverID, from, req.Status); err != nil {
+			return nil, err
+		}
+		if err := s.statusLog.Add(ctx, change); err != nil {
+			return nil, err
+		}
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditStatusChange, Actor: actor, TenantID: driver.TenantID}
+		if err := s.audit.Record(ctx, entry, map[string]string{"status": from}, map[string]string{"status": req.Status, "reason": req.Reason}); err != nil {
+			return nil, err
+		}
+		return eventsOf(models.EventDriverStatusChanged, driverID, driver.TenantID, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// StatusHistory logic
+func (s *onboardingServiceImpl) StatusHistory(ctx context.Context, driverID string) ([]models.DriverStatusChange, error) {
+	return s.statusLog.ListByDriver(ctx, driverID)
+}
+
+// PutDocument stores the current document of a type for a driver
+func (s *onboardingServiceImpl) PutDocument(ctx context.Context, doc *models.DriverDocument) error {
+	if !slices.Contains(models.DocumentTypes, doc.Type) {
+		return fmt.Errorf("%w: unknown document type %q", ErrValidation, doc.Type)
+	}
+	if doc.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: expiresAt is required", ErrValidation)
+	}
+	if doc.IssuedAt != nil && !doc.ExpiresAt.After(*doc.IssuedAt) {
+		return fmt.Errorf("%w: expiresAt must be after issuedAt", ErrValidation)
+	}
+	driver, err := s.drivers.GetByID(ctx, doc.DriverID)
+	if err != nil {
+		return err
+	}
+	doc.TenantID = driver.TenantID
+	return s.documents.Upsert(ctx, doc)
+}
+
+// ListDocuments logic
+func (s *onboardingServiceImpl) ListDocuments(ctx context.Context, driverID string) ([]models.DriverDocument, error) {
+	return s.documents.ListByDriver(ctx, driverID)
+}
+
+// ExpiringDocuments lists documents of any type that expire within the given time, including expired ones
+func (s *onboardingServiceImpl) ExpiringDocuments(ctx context.Context, within time.Duration) ([]models.DriverDocument, error) {
+	return s.documents.ListExpiring(ctx, nil, time.Now().Add(within))
+}
+
+// SuspendExpired runs the expiry check once and returns how many drivers were suspended
+func (s *onboardingServiceImpl) SuspendExpired(ctx context.Context) (int, error) {
+	expired, err := s.documents.ListExpiring(ctx, s.mandatory, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	byDriver := make(map[string][]string)
+	for _, doc := range expired {
+		byDriver[doc.DriverID] = append(byDriver[doc.DriverID], doc.Type)
+	}
+
+	suspended := 0
+	for driverID, types := range byDriver {
+		driver, err := s.drivers.GetByID(ctx, driverID)
+		if errors.Is(err, repository.ErrDriverNotFound) {
+			continue
+		}
+		if err != nil {
+			return suspended, err
+		}
+		if !driver.CanWork() {
+			continue
+		}
+
+		sort.Strings(types)
+		req := &models.StatusChangeRequest{Status: models.DriverSuspended, Reason: "expired documents: " + strings.Join(types, ", ")}
+		_, err = s.ChangeStatus(ctx, driverID, req, SystemActor)
+		if errors.Is(err, repository.ErrDriverStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return suspended, err
+		}
+		suspended++
+	}
+	return suspended, nil
+}
+
+// Run checks for expired documents on every interval until ctx is done
+func (s *onboardingServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.SuspendExpired(ctx); err != nil {
+			log.Printf("WARN: document expiry check failed: %v", err)
+		} else if n > 0 {
+			log.Printf("suspended %d drivers with expired documents", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// missingDocuments lists mandatory document types that are absent or expired at the given time
+func (s *onboardingServiceImpl) missingDocuments(ctx context.Context, driverID string, at time.Time) ([]string, error) {
+	docs, err := s.documents.ListByDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		if doc.ExpiresAt.After(at) {
+			valid[doc.Type] = true
+		}
+	}
+
+	var missing []string
+	for _, t := range s.mandatory {
+		if !valid[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing, nil
+}
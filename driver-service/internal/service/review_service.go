@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// ratingWindow is the span of the rolling average kept on drivers
+const ratingWindow = 30 * 24 * time.Hour
+
+// ratingRefreshMarker stores the window edge the refresh job has passed
+const ratingRefreshMarker = "rating_refresh"
+
+const (
+	maxReviewTags    = 5
+	maxCommentLength = 1000
+)
+
+// ReviewService collects rider reviews and keeps the driver rating aggregates current
+type ReviewService interface {
+	SubmitReview(ctx context.Context, review *models.Review) (*models.Review, error)
+	GetReview(ctx context.Context, id string) (*models.Review, error)
+	ListReviews(ctx context.Context, page, pageSize int, driverID, status string) ([]models.Review, error)
+	Moderate(ctx context.Context, id string, req *models.ModerationRequest) (*models.Review, error)
+	// RefreshRating recomputes the aggregates stored on a driver
+	RefreshRating(ctx context.Context, driverID string) (*models.DriverRating, error)
+	// Run refreshes drivers whose reviews leave the rolling window, at start and on every interval
+	// until ctx is done
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type reviewServiceImpl struct {
+	reviews repository.ReviewRepository
+	trips   repository.TripRepository
+	drivers repository.DriverRepository
+	markers repository.MarkerRepository
+	audit   AuditService
+	events  *outbox.Writer
+}
+
+// NewReviewService creates service instance
+func NewReviewService(reviews repository.ReviewRepository, trips repository.TripRepository, drivers repository.DriverRepository, markers repository.MarkerRepository, audit AuditService, events *outbox.Writer) ReviewService {
+	return &reviewServiceImpl{reviews: reviews, trips: trips, drivers: drivers, markers: markers, audit: audit, events: events}
+}
+
+// SubmitReview rates the driver of a completed trip; each trip can be reviewed once, by its rider
+func (s *reviewServiceImpl) SubmitReview(ctx context.Context, review *models.Review) (*models.Review, error) {
+	if review.TripID == "" {
+		return nil, fmt.Errorf("%w: tripId is required", ErrValidation)
+	}
+	if review.Stars < 1 || review.Stars > 5 {
+		return nil, fmt.Errorf("%w: stars must be between 1 and 5", ErrValidation)
+	}
+	if len([]rune(review.Comment)) > maxCommentLength {
+		return nil, fmt.Errorf("%w: comment is longer than %d characters", ErrValidation, maxCommentLength)
+	}
+
+	var tags []string
+	for _, tag := range review.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(models.ReviewTags, tag) {
+			return nil, fmt.Errorf("%w: unknown tag %q", ErrValidation, tag)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxReviewTags {
+		return nil, fmt.Errorf("%w: at most %d tags", ErrValidation, maxReviewTags)
+	}
+
+	trip, err := s.trips.GetByID(ctx, review.TripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip.Status != models.TripCompleted {
+		return nil, fmt.Errorf("%w: only completed trips can be reviewed", ErrValidation)
+	}
+	if trip.RiderID != "" && review.RiderID != trip.RiderID {
+		return nil, fmt.Errorf("%w: riderId does not match the trip", ErrValidation)
+	}
+
+	review.RiderID = trip.RiderID
+	review.DriverID = trip.DriverID
+	review.TenantID = trip.TenantID
+	review.Tags = tags
+	review.Comment = strings.TrimSpace(review.Comment)
+	review.Status = models.ReviewVisible
+	review.ModerationReason = ""
+	review.ModeratedAt = nil
+	review.CreatedAt = time.Now()
+
+	if err := s.reviews.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	if _, err := s.RefreshRating(ctx, review.DriverID); err != nil {
+		// the review is stored, the next refresh will pick it up
+		log.Printf("WARN: could not refresh rating of driver %s: %v", review.DriverID, err)
+	}
+	return review, nil
+}
+
+// GetReview logic
+func (s *reviewServiceImpl) GetReview(ctx context.Context, id string) (*models.Review, error) {
+	return s.reviews.GetByID(ctx, id)
+}
+
+// ListReviews logic, newest first
+func (s *reviewServiceImpl) ListReviews(ctx context.Context, page, pageSize int, driverID, status string) ([]models.Review, error) {
+	if status != "" && status != models.ReviewVisible && status != models.ReviewHidden {
+		return nil, fmt.Errorf("%w: status must be visible or hidden", ErrValidation)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.reviews.List(ctx, page, pageSize, driverID, status)
+}
+
+// Moderate hides or restores a review; hiding needs a reason. The driver's rating follows.
+func (s *reviewServiceImpl) Moderate(ctx context.Context, id string, req *models.ModerationRequest) (*models.Review, error) {
+	if err := requirePlatform(ctx); err != nil {
+		return nil, err
+	}
+	if req.Status != models.ReviewVisible && req.Status != models.ReviewHidden {
+		return nil, fmt.Errorf("%w: status must be visible or hidden", ErrValidation)
+	}
+	if req.Status == models.ReviewHidden && strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrValidation)
+	}
+
+	review, err := s.reviews.SetStatus(ctx, id, req.Status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.RefreshRating(ctx, review.DriverID); err != nil {
+		log.Printf("WARN: could not refresh rating of driver %s: %v", review.DriverID, err)
+	}
+	return review, nil
+}
+
+// RefreshRating logic
+func (s *reviewServiceImpl) RefreshRating(ctx context.Context, driverID string) (*models.DriverRating, error) {
+	rating, err := s.reviews.Rating(ctx, driverID, time.Now().Add(-ratingWindow))
+	if err != nil {
+		return nil, err
+	}
+	err = s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		driver, err := s.drivers.GetByID(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.drivers.SetRating(ctx, driverID, rating); err != nil {
+			return nil, err
+		}
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditRatingChange, TenantID: driver.TenantID}
+		if err := s.audit.Record(ctx, entry, ratingFields(driver.Rating), ratingFields(rating)); err != nil {
+			return nil, err
+		}
+		return updatedEvents(ctx, s.drivers, driverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
+
+// ratingFields are the aggregates the audit log compares, without the refresh time
+func ratingFields(r *models.DriverRating) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	return map[string]interface{}{"average": r.Average, "count": r.Count, "average30d": r.Average30, "count30d": r.Count30}
+}
+
+// Run keeps the rolling averages correct for drivers who get no new reviews. The edge of the
+// last pass is stored, so reviews that aged out while the service was down are caught up on the
+// first pass; without a stored edge every driver with older reviews is refreshed once.
+func (s *reviewServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.refreshAged(ctx); err != nil {
+			log.Printf("WARN: rating refresh failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshAged refreshes drivers with reviews that crossed the window edge since the stored one;
+// the edge only moves on once all of them are refreshed
+func (s *reviewServiceImpl) refreshAged(ctx context.Context) error {
+	last, err := s.markers.Get(ctx, ratingRefreshMarker)
+	if err != nil {
+		return err
+	}
+	edge := time.Now().Add(-ratingWindow)
+	ids, err := s.reviews.DriverIDsReviewedBetween(ctx, last, edge)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, id := range ids {
+		// reviews of deleted drivers have nothing left to refresh
+		if _, err := s.RefreshRating(ctx, id); err != nil && !errors.Is(err, repository.ErrDriverNotFound) {
+			log.Printf("WARN: could not refresh rating of driver %s: %v", id, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d drivers not refreshed, retrying from %s", failed, len(ids), last.Format(time.RFC3339))
+	}
+	return s.markers.Set(ctx, ratingRefreshMarker, edge)
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/tracking"
+)
+
+// ShiftService records who is driving which vehicle and hands vehicles over between drivers
+type ShiftService interface {
+	StartShift(ctx context.Context, driverID, vehicleID string) (*models.Shift, error)
+	EndShift(ctx context.Context, driverID string) (*models.Shift, error)
+	Handover(ctx context.Context, req *models.HandoverRequest) (*models.Shift, error)
+	ListShifts(ctx context.Context, page, pageSize int, driverID, vehicleID string, activeOnly bool) ([]models.Shift, error)
+	Hours(ctx context.Context, driverID string, from, to time.Time) ([]models.ShiftHours, error)
+}
+
+type shiftServiceImpl struct {
+	shifts      repository.ShiftRepository
+	drivers     repository.DriverRepository
+	vehicles    repository.VehicleRepository
+	assignments repository.AssignmentRepository
+	trips       repository.TripRepository
+	filter      *tracking.Filter
+	audit       AuditService
+	events      *outbox.Writer
+}
+
+// NewShiftService creates service instance
+func NewShiftService(shifts repository.ShiftRepository, drivers repository.DriverRepository, vehicles repository.VehicleRepository,
+	assignments repository.AssignmentRepository, trips repository.TripRepository, filter *tracking.Filter, audit AuditService, events *outbox.Writer) ShiftService {
+	return &shiftServiceImpl{shifts: shifts, drivers: drivers, vehicles: vehicles, assignments: assignments, trips: trips, filter: filter, audit: audit, events: events}
+}
+
+// StartShift puts a driver on duty with a vehicle they are assigned to.
+// Without a vehicle id the driver's current assignment is used.
+func (s *shiftServiceImpl) StartShift(ctx context.Context, driverID, vehicleID string) (*models.Shift, error) {
+	if driverID == "" {
+		return nil, fmt.Errorf("%w: driverId is required", ErrValidation)
+	}
+	vehicle, err := s.assignedVehicle(ctx, driverID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.shifts.ActiveByDriver(ctx, driverID); err == nil {
+		return nil, repository.ErrDriverOnShift
+	} else if !errors.Is(err, repository.ErrShiftNotFound) {
+		return nil, err
+	}
+	if _, err := s.shifts.ActiveByVehicle(ctx, vehicle.ID.Hex()); err == nil {
+		return nil, repository.ErrVehicleOnShift
+	} else if !errors.Is(err, repository.ErrShiftNotFound) {
+		return nil, err
+	}
+
+	shift := &models.Shift{
+		DriverID:  driverID,
+		VehicleID: vehicle.ID.Hex(),
+		Plate:     vehicle.Plate,
+		StartedAt: time.Now(),
+		TenantID:  vehicle.TenantID,
+	}
+	err = s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if err := s.shifts.Create(ctx, shift); err != nil {
+			return nil, err
+		}
+		if err := s.setOnShift(ctx, driverID, shift, true); err != nil {
+			return nil, err
+		}
+		return updatedEvents(ctx, s.drivers, driverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// EndShift takes a driver off duty; a driver on an active trip has to finish it first
+func (s *shiftServiceImpl) EndShift(ctx context.Context, driverID string) (*models.Shift, error) {
+	if err := s.ensureNoActiveTrip(ctx, driverID); err != nil {
+		return nil, err
+	}
+
+	var shift *models.Shift
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		var err error
+		if shift, err = s.shifts.End(ctx, driverID, time.Now(), models.ShiftEnded); err != nil {
+			return nil, err
+		}
+		if err := s.setOnShift(ctx, driverID, shift, false); err != nil {
+			return nil, err
+		}
+		return updatedEvents(ctx, s.drivers, driverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// Handover ends the running shift of a vehicle and starts the incoming driver's at the same instant.
+// The incoming driver takes over the vehicle's live location and on duty status.
+func (s *shiftServiceImpl) Handover(ctx context.Context, req *models.HandoverRequest) (*models.Shift, error) {
+	if req.VehicleID == "" || req.ToDriverID == "" {
+		return nil, fmt.Errorf("%w: vehicleId and toDriverId are required", ErrValidation)
+	}
+
+	// checks and writes share one transaction: a concurrent end, start or handover of either
+	// driver conflicts with it instead of leaving half a handover behind
+	var shift *models.Shift
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		current, err := s.shifts.ActiveByVehicle(ctx, req.VehicleID)
+		if err != nil {
+			return nil, err
+		}
+		if current.DriverID == req.ToDriverID {
+			return nil, fmt.Errorf("%w: driver is already on shift in this vehicle", ErrValidation)
+		}
+
+		vehicle, err := s.assignedVehicle(ctx, req.ToDriverID, req.VehicleID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.shifts.ActiveByDriver(ctx, req.ToDriverID); err == nil {
+			return nil, repository.ErrDriverOnShift
+		} else if !errors.Is(err, repository.ErrShiftNotFound) {
+			return nil, err
+		}
+		if err := s.ensureNoActiveTrip(ctx, current.DriverID); err != nil {
+			return nil, err
+		}
+		outgoing, err := s.drivers.GetByID(ctx, current.DriverID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		ended, err := s.shifts.End(ctx, current.DriverID, now, models.ShiftHandover)
+		if err != nil {
+			return nil, err
+		}
+		shift = &models.Shift{
+			DriverID:     req.ToDriverID,
+			VehicleID:    req.VehicleID,
+			Plate:        vehicle.Plate,
+			StartedAt:    now,
+			HandoverFrom: current.DriverID,
+			TenantID:     vehicle.TenantID,
+		}
+		if err := s.shifts.Create(ctx, shift); err != nil {
+			return nil, err
+		}
+
+		// the car is where the outgoing driver last reported it
+		var events []models.Event
+		if outgoing.Location.Lat != 0 || outgoing.Location.Lon != 0 {
+			incoming, err := s.drivers.UpdateLocation(ctx, req.ToDriverID, outgoing.Location)
+			if err != nil {
+				return nil, err
+			}
+			if events, err = eventsOf(models.EventDriverLocationMoved, req.ToDriverID, incoming.TenantID, models.DriverMoved{Location: outgoing.Location, At: now}); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.setOnShift(ctx, current.DriverID, ended, false); err != nil {
+			return nil, err
+		}
+		if err := s.setOnShift(ctx, req.ToDriverID, shift, true); err != nil {
+			return nil, err
+		}
+		updated, err := updatedEvents(ctx, s.drivers, current.DriverID, req.ToDriverID)
+		return append(events, updated...), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the next ping of the incoming driver continues from the transferred position
+	s.filter.Forget(req.ToDriverID)
+	return shift, nil
+}
+
+// setOnShift flips the driver's duty flag and records the shift in the audit log; it runs
+// inside the transaction of the shift write
+func (s *shiftServiceImpl) setOnShift(ctx context.Context, driverID string, shift *models.Shift, onShift bool) error {
+	if err := s.drivers.SetOnShift(ctx, driverID, onShift); err != nil {
+		return err
+	}
+	entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditShiftEnd, TenantID: shift.TenantID}
+	fields := map[string]string{"vehicleId": shift.VehicleID, "plate": shift.Plate}
+	if onShift {
+		entry.Action = models.AuditShiftStart
+		return s.audit.Record(ctx, entry, nil, fields)
+	}
+	return s.audit.Record(ctx, entry, fields, nil)
+}
+
+// ListShifts logic
+func (s *shiftServiceImpl) ListShifts(ctx context.Context, page, pageSize int, driverID, vehicleID string, activeOnly bool) ([]models.Shift, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.shifts.List(ctx, page, pageSize, driverID, vehicleID, activeOnly)
+}
+
+// Hours sums time on shift per driver between from and to; running shifts count until now
+func (s *shiftServiceImpl) Hours(ctx context.Context, driverID string, from, to time.Time) ([]models.ShiftHours, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrValidation)
+	}
+
+	shifts, err := s.shifts.ListOverlapping(ctx, driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byDriver := make(map[string]*models.ShiftHours)
+	for _, sh := range shifts {
+		end := now
+		if sh.EndedAt != nil {
+			end = *sh.EndedAt
+		}
+		start := sh.StartedAt
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		h, ok := byDriver[sh.DriverID]
+		if !ok {
+			h = &models.ShiftHours{DriverID: sh.DriverID}
+			byDriver[sh.DriverID] = h
+		}
+		h.Shifts++
+		h.Hours += end.Sub(start).Hours()
+	}
+
+	result := make([]models.ShiftHours, 0, len(byDriver))
+	for _, h := range byDriver {
+		h.Hours = math.Round(h.Hours*100) / 100
+		result = append(result, *h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Hours > result[j].Hours })
+	return result, nil
+}
+
+// assignedVehicle returns the vehicle the approved driver is assigned to, which must match vehicleID when given
+func (s *shiftServiceImpl) assignedVehicle(ctx context.Context, driverID, vehicleID string) (*models.Vehicle, error) {
+	driver, err := s.drivers.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if !driver.CanWork() {
+		return nil, ErrDriverNotApproved
+	}
+	assignment, err := s.assignments.ActiveByDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if vehicleID != "" && assignment.VehicleID != vehicleID {
+		return nil, fmt.Errorf("%w: driver is not assigned to vehicle %s", ErrValidation, vehicleID)
+	}
+	return s.vehicles.GetByID(ctx, assignment.VehicleID)
+}
+
+func (s *shiftServiceImpl) ensureNoActiveTrip(ctx context.Context, driverID string) error {
+	trip, err := s.trips.FindActiveByDriver(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	if trip != nil {
+		return repository.ErrDriverBusy
+	}
+	return nil
+}
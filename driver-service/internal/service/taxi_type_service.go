@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// ErrTaxiTypeInUse is returned when deleting a taxi type that vehicles or other types still refer to
+var ErrTaxiTypeInUse = errors.New("taxi type still in use")
+
+var (
+	taxiTypeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	hexColorPattern     = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// TaxiTypeService manages the taxi type catalog the rest of the service validates against.
+// The catalog is shared by every tenant, only platform admins change it.
+type TaxiTypeService interface {
+	CreateTaxiType(ctx context.Context, taxiType *models.TaxiType) error
+	GetTaxiType(ctx context.Context, code string) (*models.TaxiType, error)
+	UpdateTaxiType(ctx context.Context, code string, taxiType *models.TaxiType) error
+	DeleteTaxiType(ctx context.Context, code string) error
+	ListTaxiTypes(ctx context.Context, city string) ([]models.TaxiType, error)
+	// Validate returns a validation error when the code is not in the catalog or,
+	// for a non-empty city, not offered there
+	Validate(ctx context.Context, code, city string) error
+	SeedDefaults(ctx context.Context) error
+}
+
+type taxiTypeServiceImpl struct {
+	taxiTypes repository.TaxiTypeRepository
+	vehicles  repository.VehicleRepository
+	cities    *city.Registry
+}
+
+// NewTaxiTypeService creates service instance
+func NewTaxiTypeService(taxiTypes repository.TaxiTypeRepository, vehicles repository.VehicleRepository, cities *city.Registry) TaxiTypeService {
+	return &taxiTypeServiceImpl{taxiTypes: taxiTypes, vehicles: vehicles, cities: cities}
+}
+
+// CreateTaxiType adds a type to the catalog
+func (s *taxiTypeServiceImpl) CreateTaxiType(ctx context.Context, taxiType *models.TaxiType) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
+	taxiType.Code = strings.ToLower(strings.TrimSpace(taxiType.Code))
+	if !taxiTypeCodePattern.MatchString(taxiType.Code) {
+		return fmt.Errorf("%w: code must be lower case letters, digits or underscores", ErrValidation)
+	}
+	if err := s.validate(ctx, taxiType); err != nil {
+		return err
+	}
+	return s.taxiTypes.Create(ctx, taxiType)
+}
+
+// GetTaxiType logic
+func (s *taxiTypeServiceImpl) GetTaxiType(ctx context.Context, code string) (*models.TaxiType, error) {
+	return s.taxiTypes.Get(ctx, code)
+}
+
+// UpdateTaxiType changes names, color, seats, tariff, order and cities; the code stays
+func (s *taxiTypeServiceImpl) UpdateTaxiType(ctx context.Context, code string, taxiType *models.TaxiType) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
+	taxiType.Code = code
+	if err := s.validate(ctx, taxiType); err != nil {
+		return err
+	}
+	return s.taxiTypes.Update(ctx, taxiType)
+}
+
+// DeleteTaxiType removes a type no vehicle is registered with
+func (s *taxiTypeServiceImpl) DeleteTaxiType(ctx context.Context, code string) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
+	if _, err := s.taxiTypes.Get(ctx, code); err != nil {
+		return err
+	}
+
+	count, err := s.vehicles.CountByTaxiType(ctx, code)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d vehicles", ErrTaxiTypeInUse, count)
+	}
+
+	all, err := s.taxiTypes.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range all {
+		if t.Code != code && t.TariffCode == code {
+			return fmt.Errorf("%w: tariff of %s", ErrTaxiTypeInUse, t.Code)
+		}
+	}
+
+	return s.taxiTypes.Delete(ctx, code)
+}
+
+// ListTaxiTypes returns the catalog, optionally only the types offered in a city
+func (s *taxiTypeServiceImpl) ListTaxiTypes(ctx context.Context, city string) ([]models.TaxiType, error) {
+	if err := checkCity(s.cities, city); err != nil {
+		return nil, err
+	}
+	all, err := s.taxiTypes.List(ctx)
+	if err != nil || city == "" {
+		return all, err
+	}
+	offered := all[:0]
+	for _, t := range all {
+		if t.OperatesIn(city) {
+			offered = append(offered, t)
+		}
+	}
+	return offered, nil
+}
+
+// Validate logic
+func (s *taxiTypeServiceImpl) Validate(ctx context.Context, code, city string) error {
+	taxiType, err := s.taxiTypes.Get(ctx, code)
+	if errors.Is(err, repository.ErrTaxiTypeNotFound) {
+		return fmt.Errorf("%w: unknown taxi type %q", ErrValidation, code)
+	}
+	if err != nil {
+		return err
+	}
+	if city != "" && !taxiType.OperatesIn(city) {
+		return fmt.Errorf("%w: taxi type %q is not offered in %s", ErrValidation, code, city)
+	}
+	return nil
+}
+
+// SeedDefaults fills an empty catalog with Istanbul's taxi types
+func (s *taxiTypeServiceImpl) SeedDefaults(ctx context.Context) error {
+	count, err := s.taxiTypes.Count(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	defaults := []models.TaxiType{
+		{Code: "yellow", NameTR: "Sarı Taksi", NameEN: "Yellow Taxi", Color: "#FFD100", Seats: 4, Order: 1},
+		{Code: "turquoise", NameTR: "Turkuaz Taksi", NameEN: "Turquoise Taxi", Color: "#30C5C0", Seats: 4, Order: 2},
+		{Code: "black", NameTR: "Siyah Taksi", NameEN: "Black Taxi", Color: "#1F1F1F", Seats: 4, Order: 3},
+	}
+	for i := range defaults {
+		if err := s.taxiTypes.Create(ctx, &defaults[i]); err != nil && !errors.Is(err, repository.ErrTaxiTypeExists) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *taxiTypeServiceImpl) validate(ctx context.Context, taxiType *models.TaxiType) error {
+	taxiType.NameTR = strings.TrimSpace(taxiType.NameTR)
+	taxiType.NameEN = strings.TrimSpace(taxiType.NameEN)
+	if taxiType.NameTR == "" || taxiType.NameEN == "" {
+		return fmt.Errorf("%w: nameTr and nameEn are required", ErrValidation)
+	}
+	if !hexColorPattern.MatchString(taxiType.Color) {
+		return fmt.Errorf("%w: color must be a hex value like #FFD100", ErrValidation)
+	}
+	if taxiType.Seats < 1 {
+		return fmt.Errorf("%w: seats must be positive", ErrValidation)
+	}
+
+	// cities must be configured; an empty list means everywhere
+	cities := make([]string, 0, len(taxiType.Cities))
+	for _, code := range taxiType.Cities {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if err := checkCity(s.cities, code); err != nil || code == "" {
+			return fmt.Errorf("%w: unknown city %q", ErrValidation, code)
+		}
+		if !slices.Contains(cities, code) {
+			cities = append(cities, code)
+		}
+	}
+	slices.Sort(cities)
+	taxiType.Cities = cities
+
+	// a type may share the tariff of another catalog entry
+	taxiType.TariffCode = strings.ToLower(strings.TrimSpace(taxiType.TariffCode))
+	if taxiType.TariffCode == taxiType.Code {
+		taxiType.TariffCode = ""
+	}
+	if taxiType.TariffCode != "" {
+		ref, err := s.taxiTypes.Get(ctx, taxiType.TariffCode)
+		if errors.Is(err, repository.ErrTaxiTypeNotFound) {
+			return fmt.Errorf("%w: unknown tariff code %q", ErrValidation, taxiType.TariffCode)
+		}
+		if err != nil {
+			return err
+		}
+		// keep references one level deep so tariff lookups never chain
+		if ref.TariffCode != "" {
+			return fmt.Errorf("%w: %s uses the tariff of %s, refer to that instead", ErrValidation, ref.Code, ref.TariffCode)
+		}
+		all, err := s.taxiTypes.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range all {
+			if t.TariffCode == taxiType.Code {
+				return fmt.Errorf("%w: %s uses the tariff of %s", ErrValidation, t.Code, taxiType.Code)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/city"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// ErrInvalidTransition is returned when a trip cannot move to the requested state
+var ErrInvalidTransition = errors.New("invalid trip transition")
+
+// TripService defines trip lifecycle logic
+type TripService interface {
+	RequestTrip(ctx context.Context, trip *models.Trip) (string, error)
+	GetTrip(ctx context.Context, id string) (*models.Trip, error)
+	ListTrips(ctx context.Context, page, pageSize int, driverID, status, city string) ([]models.Trip, error)
+	AcceptTrip(ctx context.Context, id, driverID string) (*models.Trip, error)
+	ArriveTrip(ctx context.Context, id string) (*models.Trip, error)
+	StartTrip(ctx context.Context, id string) (*models.Trip, error)
+	CompleteTrip(ctx context.Context, id string) (*models.Trip, error)
+	CancelTrip(ctx context.Context, id, reason string) (*models.Trip, error)
+}
+
+type tripServiceImpl struct {
+	repo      repository.TripRepository
+	drivers   repository.DriverRepository
+	locations repository.LocationRepository
+	cities    *city.Registry
+}
+
+// NewTripService creates service instance
+func NewTripService(repo repository.TripRepository, drivers repository.DriverRepository, locations repository.LocationRepository, cities *city.Registry) TripService {
+	return &tripServiceImpl{repo: repo, drivers: drivers, locations: locations, cities: cities}
+}
+
+// RequestTrip opens a new trip waiting for a driver in the city of the pickup point, unless given
+func (s *tripServiceImpl) RequestTrip(ctx context.Context, trip *models.Trip) (string, error) {
+	if trip.RiderID == "" {
+		return "", fmt.Errorf("%w: riderId is required", ErrValidation)
+	}
+	c, err := resolveCity(s.cities, trip.City, trip.Pickup.Lat, trip.Pickup.Lon)
+	if err != nil {
+		return "", err
+	}
+	trip.City = c.Code
+
+	trip.Status = models.TripRequested
+	trip.Active = false
+	trip.DriverID = ""
+	trip.RequestedAt = time.Now()
+
+	return s.repo.Create(ctx, trip)
+}
+
+// GetTrip logic
+func (s *tripServiceImpl) GetTrip(ctx context.Context, id string) (*models.Trip, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListTrips logic
+func (s *tripServiceImpl) ListTrips(ctx context.Context, page, pageSize int, driverID, status, city string) ([]models.Trip, error) {
+	if err := checkCity(s.cities, city); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.repo.List(ctx, page, pageSize, driverID, status, city)
+}
+
+// AcceptTrip assigns a driver, who must exist and be free
+func (s *tripServiceImpl) AcceptTrip(ctx context.Context, id, driverID string) (*models.Trip, error) {
+	if driverID == "" {
+		return nil, fmt.Errorf("%w: driverId is required", ErrValidation)
+	}
+	driver, err := s.drivers.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if !driver.CanWork() {
+		return nil, ErrDriverNotApproved
+	}
+
+	active, err := s.repo.FindActiveByDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, repository.ErrDriverBusy
+	}
+
+	return s.transition(ctx, id, models.TripAccepted, func(trip *models.Trip, now time.Time) error {
+		if trip.TaxiType != "" && trip.TaxiType != driver.TaxiType {
+			return fmt.Errorf("%w: trip needs a %s taxi", ErrValidation, trip.TaxiType)
+		}
+		if trip.City != "" && trip.City != driver.City {
+			return fmt.Errorf("%w: trip is in %s, driver works in %s", ErrValidation, trip.City, driver.City)
+		}
+		trip.DriverID = driverID
+		trip.TenantID = driver.TenantID
+		trip.TaxiType = driver.TaxiType
+		trip.AcceptedAt = &now
+		return nil
+	})
+}
+
+// ArriveTrip marks the driver as waiting at the pickup point
+func (s *tripServiceImpl) ArriveTrip(ctx context.Context, id string) (*models.Trip, error) {
+	return s.transition(ctx, id, models.TripArrived, func(trip *models.Trip, now time.Time) error {
+		trip.ArrivedAt = &now
+		return nil
+	})
+}
+
+// StartTrip marks the rider as on board
+func (s *tripServiceImpl) StartTrip(ctx context.Context, id string) (*models.Trip, error) {
+	return s.transition(ctx, id, models.TripStarted, func(trip *models.Trip, now time.Time) error {
+		trip.StartedAt = &now
+		return nil
+	})
+}
+
+// CompleteTrip closes the trip and computes the traveled distance from the driver's pings
+func (s *tripServiceImpl) CompleteTrip(ctx context.Context, id string) (*models.Trip, error) {
+	return s.transition(ctx, id, models.TripCompleted, func(trip *models.Trip, now time.Time) error {
+		pings, err := s.locations.ListByDriver(ctx, trip.DriverID, *trip.StartedAt, now)
+		if err != nil {
+			return err
+		}
+		trip.DistanceKm = pathDistanceKm(pings)
+		trip.CompletedAt = &now
+		return nil
+	})
+}
+
+// CancelTrip aborts a trip before it has started
+func (s *tripServiceImpl) CancelTrip(ctx context.Context, id, reason string) (*models.Trip, error) {
+	return s.transition(ctx, id, models.TripCancelled, func(trip *models.Trip, now time.Time) error {
+		trip.CancelReason = reason
+		trip.CancelledAt = &now
+		return nil
+	})
+}
+
+// transition loads the trip, checks the state machine, applies the change and saves it
+// guarded by the previous status so concurrent transitions cannot both win
+func (s *tripServiceImpl) transition(ctx context.Context, id, to string, apply func(trip *models.Trip, now time.Time) error) (*models.Trip, error) {
+	trip, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTransition(trip.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, trip.Status, to)
+	}
+
+	from := trip.Status
+	if err := apply(trip, time.Now()); err != nil {
+		return nil, err
+	}
+	trip.Status = to
+	trip.Active = models.IsActiveTripStatus(to)
+
+	if err := s.repo.Transition(ctx, trip, from); err != nil {
+		return nil, err
+	}
+
+	return trip, nil
+}
+
+func canTransition(from, to string) bool {
+	for _, next := range models.TripTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// pathDistanceKm sums the Haversine distance between consecutive pings
+func pathDistanceKm(pings []models.LocationPing) float64 {
+	total := 0.0
+	for i := 1; i < len(pings); i++ {
+		prev, cur := pings[i-1].Location, pings[i].Location
+		total += geo.Distance(prev.Lat, prev.Lon, cur.Lat, cur.Lon)
+	}
+	return total
+}
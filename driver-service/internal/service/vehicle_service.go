@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// ErrVehicleInUse is returned when deleting a vehicle that drivers are still assigned to
+var ErrVehicleInUse = errors.New("vehicle still has assigned drivers")
+
+// VehicleService manages vehicles and which driver works with which vehicle
+type VehicleService interface {
+	CreateVehicle(ctx context.Context, vehicle *models.Vehicle) (string, error)
+	GetVehicle(ctx context.Context, id string) (*models.Vehicle, error)
+	UpdateVehicle(ctx context.Context, id string, vehicle *models.Vehicle) error
+	DeleteVehicle(ctx context.Context, id string) error
+	ListVehicles(ctx context.Context, page, pageSize int) ([]models.Vehicle, error)
+
+	Assign(ctx context.Context, driverID, vehicleID string) (*models.VehicleAssignment, error)
+	Unassign(ctx context.Context, driverID string) (*models.VehicleAssignment, error)
+	CurrentVehicle(ctx context.Context, driverID string) (*models.Vehicle, error)
+	// DriverByPlate returns the id of the driver working with the vehicle: the one on shift,
+	// otherwise the only one assigned to it
+	DriverByPlate(ctx context.Context, plate string) (string, error)
+	History(ctx context.Context, driverID, vehicleID string) ([]models.VehicleAssignment, error)
+
+	// RegisterPlate returns the vehicle with the plate, registering it from the given fields when unknown.
+	// It must not run inside a transaction: losing a registration race aborts it.
+	RegisterPlate(ctx context.Context, vehicle *models.Vehicle) (*models.Vehicle, error)
+	// AssignVehicle assigns a vehicle returned by RegisterPlate; it joins the caller's transaction
+	AssignVehicle(ctx context.Context, driverID string, vehicle *models.Vehicle, from time.Time) error
+	// AssignByPlate assigns the vehicle with the plate, registering it from the given fields when unknown
+	AssignByPlate(ctx context.Context, driverID string, vehicle *models.Vehicle, from time.Time) error
+	// ResolveVehicles fills Vehicle on each driver with the currently assigned vehicle
+	ResolveVehicles(ctx context.Context, drivers []models.Driver) error
+	// MigrateFromDrivers splits the car fields of drivers without an assignment into vehicles
+	MigrateFromDrivers(ctx context.Context) (int, error)
+}
+
+type vehicleServiceImpl struct {
+	vehicles    repository.VehicleRepository
+	assignments repository.AssignmentRepository
+	drivers     repository.DriverRepository
+	shifts      repository.ShiftRepository
+	taxiTypes   TaxiTypeService
+	audit       AuditService
+	events      *outbox.Writer
+}
+
+// NewVehicleService creates service instance
+func NewVehicleService(vehicles repository.VehicleRepository, assignments repository.AssignmentRepository, drivers repository.DriverRepository, shifts repository.ShiftRepository, taxiTypes TaxiTypeService, audit AuditService, events *outbox.Writer) VehicleService {
+	return &vehicleServiceImpl{vehicles: vehicles, assignments: assignments, drivers: drivers, shifts: shifts, taxiTypes: taxiTypes, audit: audit, events: events}
+}
+
+// CreateVehicle registers a vehicle
+func (s *vehicleServiceImpl) CreateVehicle(ctx context.Context, vehicle *models.Vehicle) (string, error) {
+	if err := validateVehicle(vehicle); err != nil {
+		return "", err
+	}
+	if err := s.taxiTypes.Validate(ctx, vehicle.TaxiType, ""); err != nil {
+		return "", err
+	}
+	var id string
+	err := s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		var err error
+		if id, err = s.vehicles.Create(ctx, vehicle); err != nil {
+			return nil, err
+		}
+		return nil, s.audit.Record(ctx, &models.AuditEntry{Entity: models.AuditVehicle, EntityID: id, Action: models.AuditCreate, TenantID: vehicle.TenantID}, nil, vehicle)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// GetVehicle logic
+func (s *vehicleServiceImpl) GetVehicle(ctx context.Context, id string) (*models.Vehicle, error) {
+	return s.vehicles.GetByID(ctx, id)
+}
+
+// UpdateVehicle changes a vehicle and refreshes the copy on its assigned drivers
+func (s *vehicleServiceImpl) UpdateVehicle(ctx context.Context, id string, vehicle *models.Vehicle) error {
+	if err := validateVehicle(vehicle); err != nil {
+		return err
+	}
+	if err := s.taxiTypes.Validate(ctx, vehicle.TaxiType, ""); err != nil {
+		return err
+	}
+	before, err := s.vehicles.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// the drivers' copy of the vehicle changes with it
+	return s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if err := s.vehicles.Update(ctx, id, vehicle); err != nil {
+			return nil, err
+		}
+		after, err := s.vehicles.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.audit.Record(ctx, &models.AuditEntry{Entity: models.AuditVehicle, EntityID: id, Action: models.AuditUpdate, TenantID: before.TenantID}, before, after); err != nil {
+			return nil, err
+		}
+
+		assignments, err := s.assignments.ActiveByVehicle(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		driverIDs := make([]string, len(assignments))
+		for i, a := range assignments {
+			driverIDs[i] = a.DriverID
+		}
+		if err := s.drivers.SetVehicle(ctx, driverIDs, vehicle); err != nil {
+			return nil, err
+		}
+		for _, driverID := range driverIDs {
+			entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditVehicleChange, TenantID: before.TenantID}
+			if err := s.audit.Record(ctx, entry, vehicleFields(before), vehicleFields(after)); err != nil {
+				return nil, err
+			}
+		}
+		return updatedEvents(ctx, s.drivers, driverIDs...)
+	})
+}
+
+// DeleteVehicle removes a vehicle nobody is assigned to; its history stays
+func (s *vehicleServiceImpl) DeleteVehicle(ctx context.Context, id string) error {
+	count, err := s.assignments.CountActiveByVehicle(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVehicleInUse
+	}
+	before, err := s.vehicles.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		if err := s.vehicles.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, s.audit.Record(ctx, &models.AuditEntry{Entity: models.AuditVehicle, EntityID: id, Action: models.AuditDelete, TenantID: before.TenantID}, before, nil)
+	})
+}
+
+// ListVehicles logic
+func (s *vehicleServiceImpl) ListVehicles(ctx context.Context, page, pageSize int) ([]models.Vehicle, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.vehicles.List(ctx, page, pageSize)
+}
+
+// Assign moves a driver to a vehicle, closing the previous assignment
+func (s *vehicleServiceImpl) Assign(ctx context.Context, driverID, vehicleID string) (*models.VehicleAssignment, error) {
+	vehicle, err := s.vehicles.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.assign(ctx, driverID, vehicle, time.Now())
+}
+
+func (s *vehicleServiceImpl) assign(ctx context.Context, driverID string, vehicle *models.Vehicle, from time.Time) (*models.VehicleAssignment, error) {
+	vehicleID := vehicle.ID.Hex()
+
+	// operators only reach their own records; platform admins must not mix fleets either
+	driver, err := s.drivers.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if driver.TenantID != vehicle.TenantID {
+		return nil, fmt.Errorf("%w: driver and vehicle belong to different fleets", ErrValidation)
+	}
+
+	var (
+		previous   map[string]string
+		assignment *models.VehicleAssignment
+		unchanged  bool
+	)
+	err = s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		previous, unchanged = nil, false
+		current, err := s.assignments.ActiveByDriver(ctx, driverID)
+		switch {
+		case err == nil && current.VehicleID == vehicleID:
+			// only the copy on the driver is refreshed
+			assignment, unchanged = current, true
+		case err == nil:
+			if err := s.ensureOffShift(ctx, driverID); err != nil {
+				return nil, err
+			}
+			if _, err := s.assignments.End(ctx, driverID, from); err != nil && !errors.Is(err, repository.ErrAssignmentNotFound) {
+				return nil, err
+			}
+			previous = map[string]string{"vehicleId": current.VehicleID, "plate": driver.Plate}
+		case !errors.Is(err, repository.ErrAssignmentNotFound):
+			return nil, err
+		}
+
+		if !unchanged {
+			assignment = &models.VehicleAssignment{DriverID: driverID, VehicleID: vehicleID, From: from, TenantID: driver.TenantID}
+			if err := s.assignments.Create(ctx, assignment); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.drivers.SetVehicle(ctx, []string{driverID}, vehicle); err != nil {
+			return nil, err
+		}
+		if unchanged {
+			entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditVehicleChange, TenantID: driver.TenantID}
+			err = s.audit.Record(ctx, entry, driverVehicleFields(driver), vehicleFields(vehicle))
+		} else {
+			entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditVehicleAssign, TenantID: driver.TenantID}
+			err = s.audit.Record(ctx, entry, previous, map[string]string{"vehicleId": vehicleID, "plate": vehicle.Plate})
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updatedEvents(ctx, s.drivers, driverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// Unassign ends the driver's current assignment
+func (s *vehicleServiceImpl) Unassign(ctx context.Context, driverID string) (*models.VehicleAssignment, error) {
+	driver, err := s.drivers.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureOffShift(ctx, driverID); err != nil {
+		return nil, err
+	}
+	var assignment *models.VehicleAssignment
+	err = s.events.Write(ctx, func(ctx context.Context) ([]models.Event, error) {
+		var err error
+		if assignment, err = s.assignments.End(ctx, driverID, time.Now()); err != nil {
+			return nil, err
+		}
+		if err := s.drivers.SetVehicle(ctx, []string{driverID}, nil); err != nil {
+			return nil, err
+		}
+		entry := &models.AuditEntry{Entity: models.AuditDriver, EntityID: driverID, Action: models.AuditVehicleUnassign, TenantID: driver.TenantID}
+		if err := s.audit.Record(ctx, entry, map[string]string{"vehicleId": assignment.VehicleID, "plate": driver.Plate}, nil); err != nil {
+			return nil, err
+		}
+		return updatedEvents(ctx, s.drivers, driverID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// CurrentVehicle returns the vehicle the driver is assigned to
+func (s *vehicleServiceImpl) CurrentVehicle(ctx context.Context, driverID string) (*models.Vehicle, error) {
+	assignment, err := s.assignments.ActiveByDriver(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	return s.vehicles.GetByID(ctx, assignment.VehicleID)
+}
+
+// DriverByPlate goes through the plate index instead of scanning drivers; a shared vehicle
+// nobody is on shift with has no single driver and is reported as not found
+func (s *vehicleServiceImpl) DriverByPlate(ctx context.Context, plate string) (string, error) {
+	vehicle, err := s.vehicles.GetByPlate(ctx, plate)
+	if errors.Is(err, repository.ErrVehicleNotFound) {
+		return "", repository.ErrDriverNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	vehicleID := vehicle.ID.Hex()
+
+	shift, err := s.shifts.ActiveByVehicle(ctx, vehicleID)
+	if err == nil {
+		return shift.DriverID, nil
+	}
+	if !errors.Is(err, repository.ErrShiftNotFound) {
+		return "", err
+	}
+	assignments, err := s.assignments.ActiveByVehicle(ctx, vehicleID)
+	if err != nil {
+		return "", err
+	}
+	if len(assignments) != 1 {
+		return "", repository.ErrDriverNotFound
+	}
+	return assignments[0].DriverID, nil
+}
+
+// History lists assignments of a driver or a vehicle, newest first
+func (s *vehicleServiceImpl) History(ctx context.Context, driverID, vehicleID string) ([]models.VehicleAssignment, error) {
+	return s.assignments.List(ctx, driverID, vehicleID)
+}
+
+// AssignByPlate supports clients that still send the car on the driver
+func (s *vehicleServiceImpl) AssignByPlate(ctx context.Context, driverID string, vehicle *models.Vehicle, from time.Time) error {
+	existing, err := s.RegisterPlate(ctx, vehicle)
+	if err != nil {
+		return err
+	}
+	return s.AssignVehicle(ctx, driverID, existing, from)
+}
+
+// RegisterPlate looks the plate up and registers the vehicle when nobody has yet
+func (s *vehicleServiceImpl) RegisterPlate(ctx context.Context, vehicle *models.Vehicle) (*models.Vehicle, error) {
+	existing, err := s.vehicles.GetByPlate(ctx, vehicle.Plate)
+	if errors.Is(err, repository.ErrVehicleNotFound) {
+		if _, err := s.CreateVehicle(ctx, vehicle); err != nil && !errors.Is(err, repository.ErrPlateTaken) {
+			return nil, err
+		}
+		// a concurrent request may have registered the plate first, or another fleet owns it
+		existing, err = s.vehicles.GetByPlate(ctx, vehicle.Plate)
+		if errors.Is(err, repository.ErrVehicleNotFound) {
+			return nil, repository.ErrPlateTaken
+		}
+	}
+	return existing, err
+}
+
+// AssignVehicle logic
+func (s *vehicleServiceImpl) AssignVehicle(ctx context.Context, driverID string, vehicle *models.Vehicle, from time.Time) error {
+	_, err := s.assign(ctx, driverID, vehicle, from)
+	return err
+}
+
+// ResolveVehicles looks up the current vehicles of a page of drivers with two queries
+func (s *vehicleServiceImpl) ResolveVehicles(ctx context.Context, drivers []models.Driver) error {
+	ids := make([]string, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.ID.Hex()
+	}
+	current, err := s.assignments.ActiveByDrivers(ctx, ids)
+	if err != nil || len(current) == 0 {
+		return err
+	}
+
+	vehicleIDs := make([]string, 0, len(current))
+	for _, id := range current {
+		vehicleIDs = append(vehicleIDs, id)
+	}
+	vehicles, err := s.vehicles.FindByIDs(ctx, vehicleIDs)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.Vehicle, len(vehicles))
+	for i := range vehicles {
+		byID[vehicles[i].ID.Hex()] = &vehicles[i]
+	}
+
+	for i := range drivers {
+		drivers[i].Vehicle = byID[current[ids[i]]]
+	}
+	return nil
+}
+
+// MigrateFromDrivers creates vehicles from the car fields that used to live on drivers.
+// It is idempotent: drivers that already have an assignment are skipped.
+func (s *vehicleServiceImpl) MigrateFromDrivers(ctx context.Context) (int, error) {
+	const pageSize = 500
+	migrated := 0
+	for page := 1; ; page++ {
+		drivers, err := s.drivers.List(ctx, page, pageSize, "")
+		if err != nil {
+			return migrated, err
+		}
+
+		ids := make([]string, len(drivers))
+		for i, d := range drivers {
+			ids[i] = d.ID.Hex()
+		}
+		current, err := s.assignments.ActiveByDrivers(ctx, ids)
+		if err != nil {
+			return migrated, err
+		}
+
+		for _, d := range drivers {
+			if strings.TrimSpace(d.Plate) == "" || current[d.ID.Hex()] != "" {
+				continue
+			}
+			vehicle := &models.Vehicle{Plate: d.Plate, TaxiType: d.TaxiType, CarBrand: d.CarBrand, CarModel: d.CarModel}
+			if err := s.AssignByPlate(ctx, d.ID.Hex(), vehicle, d.CreatedAt); err != nil {
+				if errors.Is(err, ErrValidation) {
+					log.Printf("WARN: driver %s not migrated: %v", d.ID.Hex(), err)
+					continue
+				}
+				return migrated, fmt.Errorf("driver %s: %w", d.ID.Hex(), err)
+			}
+			migrated++
+		}
+
+		if len(drivers) < pageSize {
+			return migrated, nil
+		}
+	}
+}
+
+// ensureOffShift keeps drivers from leaving the vehicle they are on shift with
+func (s *vehicleServiceImpl) ensureOffShift(ctx context.Context, driverID string) error {
+	_, err := s.shifts.ActiveByDriver(ctx, driverID)
+	switch {
+	case err == nil:
+		return repository.ErrDriverOnShift
+	case errors.Is(err, repository.ErrShiftNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// vehicleFields are the vehicle fields mirrored on its drivers, as the audit log records them
+func vehicleFields(v *models.Vehicle) map[string]string {
+	return map[string]string{"plate": v.Plate, "taxiType": v.TaxiType, "carBrand": v.CarBrand, "carModel": v.CarModel}
+}
+
+// driverVehicleFields are the same fields as the driver's copy holds them
+func driverVehicleFields(d *models.Driver) map[string]string {
+	return map[string]string{"plate": d.Plate, "taxiType": d.TaxiType, "carBrand": d.CarBrand, "carModel": d.CarModel}
+}
+
+func validateVehicle(vehicle *models.Vehicle) error {
+	if models.NormalizePlate(vehicle.Plate) == "" {
+		return fmt.Errorf("%w: plate is required", ErrValidation)
+	}
+	if vehicle.TaxiType == "" {
+		return fmt.Errorf("%w: taxiType is required", ErrValidation)
+	}
+	attrs, err := models.NormalizeAttributes(vehicle.Attributes, models.AttributeScopeVehicle)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	vehicle.Attributes = attrs
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the fakes embed the interfaces, calls the tests do not expect panic on the nil value
+
+type plateVehicles struct {
+	repository.VehicleRepository
+	vehicle *models.Vehicle
+}
+
+func (r plateVehicles) GetByPlate(ctx context.Context, plate string) (*models.Vehicle, error) {
+	if models.NormalizePlate(plate) != r.vehicle.Plate {
+		return nil, repository.ErrVehicleNotFound
+	}
+	return r.vehicle, nil
+}
+
+type vehicleShifts struct {
+	repository.ShiftRepository
+	active map[string]string // vehicle id -> driver id
+}
+
+func (r vehicleShifts) ActiveByVehicle(ctx context.Context, vehicleID string) (*models.Shift, error) {
+	driverID, ok := r.active[vehicleID]
+	if !ok {
+		return nil, repository.ErrShiftNotFound
+	}
+	return &models.Shift{DriverID: driverID, VehicleID: vehicleID}, nil
+}
+
+type vehicleAssignments struct {
+	repository.AssignmentRepository
+	drivers []string
+}
+
+func (r vehicleAssignments) ActiveByVehicle(ctx context.Context, vehicleID string) ([]models.VehicleAssignment, error) {
+	var assignments []models.VehicleAssignment
+	for _, id := range r.drivers {
+		assignments = append(assignments, models.VehicleAssignment{DriverID: id, VehicleID: vehicleID})
+	}
+	return assignments, nil
+}
+
+func TestDriverByPlate(t *testing.T) {
+	vehicle := &models.Vehicle{ID: primitive.NewObjectID(), Plate: "34ABC123"}
+
+	for _, tc := range []struct {
+		name     string
+		onShift  string
+		assigned []string
+		want     string
+	}{
+		{name: "driver on shift wins", onShift: "b", assigned: []string{"a", "b"}, want: "b"},
+		{name: "only assigned driver", assigned: []string{"a"}, want: "a"},
+		{name: "shared vehicle off shift", assigned: []string{"a", "b"}},
+		{name: "nobody assigned"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			shifts := vehicleShifts{active: map[string]string{}}
+			if tc.onShift != "" {
+				shifts.active[vehicle.ID.Hex()] = tc.onShift
+			}
+			s := &vehicleServiceImpl{
+				vehicles:    plateVehicles{vehicle: vehicle},
+				shifts:      shifts,
+				assignments: vehicleAssignments{drivers: tc.assigned},
+			}
+
+			got, err := s.DriverByPlate(context.Background(), "34 abc 123")
+			if tc.want == "" {
+				if !errors.Is(err, repository.ErrDriverNotFound) {
+					t.Fatalf("err = %v, want ErrDriverNotFound", err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("DriverByPlate = %q, %v, want %q", got, err, tc.want)
+			}
+		})
+	}
+
+	s := &vehicleServiceImpl{vehicles: plateVehicles{vehicle: vehicle}}
+	if _, err := s.DriverByPlate(context.Background(), "06XYZ1"); !errors.Is(err, repository.ErrDriverNotFound) {
+		t.Fatalf("unknown plate: err = %v, want ErrDriverNotFound", err)
+	}
+}
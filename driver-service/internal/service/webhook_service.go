@@ -0,0 +1,434 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/outbox"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+)
+
+// headers of a webhook delivery. The signature is "sha256=" followed by the hex HMAC-SHA256 of
+// the timestamp, a dot and the body, keyed with the subscription's secret.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery" // the same on every attempt, for deduplication
+)
+
+const minSecretLength = 16
+
+// WebhookPolicy controls how deliveries are retried
+type WebhookPolicy struct {
+	Timeout     time.Duration // per attempt
+	MaxAttempts int           // a delivery is dead after this many failed attempts
+	RetryBase   time.Duration // wait after the first failure, doubled after each further one
+	RetryMax    time.Duration
+	Retention   time.Duration // delivered entries are kept this long
+	// AllowPrivate lets subscriptions reach private, loopback and link-local addresses,
+	// which are refused otherwise so tenants cannot probe the internal network
+	AllowPrivate bool
+}
+
+// WebhookService manages webhook subscriptions and delivers events to them. As an outbox sink
+// it queues one delivery per matching subscription; Run sends them.
+type WebhookService interface {
+	outbox.Sink
+	// CreateWebhook returns the id; the secret is generated on the subscription when not given
+	CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) (string, error)
+	GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, id string, update *models.WebhookUpdate) error
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListDeliveries(ctx context.Context, subscriptionID, status string, page, pageSize int) ([]models.WebhookDelivery, error)
+	// ReplayDelivery sends a delivery again, whatever its state
+	ReplayDelivery(ctx context.Context, id string) error
+	// ReplayDead sends every dead delivery of a subscription again, returning how many
+	ReplayDead(ctx context.Context, subscriptionID string) (int64, error)
+	// DeliverDue sends every delivery that is due and returns how many succeeded
+	DeliverDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type webhookServiceImpl struct {
+	subs       repository.WebhookRepository
+	deliveries repository.DeliveryRepository
+	policy     WebhookPolicy
+	client     *http.Client
+}
+
+// NewWebhookService creates service instance
+func NewWebhookService(subs repository.WebhookRepository, deliveries repository.DeliveryRepository, policy WebhookPolicy) WebhookService {
+	return &webhookServiceImpl{subs: subs, deliveries: deliveries, policy: policy, client: newWebhookClient(policy)}
+}
+
+// newWebhookClient checks every address it connects to, after DNS resolution, so a receiver
+// cannot point its name at an internal address once registered. Redirects are not followed
+// and count as failures; proxies from the environment are not used.
+func newWebhookClient(policy WebhookPolicy) *http.Client {
+	dialer := &net.Dialer{Timeout: policy.Timeout}
+	if !policy.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("webhook receiver address %s is not public", host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout:   policy.Timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: policy.Timeout},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CreateWebhook starts an active subscription
+func (s *webhookServiceImpl) CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) (string, error) {
+	sub.Active = true
+	if sub.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return "", err
+		}
+		sub.Secret = secret
+	}
+	if err := s.validate(ctx, sub); err != nil {
+		return "", err
+	}
+	return s.subs.Create(ctx, sub)
+}
+
+// GetWebhook returns a subscription without its secret
+func (s *webhookServiceImpl) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	sub, err := s.subs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// UpdateWebhook keeps the secret and active unless they are given
+func (s *webhookServiceImpl) UpdateWebhook(ctx context.Context, id string, update *models.WebhookUpdate) error {
+	sub, err := s.subs.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	resumed := update.Active != nil && *update.Active && !sub.Active
+	sub.URL, sub.Events, sub.Secret = update.URL, update.Events, update.Secret
+	if update.Active != nil {
+		sub.Active = *update.Active
+	}
+	if err := s.validate(ctx, sub); err != nil {
+		return err
+	}
+	if err := s.subs.Update(ctx, id, sub); err != nil {
+		return err
+	}
+	if resumed {
+		// deliveries queued while paused were pushed back, they are sent right away now
+		return s.deliveries.Resume(ctx, id)
+	}
+	return nil
+}
+
+// DeleteWebhook removes a subscription together with its queued and dead deliveries
+func (s *webhookServiceImpl) DeleteWebhook(ctx context.Context, id string) error {
+	if err := s.subs.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.deliveries.DeleteBySubscription(ctx, id)
+}
+
+// ListWebhooks returns the caller's subscriptions without secrets
+func (s *webhookServiceImpl) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs, err := s.subs.List(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// ListDeliveries logic
+func (s *webhookServiceImpl) ListDeliveries(ctx context.Context, subscriptionID, status string, page, pageSize int) ([]models.WebhookDelivery, error) {
+	if status != "" && status != models.DeliveryPending && status != models.DeliveryDelivered && status != models.DeliveryDead {
+		return nil, fmt.Errorf("%w: status must be pending, delivered or dead", ErrValidation)
+	}
+	if _, err := s.subs.GetByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.deliveries.List(ctx, subscriptionID, status, page, pageSize)
+}
+
+// ReplayDelivery logic
+func (s *webhookServiceImpl) ReplayDelivery(ctx context.Context, id string) error {
+	return s.deliveries.Requeue(ctx, id)
+}
+
+// ReplayDead logic
+func (s *webhookServiceImpl) ReplayDead(ctx context.Context, subscriptionID string) (int64, error) {
+	if _, err := s.subs.GetByID(ctx, subscriptionID); err != nil {
+		return 0, err
+	}
+	return s.deliveries.RequeueDead(ctx, subscriptionID)
+}
+
+// Name logic
+func (s *webhookServiceImpl) Name() string {
+	return "webhooks"
+}
+
+// Publish queues the events for the subscriptions that want them, paused ones included
+func (s *webhookServiceImpl) Publish(ctx context.Context, events []models.Event) error {
+	subs, err := s.subs.List(ctx, false)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, e := range events {
+		for i := range subs {
+			if !subs[i].Wants(&e) {
+				continue
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: subs[i].ID.Hex(),
+				Event:          e,
+				Status:         models.DeliveryPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+				TenantID:       subs[i].TenantID,
+			})
+		}
+	}
+	return s.deliveries.Enqueue(ctx, deliveries)
+}
+
+// DeliverDue logic
+func (s *webhookServiceImpl) DeliverDue(ctx context.Context) (int, error) {
+	// a claimed delivery is hidden long enough for one attempt to finish
+	lease := 2*s.policy.Timeout + 5*time.Second
+	delivered := 0
+	for ctx.Err() == nil {
+		delivery, err := s.deliveries.ClaimDue(ctx, time.Now(), lease)
+		if errors.Is(err, repository.ErrDeliveryNotFound) {
+			return delivered, nil
+		}
+		if err != nil {
+			return delivered, err
+		}
+
+		ok, err := s.attempt(ctx, delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, ctx.Err()
+}
+
+// attempt sends a claimed delivery once and stores the outcome
+func (s *webhookServiceImpl) attempt(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	sub, err := s.subs.GetByID(ctx, delivery.SubscriptionID)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		// removed while the delivery was claimed, its deliveries are gone as well
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if !sub.Active {
+		// paused subscriptions keep their queue without using up attempts
+		delivery.NextAttemptAt = now.Add(s.policy.RetryMax)
+		return false, s.deliveries.SaveAttempt(ctx, delivery)
+	}
+
+	delivery.Attempts++
+	code, sendErr := s.send(ctx, sub, delivery)
+	delivery.LastStatusCode = code
+	switch {
+	case sendErr == nil:
+		expires := now.Add(s.policy.Retention)
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.ExpiresAt = &expires
+		delivery.LastError = ""
+	case delivery.Attempts >= s.policy.MaxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = sendErr.Error()
+		log.Printf("WARN: webhook delivery %s to %s is dead after %d attempts: %v", delivery.ID.Hex(), sub.URL, delivery.Attempts, sendErr)
+	default:
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+		delivery.LastError = sendErr.Error()
+	}
+	return sendErr == nil, s.deliveries.SaveAttempt(ctx, delivery)
+}
+
+// send posts the event with its signature; anything but 2xx is a failure
+func (s *webhookServiceImpl) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(sub.Secret, timestamp, body))
+	req.Header.Set(WebhookEventHeader, delivery.Event.Type)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, up to RetryMax, with up to 10% jitter
+// so deliveries that failed together do not retry together
+func (s *webhookServiceImpl) backoff(attempts int) time.Duration {
+	wait := s.policy.RetryMax
+	if shift := attempts - 1; shift < 30 {
+		wait = min(s.policy.RetryBase<<shift, s.policy.RetryMax)
+	}
+	if jitter := int64(wait / 10); jitter > 0 {
+		if n, err := rand.Int(rand.Reader, big.NewInt(jitter)); err == nil {
+			wait += time.Duration(n.Int64())
+		}
+	}
+	return wait
+}
+
+// Run sends due deliveries on every interval until ctx is done
+func (s *webhookServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("WARN: webhook delivery failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SignWebhook computes the signature header value receivers check deliveries against
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validate normalizes a subscription and refuses URLs that resolve to internal addresses
+func (s *webhookServiceImpl) validate(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.URL = strings.TrimSpace(sub.URL)
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrValidation)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not carry credentials", ErrValidation)
+	}
+	if !s.policy.AllowPrivate {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+		if err != nil || len(addrs) == 0 {
+			return fmt.Errorf("%w: url host %s does not resolve", ErrValidation, u.Hostname())
+		}
+		for _, a := range addrs {
+			if !publicIP(a.IP) {
+				return fmt.Errorf("%w: url host %s is not a public address", ErrValidation, u.Hostname())
+			}
+		}
+	}
+	if sub.Secret != "" && len(sub.Secret) < minSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrValidation, minSecretLength)
+	}
+
+	events := make([]string, 0, len(sub.Events))
+	for _, t := range sub.Events {
+		if !slices.Contains(models.EventTypes, t) {
+			return fmt.Errorf("%w: unknown event type %q", ErrValidation, t)
+		}
+		if !slices.Contains(events, t) {
+			events = append(events, t)
+		}
+	}
+	sub.Events = events
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, internal to providers like private ranges
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether an address is reachable on the internet rather than loopback,
+// private, link-local (169.254.169.254 is the cloud metadata service), multicast or unspecified
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,31 @@
+package telematics
+
+import (
+	"log/slog"
+	"os"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// StartBroker runs an in-process MQTT broker without authentication on addr (e.g. ":1883").
+// It is meant for local development and tests, not for production traffic.
+func StartBroker(addr string) (*mochi.Server, error) {
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		return nil, err
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})); err != nil {
+		return nil, err
+	}
+
+	// Serve starts the listeners in the background and returns
+	if err := server.Serve(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
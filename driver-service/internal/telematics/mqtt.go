@@ -0,0 +1,226 @@
+package telematics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/models"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/repository"
+	"github.com/eneszeyt/bitaksi-driver-service/internal/service"
+)
+
+// PlateWildcard marks the topic level that carries the vehicle plate
+const PlateWildcard = "{plate}"
+
+// how long plate lookups are cached; unknown plates are retried sooner
+const (
+	plateTTL        = 5 * time.Minute
+	unknownPlateTTL = 30 * time.Second
+)
+
+// Config describes the broker connection and how pings are batched
+type Config struct {
+	BrokerURL string // e.g. tcp://localhost:1883
+	ClientID  string
+	Username  string
+	Password  string
+	Topic     string // e.g. taxis/{plate}/gps
+
+	BatchSize     int           // pings handed to the pipeline at once
+	BatchInterval time.Duration // longest a ping waits for its batch
+}
+
+// Adapter subscribes to taxi GPS topics and feeds the pings into the same
+// location pipeline as the REST endpoints
+type Adapter struct {
+	cfg        Config
+	service    service.DriverService
+	filter     string // subscription filter, the plate level replaced by '+'
+	plateLevel int
+
+	queue chan models.LocationBatchItem
+
+	mu     sync.Mutex
+	plates map[string]plateEntry
+}
+
+type plateEntry struct {
+	driverID string // empty for unknown plates
+	expires  time.Time
+}
+
+// NewAdapter validates the topic pattern and creates an adapter; call Run to connect
+func NewAdapter(cfg Config, service service.DriverService) (*Adapter, error) {
+	levels := strings.Split(cfg.Topic, "/")
+	plateLevel := -1
+	for i, level := range levels {
+		if level == PlateWildcard {
+			if plateLevel >= 0 {
+				return nil, fmt.Errorf("topic %q has more than one %s", cfg.Topic, PlateWildcard)
+			}
+			plateLevel = i
+			levels[i] = "+"
+		}
+	}
+	if plateLevel < 0 {
+		return nil, fmt.Errorf("topic %q has no %s level", cfg.Topic, PlateWildcard)
+	}
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = 250 * time.Millisecond
+	}
+
+	return &Adapter{
+		cfg:        cfg,
+		service:    service,
+		filter:     strings.Join(levels, "/"),
+		plateLevel: plateLevel,
+		queue:      make(chan models.LocationBatchItem, 4*cfg.BatchSize),
+		plates:     make(map[string]plateEntry),
+	}, nil
+}
+
+// Run connects to the broker and ingests pings until ctx is done
+func (a *Adapter) Run(ctx context.Context) error {
+	opts := mqtt.NewClientOptions().
+		AddBroker(a.cfg.BrokerURL).
+		SetClientID(a.cfg.ClientID).
+		SetUsername(a.cfg.Username).
+		SetPassword(a.cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetCleanSession(false)
+	// subscriptions are renewed on every (re)connect
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		token := c.Subscribe(a.filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+			a.handle(ctx, msg.Topic(), msg.Payload())
+		})
+		if token.Wait() && token.Error() != nil {
+			log.Printf("WARN: mqtt subscribe to %s failed: %v", a.filter, token.Error())
+			return
+		}
+		log.Printf("mqtt subscribed to %s", a.filter)
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("WARN: mqtt connection lost: %v", err)
+	})
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+	defer client.Disconnect(250)
+
+	a.batchLoop(ctx)
+	return nil
+}
+
+// handle turns one message into a queued ping
+func (a *Adapter) handle(ctx context.Context, topic string, payload []byte) {
+	levels := strings.Split(topic, "/")
+	if a.plateLevel >= len(levels) {
+		return
+	}
+	plate := levels[a.plateLevel]
+
+	var update models.LocationUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		log.Printf("WARN: mqtt invalid payload on %s: %v", topic, err)
+		return
+	}
+
+	driverID, err := a.driverID(ctx, plate)
+	if err != nil {
+		log.Printf("WARN: mqtt %s: %v", topic, err)
+		return
+	}
+	if driverID == "" {
+		return
+	}
+
+	// without a timestamp the arrival time counts, not the time the batch is processed
+	if update.Ts == nil {
+		now := time.Now()
+		update.Ts = &now
+	}
+
+	select {
+	case a.queue <- models.LocationBatchItem{DriverID: driverID, LocationUpdate: update}:
+	case <-ctx.Done():
+	}
+}
+
+// driverID resolves a plate through a small cache; "" means no driver has the plate
+func (a *Adapter) driverID(ctx context.Context, plate string) (string, error) {
+	now := time.Now()
+	a.mu.Lock()
+	entry, ok := a.plates[plate]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.driverID, nil
+	}
+
+	entry = plateEntry{expires: now.Add(plateTTL)}
+	driver, err := a.service.GetByPlate(ctx, plate)
+	switch {
+	case errors.Is(err, repository.ErrDriverNotFound):
+		log.Printf("WARN: mqtt no driver with plate %s", plate)
+		entry.expires = now.Add(unknownPlateTTL)
+	case err != nil:
+		return "", err
+	default:
+		entry.driverID = driver.ID.Hex()
+	}
+
+	a.mu.Lock()
+	a.plates[plate] = entry
+	a.mu.Unlock()
+	return entry.driverID, nil
+}
+
+// batchLoop hands queued pings to the pipeline when a batch is full or the interval passed
+func (a *Adapter) batchLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.BatchInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.LocationBatchItem, 0, a.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		results, err := a.service.IngestLocations(context.WithoutCancel(ctx), batch)
+		if err != nil {
+			log.Printf("WARN: mqtt batch of %d pings failed: %v", len(batch), err)
+		}
+		for _, res := range results {
+			if res.Status == models.LocationError {
+				log.Printf("WARN: mqtt ping for driver %s: %s", res.DriverID, res.Error)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case item := <-a.queue:
+			batch = append(batch, item)
+			if len(batch) >= a.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
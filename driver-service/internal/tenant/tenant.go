@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// headers set by the gateway from the caller's token; clients cannot send them through it
+const (
+	HeaderTenant = "X-Tenant-ID"
+	HeaderRole   = "X-Role"
+	HeaderActor  = "X-Actor" // the user the token was issued to
+
+	// HeaderRequestID correlates a request across gateway and service logs
+	HeaderRequestID = "X-Request-ID"
+
+	// HeaderGatewaySecret carries the secret shared with the gateway; the headers above are
+	// only trusted on requests that have it
+	HeaderGatewaySecret = "X-Gateway-Secret"
+
+	// RolePlatform may act across tenants
+	RolePlatform = "platform"
+)
+
+// Scope is who a request acts for
+type Scope struct {
+	TenantID string // the fleet operator; for platform admins an optional narrowing
+	Platform bool
+}
+
+type (
+	scopeKey     struct{}
+	actorKey     struct{}
+	requestIDKey struct{}
+)
+
+// NewContext returns a context carrying the scope
+func NewContext(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// FromContext returns the request's scope; ok is false for internal callers such as background jobs
+func FromContext(ctx context.Context) (s Scope, ok bool) {
+	s, ok = ctx.Value(scopeKey{}).(Scope)
+	return s, ok
+}
+
+// ID returns the tenant queries must be limited to, "" when the caller may see every tenant
+func ID(ctx context.Context) string {
+	s, _ := FromContext(ctx)
+	return s.TenantID
+}
+
+// IsOperator reports whether the caller is a fleet operator rather than a platform admin or internal job
+func IsOperator(ctx context.Context) bool {
+	s, ok := FromContext(ctx)
+	return ok && !s.Platform
+}
+
+// Actor returns who made the request, "" when the gateway did not say
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// RequestID returns the id of the request, "" outside of one
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware reads the scope, actor and request id forwarded by the gateway. Requests without
+// the gateway's secret, and requests that act for neither a tenant nor the platform, are
+// refused, so nothing reaches the handlers unscoped. Requests without an id get a new one,
+// echoed in the response.
+func Middleware(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, requestID)
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderGatewaySecret)), []byte(secret)) != 1 {
+			http.Error(w, "requests must come through the gateway", http.StatusUnauthorized)
+			return
+		}
+
+		id := strings.TrimSpace(r.Header.Get(HeaderTenant))
+		platform := r.Header.Get(HeaderRole) == RolePlatform
+		if id == "" && !platform {
+			http.Error(w, "request has no tenant scope", http.StatusForbidden)
+			return
+		}
+
+		ctx := NewContext(r.Context(), Scope{TenantID: id, Platform: platform})
+		if actor := strings.TrimSpace(r.Header.Get(HeaderActor)); actor != "" {
+			ctx = context.WithValue(ctx, actorKey{}, actor)
+		}
+		ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,208 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/eneszeyt/bitaksi-geo"
+)
+
+// flags attached to pings that failed the plausibility checks
+const (
+	FlagTeleport   = "teleport"     // implied speed above the taxi type's limit
+	FlagOutOfOrder = "out_of_order" // older than the last accepted ping
+)
+
+// what to do with a teleport
+const (
+	ActionReject = "reject" // keep the previous position
+	ActionFlag   = "flag"   // move the driver anyway and mark the ping
+)
+
+// Profile holds the filter thresholds for one taxi type
+type Profile struct {
+	MaxSpeedKmh      float64 `json:"maxSpeedKmh"`      // pings implying a faster move are teleports
+	ProcessNoise     float64 `json:"processNoise"`     // m/s, how fast the true position may drift between pings
+	DefaultAccuracyM float64 `json:"defaultAccuracyM"` // used when the phone does not report accuracy
+	Action           string  `json:"action"`           // "reject" or "flag"
+	MaxRejects       int     `json:"maxRejects"`       // consecutive teleports after which the filter restarts at the new point
+}
+
+// ParseProfiles reads per taxi type overrides like {"black":{"maxSpeedKmh":180}}.
+// Fields left out keep the values of the fallback profile.
+func ParseProfiles(raw string, fallback Profile) (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+	if raw == "" {
+		return profiles, nil
+	}
+
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, err
+	}
+	for taxiType, body := range overrides {
+		p := fallback
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", taxiType, err)
+		}
+		if p.Action != ActionReject && p.Action != ActionFlag {
+			return nil, fmt.Errorf("profile %q: unknown action %q", taxiType, p.Action)
+		}
+		profiles[taxiType] = p
+	}
+	return profiles, nil
+}
+
+// Result is the outcome of running one ping through the filter
+type Result struct {
+	Lat      float64 // smoothed position, the previous one when rejected
+	Lon      float64
+	SpeedKmh float64 // implied speed from the previous accepted ping
+	Flag     string
+	Rejected bool
+}
+
+// state is the filter memory for one driver
+type state struct {
+	taxiType string
+	seeded   bool // false until the first position is known
+
+	lat, lon float64 // smoothed estimate
+	variance float64 // of the estimate, in square meters
+	rawLat   float64 // last accepted raw ping, the reference for speed checks
+	rawLon   float64
+	ts       time.Time
+	rejects  int
+}
+
+// Filter smooths GPS jitter with a per driver Kalman filter and gates impossible jumps.
+// It is safe for concurrent use.
+type Filter struct {
+	profiles map[string]Profile
+	fallback Profile
+
+	mu     sync.Mutex
+	states map[string]*state
+}
+
+// NewFilter creates a filter; taxi types without a profile use the fallback
+func NewFilter(profiles map[string]Profile, fallback Profile) *Filter {
+	return &Filter{
+		profiles: profiles,
+		fallback: fallback,
+		states:   make(map[string]*state),
+	}
+}
+
+// Profile returns the thresholds for a taxi type
+func (f *Filter) Profile(taxiType string) Profile {
+	if p, ok := f.profiles[taxiType]; ok {
+		return p
+	}
+	return f.fallback
+}
+
+// Known reports whether the filter has state for the driver
+func (f *Filter) Known(driverID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.states[driverID]
+	return ok
+}
+
+// Seed starts a driver's filter, e.g. from the stored position after a restart.
+// A zero position only records the taxi type and the first ping is taken as is.
+func (f *Filter) Seed(driverID, taxiType string, lat, lon float64, ts time.Time) {
+	st := &state{taxiType: taxiType}
+	if lat != 0 || lon != 0 {
+		acc := f.Profile(taxiType).DefaultAccuracyM
+		st.seeded = true
+		st.lat, st.lon, st.rawLat, st.rawLon = lat, lon, lat, lon
+		st.variance = acc * acc
+		st.ts = ts
+	}
+
+	f.mu.Lock()
+	f.states[driverID] = st
+	f.mu.Unlock()
+}
+
+// Forget drops a driver's state, e.g. after the taxi type changed
+func (f *Filter) Forget(driverID string) {
+	f.mu.Lock()
+	delete(f.states, driverID)
+	f.mu.Unlock()
+}
+
+// Apply runs a ping through the driver's filter. accuracyM <= 0 means unknown.
+// Drivers that were never seeded are started at this ping.
+func (f *Filter) Apply(driverID string, lat, lon, accuracyM float64, ts time.Time) Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	st, ok := f.states[driverID]
+	if !ok {
+		st = &state{}
+		f.states[driverID] = st
+	}
+	p := f.Profile(st.taxiType)
+	if accuracyM <= 0 {
+		accuracyM = p.DefaultAccuracyM
+	}
+
+	if !st.seeded {
+		st.restart(lat, lon, accuracyM, ts)
+		return Result{Lat: lat, Lon: lon}
+	}
+
+	if !ts.After(st.ts) {
+		return Result{Lat: st.lat, Lon: st.lon, Flag: FlagOutOfOrder, Rejected: true}
+	}
+
+	// distance within the reported accuracy is jitter, not movement
+	dt := ts.Sub(st.ts).Seconds()
+	meters := geo.Distance(st.rawLat, st.rawLon, lat, lon) * 1000
+	speed := math.Max(0, meters-accuracyM) / dt * 3.6
+
+	res := Result{SpeedKmh: speed}
+	if p.MaxSpeedKmh > 0 && speed > p.MaxSpeedKmh {
+		res.Flag = FlagTeleport
+		st.rejects++
+		switch {
+		case p.MaxRejects > 0 && st.rejects >= p.MaxRejects:
+			// the reference point itself was probably wrong, trust the new run of pings
+			st.restart(lat, lon, accuracyM, ts)
+			res.Lat, res.Lon = lat, lon
+			return res
+		case p.Action != ActionFlag:
+			res.Lat, res.Lon = st.lat, st.lon
+			res.Rejected = true
+			return res
+		}
+	} else {
+		st.rejects = 0
+	}
+
+	// constant position model: uncertainty grows with time, the ping pulls the estimate by the Kalman gain
+	st.variance += dt * p.ProcessNoise * p.ProcessNoise
+	gain := st.variance / (st.variance + accuracyM*accuracyM)
+	st.lat += gain * (lat - st.lat)
+	st.lon += gain * (lon - st.lon)
+	st.variance *= 1 - gain
+	st.rawLat, st.rawLon = lat, lon
+	st.ts = ts
+
+	res.Lat, res.Lon = st.lat, st.lon
+	return res
+}
+
+func (st *state) restart(lat, lon, accuracyM float64, ts time.Time) {
+	st.seeded = true
+	st.lat, st.lon, st.rawLat, st.rawLon = lat, lon, lat, lon
+	st.variance = accuracyM * accuracyM
+	st.ts = ts
+	st.rejects = 0
+}
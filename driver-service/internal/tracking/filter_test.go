@@ -0,0 +1,103 @@
+package tracking
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var testProfile = Profile{MaxSpeedKmh: 150, ProcessNoise: 3, DefaultAccuracyM: 10, Action: ActionReject, MaxRejects: 3}
+
+const (
+	startLat, startLon = 41.0, 29.0
+	farLat             = 41.05 // about 5.5 km north
+)
+
+func seeded(p Profile) (*Filter, time.Time) {
+	f := NewFilter(nil, p)
+	t0 := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	f.Seed("d1", "yellow", startLat, startLon, t0)
+	return f, t0
+}
+
+func TestTeleportGate(t *testing.T) {
+	f, t0 := seeded(testProfile)
+
+	res := f.Apply("d1", farLat, startLon, 0, t0.Add(10*time.Second))
+	if !res.Rejected || res.Flag != FlagTeleport {
+		t.Fatalf("jump of 5 km in 10s: %+v, want a rejected teleport", res)
+	}
+	if res.Lat != startLat || res.Lon != startLon {
+		t.Fatalf("rejected ping moved the driver to %v,%v", res.Lat, res.Lon)
+	}
+
+	// a plausible move is accepted and smoothed towards the ping
+	res = f.Apply("d1", 41.0005, startLon, 0, t0.Add(20*time.Second))
+	if res.Rejected || res.Flag != "" {
+		t.Fatalf("55 m in 20s: %+v, want accepted", res)
+	}
+	if res.Lat <= startLat || res.Lat > 41.0005 {
+		t.Fatalf("smoothed lat %v, want between the seed and the ping", res.Lat)
+	}
+
+	flagging := testProfile
+	flagging.Action = ActionFlag
+	f, t0 = seeded(flagging)
+	res = f.Apply("d1", farLat, startLon, 0, t0.Add(10*time.Second))
+	if res.Rejected || res.Flag != FlagTeleport || res.Lat == startLat {
+		t.Fatalf("flag action: %+v, want the driver moved and the ping flagged", res)
+	}
+}
+
+func TestOutOfOrderPingsAreDropped(t *testing.T) {
+	f, t0 := seeded(testProfile)
+
+	accepted := f.Apply("d1", 41.0001, startLon, 0, t0.Add(10*time.Second))
+	if accepted.Rejected {
+		t.Fatalf("in order ping rejected: %+v", accepted)
+	}
+
+	for _, ts := range []time.Time{t0.Add(5 * time.Second), t0.Add(10 * time.Second)} {
+		res := f.Apply("d1", 41.0002, startLon, 0, ts)
+		if !res.Rejected || res.Flag != FlagOutOfOrder {
+			t.Fatalf("ping at %v: %+v, want dropped as out of order", ts, res)
+		}
+		if res.Lat != accepted.Lat || res.Lon != accepted.Lon {
+			t.Fatalf("dropped ping moved the driver to %v,%v", res.Lat, res.Lon)
+		}
+	}
+}
+
+func TestRestartAfterMaxRejects(t *testing.T) {
+	f, t0 := seeded(testProfile)
+
+	// the seed was wrong, the driver keeps reporting from the far point
+	for i := 1; i < testProfile.MaxRejects; i++ {
+		res := f.Apply("d1", farLat, startLon, 0, t0.Add(time.Duration(i)*10*time.Second))
+		if !res.Rejected {
+			t.Fatalf("teleport %d: %+v, want rejected", i, res)
+		}
+	}
+	res := f.Apply("d1", farLat, startLon, 0, t0.Add(time.Duration(testProfile.MaxRejects)*10*time.Second))
+	if res.Rejected || res.Flag != FlagTeleport || res.Lat != farLat {
+		t.Fatalf("teleport %d: %+v, want the filter restarted at the new point", testProfile.MaxRejects, res)
+	}
+
+	// later pings are checked against the new point
+	res = f.Apply("d1", farLat+0.0001, startLon, 0, t0.Add(time.Duration(testProfile.MaxRejects+1)*10*time.Second))
+	if res.Rejected || res.Flag != "" || math.Abs(res.Lat-farLat) > 0.0001 {
+		t.Fatalf("ping after the restart: %+v, want accepted near the new point", res)
+	}
+}
+
+func TestSeedWithoutPositionStartsAtFirstPing(t *testing.T) {
+	f := NewFilter(nil, testProfile)
+	f.Seed("d1", "yellow", 0, 0, time.Time{})
+	if !f.Known("d1") {
+		t.Fatal("seeded driver not known")
+	}
+	res := f.Apply("d1", farLat, startLon, 0, time.Now())
+	if res.Rejected || res.Lat != farLat || res.Lon != startLon {
+		t.Fatalf("first ping: %+v, want taken as is", res)
+	}
+}
@@ -1,28 +0,0 @@
-package utils
-
-import (
-	"math"
-)
-
-const earthRadiusKm = 6371.0 // Earth's mean radius in kilometers
-
-// CalculateDistance returns the distance between two coordinates in kilometers using Haversine formula
-func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	dLat := degreesToRadians(lat2 - lat1)
-	dLon := degreesToRadians(lon2 - lon1)
-
-	lat1Rad := degreesToRadians(lat1)
-	lat2Rad := degreesToRadians(lat2)
-
-	// Haversine formula
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Sin(dLon/2)*math.Sin(dLon/2)*math.Cos(lat1Rad)*math.Cos(lat2Rad)
-
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadiusKm * c
-}
-
-func degreesToRadians(d float64) float64 {
-	return d * math.Pi / 180
-}
@@ -1,6 +1,6 @@
 import { useEffect, useState } from 'react';
 import { MapContainer, TileLayer, Marker, Popup } from 'react-leaflet';
-import { getNearbyDrivers } from '../services/api';
+import { getNearbyDrivers, getTaxiTypes } from '../services/api';
 import 'leaflet/dist/leaflet.css';
 
 // fix for map icons (solving a known bug between leaflet and react)
@@ -18,10 +18,19 @@ L.Marker.prototype.options.icon = DefaultIcon;
 
 const CENTER = [41.0, 29.0];
 
+// dark text on light catalog colors (yellow), light text on dark ones (black)
+const textOn = (hex = '#000000') => {
+  const n = parseInt(hex.slice(1), 16);
+  const luminance = 0.299 * (n >> 16) + 0.587 * ((n >> 8) & 255) + 0.114 * (n & 255);
+  return luminance > 150 ? '#000000' : '#FFFFFF';
+};
+
 export default function MapPage() {
   const [drivers, setDrivers] = useState([]);
   // 1. new feature: filter state
-  const [filterType, setFilterType] = useState('all'); // 'all', 'yellow', 'black'
+  const [filterType, setFilterType] = useState('all'); // 'all' or a taxi type code
+  // filter buttons come from the taxi type catalog
+  const [taxiTypes, setTaxiTypes] = useState([]);
 
   // extracted data fetching function to reuse it
   const loadData = async () => {
@@ -40,14 +49,25 @@ export default function MapPage() {
     }
   };
 
+  // load the catalog once
+  useEffect(() => {
+    getTaxiTypes()
+      .then((res) => setTaxiTypes(res.data || []))
+      .catch((error) => console.error("Failed to fetch taxi types", error));
+  }, []);
+
+  const typeOf = (code) => taxiTypes.find((t) => t.code === code);
+
   // 2. new feature: re-fetch data when filterType changes
   useEffect(() => {
     loadData();
   }, [filterType]);
 
   // 3. new feature: call taxi function
-  const handleCallTaxi = (driverName) => {
-    alert(`🎉 ${driverName} is on the way! Arriving in 3 minutes.`);
+  const handleCallTaxi = (driver) => {
+    // etaMinutes comes from the nearby endpoint
+    const minutes = Math.max(1, Math.round(driver.etaMinutes || 0));
+    alert(`🎉 ${driver.firstName} is on the way! Arriving in ${minutes} minutes.`);
   };
 
   return (
@@ -66,18 +86,16 @@ export default function MapPage() {
             >
                 All
             </button>
-            <button 
-                onClick={() => setFilterType('yellow')}
-                className={`px-3 py-1 rounded text-sm transition ${filterType === 'yellow' ? 'bg-taxi text-black font-bold' : 'text-gray-300 hover:bg-gray-600'}`}
-            >
-                Yellow
-            </button>
-            <button 
-                onClick={() => setFilterType('black')}
-                className={`px-3 py-1 rounded text-sm transition ${filterType === 'black' ? 'bg-black text-white border border-gray-500' : 'text-gray-300 hover:bg-gray-600'}`}
-            >
-                Black
-            </button>
+            {taxiTypes.map((type) => (
+                <button
+                    key={type.code}
+                    onClick={() => setFilterType(type.code)}
+                    style={filterType === type.code ? { backgroundColor: type.color, color: textOn(type.color) } : undefined}
+                    className={`px-3 py-1 rounded text-sm transition ${filterType === type.code ? 'font-bold border border-gray-500' : 'text-gray-300 hover:bg-gray-600'}`}
+                >
+                    {type.nameEn}
+                </button>
+            ))}
         </div>
 
         <div className="flex items-center gap-4">
@@ -111,14 +129,17 @@ export default function MapPage() {
                   <p className="text-gray-600 font-mono text-sm mb-1">{driver.plate}</p>
                   
                   <div className="flex justify-center gap-2 mb-3">
-                    <span className={`px-2 py-0.5 rounded text-xs font-bold inline-block border ${driver.taxiType === 'yellow' ? 'bg-taxi text-black border-yellow-500' : 'bg-black text-white border-gray-600'}`}>
-                        {driver.taxiType === 'yellow' ? 'Yellow Taxi' : 'Black Taxi'}
+                    <span
+                        style={{ backgroundColor: typeOf(driver.taxiType)?.color, color: textOn(typeOf(driver.taxiType)?.color) }}
+                        className="px-2 py-0.5 rounded text-xs font-bold inline-block border border-gray-600"
+                    >
+                        {typeOf(driver.taxiType)?.nameEn || driver.taxiType}
                     </span>
                   </div>
 
                   {/* 5. new feature: call button */}
                   <button 
-                    onClick={() => handleCallTaxi(driver.firstName)}
+                    onClick={() => handleCallTaxi(driver)}
                     className="w-full bg-blue-600 text-white text-sm py-1.5 rounded hover:bg-blue-700 transition"
                   >
                     CALL TAXI
@@ -38,4 +38,7 @@ export const getNearbyDrivers = (lat, lon, type = '') => {
     return api.get(url);
 };
 
+// get the taxi type catalog (code, names, color) for the filter buttons
+export const getTaxiTypes = () => api.get('/taxi-types');
+
 export default api;
\ No newline at end of file
@@ -16,13 +16,43 @@ import (
 type jwtCustomClaims struct {
 	Name  string `json:"name"`
 	Admin bool   `json:"admin"`
+	// Tenant is the fleet operator the user works for; Role "platform" may act across tenants
+	Tenant string `json:"tenant,omitempty"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// headers driver service reads the caller's scope and identity from
+const (
+	headerTenant = "X-Tenant-ID"
+	headerRole   = "X-Role"
+	headerActor  = "X-Actor"
+	rolePlatform = "platform"
+
+	// headerGatewaySecret proves to driver service that a request came through the gateway
+	headerGatewaySecret = "X-Gateway-Secret"
+)
+
+// mockUser is an account of the mock login
+type mockUser struct {
+	password string
+	name     string
+	tenant   string
+	role     string
+}
+
+// users of the mock login (in a real app, check against database)
+var users = map[string]mockUser{
+	"admin":  {password: "password123", name: "Bitaksi Admin", role: rolePlatform},
+	"fleet1": {password: "password123", name: "Fleet One Operator", tenant: "fleet1"},
+	"fleet2": {password: "password123", name: "Fleet Two Operator", tenant: "fleet2"},
+}
+
 func main() {
 	e := echo.New()
 
 	// middleware configurations
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
@@ -30,7 +60,9 @@ func main() {
 	// this is crucial for the react app to communicate with the backend
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"}, // allow all origins (restrict this in production)
-		AllowMethods: []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete},
+		AllowMethods: []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		// the browser only hands these response headers to the app when they are exposed
+		ExposeHeaders: []string{"ETag", "Idempotent-Replayed", echo.HeaderXRequestID},
 	}))
 
 	// --- 1. setup proxy target (driver service) ---
@@ -42,6 +74,12 @@ func main() {
 		e.Logger.Fatal(err)
 	}
 
+	// driver service refuses requests that do not carry this secret
+	gatewaySecret := getEnv("GATEWAY_SECRET", "")
+	if gatewaySecret == "" {
+		e.Logger.Fatal("GATEWAY_SECRET must be set")
+	}
+
 	// create proxy targets and balancer
 	targets := []*middleware.ProxyTarget{{URL: driverServiceURL}}
 	balancer := middleware.NewRoundRobinBalancer(targets)
@@ -70,9 +108,31 @@ func main() {
 	// apply jwt middleware to the group
 	r.Use(echojwt.WithConfig(config))
 
+	// tell driver service who the caller is and which tenant they act for
+	r.Use(forwardIdentity(gatewaySecret))
+
 	// if token is valid, forward the request to driver service (reverse proxy)
 	r.Use(middleware.Proxy(balancer))
 
+	// ride dispatch, trips, fares, eta, geo, batch location, vehicle, shift, document, review, taxi type, city, audit, webhook and fleet endpoints are served by driver service as well.
+	// the colon is escaped so echo does not read it as a path parameter.
+	for _, prefix := range []string{"/dispatch", "/trips", "/fares", "/eta", "/geo", "/locations\\:batch", "/vehicles", "/shifts", "/documents", "/reviews", "/taxi-types", "/cities", "/audit", "/webhooks", "/fleet"} {
+		g := e.Group(prefix)
+		g.Use(echojwt.WithConfig(config))
+		g.Use(forwardIdentity(gatewaySecret))
+		g.Use(middleware.Proxy(balancer))
+	}
+
+	// api documentation is public; only the secret is added
+	docs := e.Group("/swagger")
+	docs.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Request().Header.Set(headerGatewaySecret, gatewaySecret)
+			return next(c)
+		}
+	})
+	docs.Use(middleware.Proxy(balancer))
+
 	// start gateway server
 	e.Logger.Fatal(e.Start(":8000"))
 }
@@ -83,16 +143,21 @@ func login(c echo.Context) error {
 	password := c.FormValue("password")
 
 	// mock check (in a real app, check against database)
-	// expected credentials -> username: admin, password: password123
-	if username != "admin" || password != "password123" {
+	// expected credentials -> username: admin (platform) or fleet1, fleet2 (operators), password: password123
+	user, ok := users[username]
+	if !ok || password != user.password {
 		return echo.ErrUnauthorized
 	}
 
 	// set custom claims for the token
 	claims := &jwtCustomClaims{
-		"Bitaksi Admin",
-		true,
-		jwt.RegisteredClaims{
+		Name:   user.name,
+		Admin:  user.role == rolePlatform,
+		Tenant: user.tenant,
+		Role:   user.role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			// the audit log records changes under this name
+			Subject: username,
 			// token expires in 72 hours
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
 		},
@@ -116,6 +181,54 @@ func login(c echo.Context) error {
 	})
 }
 
+// forwardIdentity replaces any scope and actor headers sent by the client with the ones from
+// the token, passes on the request id and adds the secret driver service checks. Platform
+// admins may narrow a request to one tenant by sending X-Tenant-ID themselves.
+func forwardIdentity(secret string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			requested := req.Header.Get(headerTenant)
+			req.Header.Del(headerTenant)
+			req.Header.Del(headerRole)
+			req.Header.Del(headerActor)
+			req.Header.Set(headerGatewaySecret, secret)
+			req.Header.Set(echo.HeaderXRequestID, c.Response().Header().Get(echo.HeaderXRequestID))
+
+			token, ok := c.Get("user").(*jwt.Token)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+			claims, ok := token.Claims.(*jwtCustomClaims)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+			// tokens issued before the subject was set only carry the display name
+			actor := claims.Subject
+			if actor == "" {
+				actor = claims.Name
+			}
+			if actor != "" {
+				req.Header.Set(headerActor, actor)
+			}
+
+			switch {
+			// tokens issued before roles existed carry only the admin flag
+			case claims.Role == rolePlatform || (claims.Role == "" && claims.Admin):
+				req.Header.Set(headerRole, rolePlatform)
+				if requested != "" {
+					req.Header.Set(headerTenant, requested)
+				}
+			case claims.Tenant != "":
+				req.Header.Set(headerTenant, claims.Tenant)
+			default:
+				return echo.NewHTTPError(http.StatusForbidden, "token has no tenant")
+			}
+			return next(c)
+		}
+	}
+}
+
 // getEnv retrieves the value of the environment variable named by the key.
 // it returns the value, which will be empty if the variable is not present.
 // if the variable is not present, it returns the fallback value.
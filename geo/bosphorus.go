@@ -0,0 +1,42 @@
+package geo
+
+// approximate Bosphorus centerline from the Marmara mouth to the Black Sea mouth (lat, lon)
+var bosphorus = [][2]float64{
+	{41.005, 28.990},
+	{41.025, 29.005},
+	{41.045, 29.035},
+	{41.070, 29.055},
+	{41.090, 29.062},
+	{41.110, 29.070},
+	{41.135, 29.085},
+	{41.170, 29.075},
+	{41.200, 29.110},
+	{41.230, 29.125},
+}
+
+// isAsianSide reports whether a point in Istanbul lies east of the Bosphorus
+func isAsianSide(lat, lon float64) bool {
+	// beyond both mouths the strait is extended straight north/south
+	if lat <= bosphorus[0][0] {
+		return lon > bosphorus[0][1]
+	}
+	last := bosphorus[len(bosphorus)-1]
+	if lat >= last[0] {
+		return lon > last[1]
+	}
+
+	for i := 1; i < len(bosphorus); i++ {
+		a, b := bosphorus[i-1], bosphorus[i]
+		if lat <= b[0] {
+			t := (lat - a[0]) / (b[0] - a[0])
+			return lon > a[1]+t*(b[1]-a[1])
+		}
+	}
+	return false
+}
+
+// CrossesBosphorus reports whether a trip between two points in Istanbul has to cross the strait,
+// i.e. the points lie on different continents. Outside Istanbul the answer is meaningless.
+func CrossesBosphorus(lat1, lon1, lat2, lon2 float64) bool {
+	return isAsianSide(lat1, lon1) != isAsianSide(lat2, lon2)
+}
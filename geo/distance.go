@@ -0,0 +1,107 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the earth's mean radius in kilometers
+const EarthRadiusKm = 6371.0
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Box is a latitude/longitude aligned rectangle
+type Box struct {
+	MinLat float64 `json:"minLat"`
+	MinLon float64 `json:"minLon"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLon float64 `json:"maxLon"`
+}
+
+// Contains reports whether the point lies inside the box, edges included
+func (b Box) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Distance returns the great-circle distance between two coordinates using the Haversine formula
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	lat1Rad := toRadians(lat1)
+	lat2Rad := toRadians(lat2)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Sin(dLon/2)*math.Sin(dLon/2)*math.Cos(lat1Rad)*math.Cos(lat2Rad)
+
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadiusKm * c
+}
+
+// Equirectangular approximates the distance by projecting onto a plane at the mean latitude.
+// It is several times cheaper than Distance and within 0.1% of it for a few tens of kilometers.
+func Equirectangular(lat1, lon1, lat2, lon2 float64) float64 {
+	x := toRadians(normalizeLon(lon2-lon1)) * math.Cos(toRadians((lat1+lat2)/2))
+	y := toRadians(lat2 - lat1)
+	return math.Sqrt(x*x+y*y) * EarthRadiusKm
+}
+
+// Bearing returns the initial compass bearing from the first point to the second in degrees [0, 360)
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadians(lat1), toRadians(lat2)
+	dLon := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLon) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLon)
+
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns the point reached by traveling distanceKm from a start point on a bearing in degrees
+func Destination(lat, lon, bearing, distanceKm float64) (float64, float64) {
+	phi1, lambda1 := toRadians(lat), toRadians(lon)
+	theta := toRadians(bearing)
+	delta := distanceKm / EarthRadiusKm
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2),
+	)
+
+	return toDegrees(phi2), normalizeLon(toDegrees(lambda2))
+}
+
+// BoundingBox returns the smallest box containing every point within radiusKm of the center.
+// Near the poles or across the antimeridian the longitude range is widened to the full circle.
+func BoundingBox(lat, lon, radiusKm float64) Box {
+	dLat := toDegrees(radiusKm / EarthRadiusKm)
+	box := Box{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	dLon := toDegrees(math.Asin(math.Sin(radiusKm/EarthRadiusKm) / math.Cos(toRadians(lat))))
+	if lon-dLon >= -180 && lon+dLon <= 180 {
+		box.MinLon, box.MaxLon = lon-dLon, lon+dLon
+	}
+	return box
+}
+
+func toRadians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func toDegrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
+
+// normalizeLon wraps a longitude into [-180, 180)
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
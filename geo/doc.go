@@ -0,0 +1,6 @@
+// Package geo holds the geometry helpers shared by the TaxiHub services.
+//
+// Coordinates are WGS84 degrees and distances are kilometers unless a name says otherwise.
+// The earth is treated as a sphere with the mean radius, which is well within
+// GPS accuracy for city-scale distances.
+package geo
@@ -0,0 +1,164 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// coord is a random valid coordinate, generated by testing/quick
+type coord struct{ Lat, Lon float64 }
+
+func (coord) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(coord{Lat: r.Float64()*180 - 90, Lon: r.Float64()*360 - 180})
+}
+
+// city is a random coordinate away from the poles, where the city-scale helpers are meant to be used
+type city struct{ Lat, Lon float64 }
+
+func (city) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(city{Lat: r.Float64()*140 - 70, Lon: r.Float64()*360 - 180})
+}
+
+var config = &quick.Config{MaxCount: 2000, Rand: rand.New(rand.NewSource(1))}
+
+func check(t *testing.T, f any) {
+	t.Helper()
+	if err := quick.Check(f, config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDistanceIsAMetric(t *testing.T) {
+	check(t, func(a, b, c coord) bool {
+		ab := Distance(a.Lat, a.Lon, b.Lat, b.Lon)
+		return ab >= 0 &&
+			ab <= math.Pi*EarthRadiusKm+1e-6 &&
+			Distance(a.Lat, a.Lon, a.Lat, a.Lon) == 0 &&
+			math.Abs(ab-Distance(b.Lat, b.Lon, a.Lat, a.Lon)) < 1e-9 &&
+			Distance(a.Lat, a.Lon, c.Lat, c.Lon) <= ab+Distance(b.Lat, b.Lon, c.Lat, c.Lon)+1e-6
+	})
+}
+
+func TestDestinationTravelsTheDistance(t *testing.T) {
+	check(t, func(p city, bearing, distance float64) bool {
+		bearing = math.Mod(math.Abs(bearing), 360)
+		distance = math.Mod(math.Abs(distance), 1000)
+
+		lat, lon := Destination(p.Lat, p.Lon, bearing, distance)
+		return lat >= -90 && lat <= 90 && lon >= -180 && lon < 180 &&
+			math.Abs(Distance(p.Lat, p.Lon, lat, lon)-distance) < 1e-6
+	})
+}
+
+func TestBearingPointsAtTheDestination(t *testing.T) {
+	check(t, func(p city, bearing, distance float64) bool {
+		bearing = math.Mod(math.Abs(bearing), 360)
+		distance = 1 + math.Mod(math.Abs(distance), 100)
+
+		lat, lon := Destination(p.Lat, p.Lon, bearing, distance)
+		got := Bearing(p.Lat, p.Lon, lat, lon)
+		diff := math.Abs(got - bearing)
+		return got >= 0 && got < 360 && math.Min(diff, 360-diff) < 1e-6
+	})
+}
+
+func TestEquirectangularCloseToDistance(t *testing.T) {
+	check(t, func(p city, bearing, distance float64) bool {
+		distance = 0.01 + math.Mod(math.Abs(distance), 30)
+		lat, lon := Destination(p.Lat, p.Lon, math.Mod(math.Abs(bearing), 360), distance)
+
+		exact := Distance(p.Lat, p.Lon, lat, lon)
+		return math.Abs(Equirectangular(p.Lat, p.Lon, lat, lon)-exact) <= exact*0.001
+	})
+}
+
+func TestBoundingBoxContainsTheCircle(t *testing.T) {
+	check(t, func(p coord, bearing, distance, radius float64) bool {
+		radius = 0.1 + math.Mod(math.Abs(radius), 500)
+		distance = math.Mod(math.Abs(distance), radius)
+		lat, lon := Destination(p.Lat, p.Lon, math.Mod(math.Abs(bearing), 360), distance)
+
+		box := BoundingBox(p.Lat, p.Lon, radius)
+		// points on the border may fall out by rounding
+		return box.Contains(lat, lon) ||
+			box.Contains(lat+1e-9*math.Copysign(1, p.Lat-lat), lon+1e-9*math.Copysign(1, p.Lon-lon))
+	})
+}
+
+func TestGeohashCellContainsThePoint(t *testing.T) {
+	check(t, func(p coord, precision uint8) bool {
+		hash := GeohashEncode(p.Lat, p.Lon, 1+int(precision%12))
+		box, err := GeohashBounds(hash)
+		if err != nil || !box.Contains(p.Lat, p.Lon) {
+			return false
+		}
+		lat, lon, err := GeohashDecode(hash)
+		return err == nil && GeohashEncode(lat, lon, len(hash)) == hash
+	})
+}
+
+func TestGeohashNeighborsTouchTheCell(t *testing.T) {
+	check(t, func(p city, precision uint8) bool {
+		hash := GeohashEncode(p.Lat, p.Lon, 1+int(precision%8))
+		box, _ := GeohashBounds(hash)
+		neighbors, err := GeohashNeighbors(hash)
+		if err != nil {
+			return false
+		}
+		for _, n := range neighbors {
+			nbox, err := GeohashBounds(n)
+			if err != nil {
+				return false
+			}
+			// only a cell at a pole is its own neighbor, beyond the pole
+			if n == hash && box.MaxLat < 90 && box.MinLat > -90 {
+				return false
+			}
+			// neighbors share an edge or a corner unless they wrap around the antimeridian
+			if math.Abs(nbox.MinLon-box.MinLon) < 180 &&
+				(nbox.MinLat > box.MaxLat+1e-9 || nbox.MaxLat < box.MinLat-1e-9 ||
+					nbox.MinLon > box.MaxLon+1e-9 || nbox.MaxLon < box.MinLon-1e-9) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	check(t, func(coords []coord) bool {
+		points := make([]Point, len(coords))
+		for i, c := range coords {
+			points[i] = Point{Lat: c.Lat, Lon: c.Lon}
+		}
+
+		decoded, err := DecodePolyline(EncodePolyline(points))
+		if err != nil || len(decoded) != len(points) {
+			return false
+		}
+		for i := range points {
+			if math.Abs(decoded[i].Lat-points[i].Lat) > 0.5e-5+1e-9 || math.Abs(decoded[i].Lon-points[i].Lon) > 0.5e-5+1e-9 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestPointInPolygonSquare(t *testing.T) {
+	square := []Point{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 10}, {Lat: 10, Lon: 10}, {Lat: 10, Lon: 0}}
+	check(t, func(p coord) bool {
+		inside := p.Lat > 0 && p.Lat < 10 && p.Lon > 0 && p.Lon < 10
+		return PointInPolygon(p.Lat, p.Lon, square) == inside
+	})
+}
+
+func TestCrossesBosphorusIsSymmetric(t *testing.T) {
+	check(t, func(a, b city) bool {
+		return CrossesBosphorus(a.Lat, a.Lon, b.Lat, b.Lon) == CrossesBosphorus(b.Lat, b.Lon, a.Lat, a.Lon) &&
+			!CrossesBosphorus(a.Lat, a.Lon, a.Lat, a.Lon)
+	})
+}
@@ -0,0 +1,120 @@
+package geo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidGeohash is returned for empty strings or characters outside the geohash alphabet
+var ErrInvalidGeohash = errors.New("invalid geohash")
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashEncode returns the geohash of a point with the given number of characters (1-12)
+func GeohashEncode(lat, lon float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > 12 {
+		precision = 12
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var sb strings.Builder
+	bit, ch, even := 0, 0, true
+	for sb.Len() < precision {
+		// bits alternate between longitude and latitude, starting with longitude
+		rng, v := &latRange, lat
+		if even {
+			rng, v = &lonRange, lon
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		even = !even
+
+		bit++
+		if bit == 5 {
+			sb.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashBounds returns the cell covered by a geohash
+func GeohashBounds(hash string) (Box, error) {
+	if hash == "" {
+		return Box{}, ErrInvalidGeohash
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		idx := strings.IndexByte(geohashAlphabet, hash[i])
+		if idx < 0 {
+			return Box{}, ErrInvalidGeohash
+		}
+		for b := 4; b >= 0; b-- {
+			rng := &latRange
+			if even {
+				rng = &lonRange
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if idx&(1<<b) != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+
+	return Box{MinLat: latRange[0], MinLon: lonRange[0], MaxLat: latRange[1], MaxLon: lonRange[1]}, nil
+}
+
+// GeohashDecode returns the center of a geohash cell
+func GeohashDecode(hash string) (float64, float64, error) {
+	box, err := GeohashBounds(hash)
+	if err != nil {
+		return 0, 0, err
+	}
+	return (box.MinLat + box.MaxLat) / 2, (box.MinLon + box.MaxLon) / 2, nil
+}
+
+// GeohashNeighbors returns the eight cells around a geohash in the order
+// N, NE, E, SE, S, SW, W, NW. Longitudes wrap around the antimeridian;
+// cells beyond a pole are returned as the clamped polar cell.
+func GeohashNeighbors(hash string) ([8]string, error) {
+	var out [8]string
+	box, err := GeohashBounds(hash)
+	if err != nil {
+		return out, err
+	}
+
+	lat := (box.MinLat + box.MaxLat) / 2
+	lon := (box.MinLon + box.MaxLon) / 2
+	dLat := box.MaxLat - box.MinLat
+	dLon := box.MaxLon - box.MinLon
+
+	offsets := [8][2]float64{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	for i, o := range offsets {
+		nLat := lat + o[0]*dLat
+		if nLat > 90 {
+			nLat = 90 - dLat/2
+		}
+		if nLat < -90 {
+			nLat = -90 + dLat/2
+		}
+		out[i] = GeohashEncode(nLat, normalizeLon(lon+o[1]*dLon), len(hash))
+	}
+	return out, nil
+}
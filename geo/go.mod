@@ -0,0 +1,3 @@
+module github.com/eneszeyt/bitaksi-geo
+
+go 1.24.0
@@ -0,0 +1,16 @@
+package geo
+
+// PointInPolygon reports whether a point lies inside a polygon using ray casting.
+// The polygon is a ring of vertices; closing it by repeating the first vertex is optional.
+// Points exactly on an edge may be reported either way.
+func PointInPolygon(lat, lon float64, polygon []Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > lat) != (b.Lat > lat) &&
+			lon < (b.Lon-a.Lon)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// ErrInvalidPolyline is returned when a string is not a valid encoded polyline
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+// polylineFactor gives the 5 decimal precision of the Google encoded polyline format
+const polylineFactor = 1e5
+
+// EncodePolyline encodes points in the Google encoded polyline format with 5 decimal precision
+func EncodePolyline(points []Point) string {
+	var sb strings.Builder
+	var prevLat, prevLon int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * polylineFactor))
+		lon := int64(math.Round(p.Lon * polylineFactor))
+		encodeSigned(&sb, lat-prevLat)
+		encodeSigned(&sb, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return sb.String()
+}
+
+// DecodePolyline decodes a Google encoded polyline with 5 decimal precision
+func DecodePolyline(s string) ([]Point, error) {
+	var points []Point
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		dLat, n, err := decodeSigned(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLon, n, err := decodeSigned(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lon += dLon
+		points = append(points, Point{Lat: float64(lat) / polylineFactor, Lon: float64(lon) / polylineFactor})
+	}
+	return points, nil
+}
+
+func encodeSigned(sb *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte(0x20|(u&0x1f)) + 63)
+		u >>= 5
+	}
+	sb.WriteByte(byte(u) + 63)
+}
+
+// decodeSigned reads one value and returns it with the number of bytes consumed
+func decodeSigned(s string) (int64, int, error) {
+	var u uint64
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		c := int(s[i]) - 63
+		if c < 0 || c > 0x3f || shift > 60 {
+			return 0, 0, ErrInvalidPolyline
+		}
+		u |= uint64(c&0x1f) << shift
+		shift += 5
+		if c < 0x20 {
+			v := int64(u >> 1)
+			if u&1 != 0 {
+				v = ^v
+			}
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, ErrInvalidPolyline
+}